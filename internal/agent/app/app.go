@@ -18,9 +18,11 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/h2p2f/practicum-metrics/internal/agent/collector"
 	"github.com/h2p2f/practicum-metrics/internal/agent/config"
 	hash2 "github.com/h2p2f/practicum-metrics/internal/agent/hash"
 	"github.com/h2p2f/practicum-metrics/internal/agent/httpclient"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
 	"github.com/h2p2f/practicum-metrics/internal/agent/storage"
 	pb "github.com/h2p2f/practicum-metrics/proto"
 )
@@ -57,38 +59,27 @@ func SendOneMetric(ctx context.Context, logger *zap.Logger, config *config.Agent
 	}
 }
 
-// getRuntimeMetrics launches memory metrics monitoring
-func getRuntimeMetrics(ctx context.Context, m *storage.MetricStorage, poolTime time.Duration) {
-	t := time.NewTicker(poolTime)
-	for {
-		select {
-		case <-ctx.Done():
-			t.Stop()
-			return
-		case <-t.C:
-			if ctx.Err() != nil {
-				return
+// protoMetrics converts the metrics to the gRPC messages
+func protoMetrics(metrics []models.Metric) []*pb.Metric {
+	data := make([]*pb.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		metric := &pb.Metric{Name: m.ID, Type: m.MType, Labels: m.Labels}
+		switch {
+		case m.Value != nil:
+			metric.Gauge = *m.Value
+		case m.Delta != nil:
+			metric.Counter = *m.Delta
+		case m.Histogram != nil:
+			metric.Histogram = &pb.Histogram{
+				Bounds: m.Histogram.Bounds,
+				Counts: m.Histogram.Counts,
+				Count:  m.Histogram.Count,
+				Sum:    m.Histogram.Sum,
 			}
-			m.RuntimeMetricsMonitor()
-		}
-	}
-}
-
-// getGopsUtilMetrics launches gops metrics monitoring
-func getGopsUtilMetrics(ctx context.Context, m *storage.MetricStorage, poolTime time.Duration) {
-	t := time.NewTicker(poolTime)
-	for {
-		select {
-		case <-ctx.Done():
-			t.Stop()
-			return
-		case <-t.C:
-			if ctx.Err() != nil {
-				return
-			}
-			m.GopsUtilizationMonitor()
 		}
+		data = append(data, metric)
 	}
+	return data
 }
 
 type App struct {
@@ -111,7 +102,7 @@ func (app *App) sendHTTPWithRateLimit(ctx context.Context) {
 			if ctx.Err() != nil {
 				return
 			}
-			data := app.db.JSONMetrics()
+			data := app.db.JSONMetrics(app.config.Labels)
 			app.logger.Info("Sending metrics to the server in json one metric at a time")
 			// create channels for workers
 			jobs := make(chan []byte, len(data))
@@ -148,7 +139,7 @@ func (app *App) sendHTTPWithoutRateLimit(ctx context.Context) {
 			if ctx.Err() != nil {
 				return
 			}
-			data := app.db.BatchJSONMetrics()
+			data := app.db.BatchJSONMetrics(app.config.Labels)
 			// calculate hash
 			var hash [32]byte
 			if app.config.Key != "" {
@@ -173,7 +164,6 @@ func (app *App) sendGRPCWithRateLimit(ctx context.Context) {
 			return
 		case <-t.C:
 			app.logger.Debug("Getting metrics from the in-memory database")
-			var data []*pb.Metric
 
 			conn, err := grpc.Dial(
 				app.config.ServerAddress,
@@ -185,22 +175,7 @@ func (app *App) sendGRPCWithRateLimit(ctx context.Context) {
 
 			c := pb.NewMetricsServiceClient(conn)
 
-			gauges := app.db.GetAllGauge()
-			counters := app.db.GetAllCounter()
-			for metric, value := range gauges {
-				data = append(data, &pb.Metric{
-					Name:  metric,
-					Gauge: value,
-					Type:  "gauge",
-				})
-			}
-			for metric, value := range counters {
-				data = append(data, &pb.Metric{
-					Name:    metric,
-					Counter: value,
-					Type:    "counter",
-				})
-			}
+			data := protoMetrics(app.db.TakeMetrics(app.config.Labels))
 
 			app.logger.Info("Sending metrics to the GRPC server one metric at a time")
 			// create channels for workers
@@ -247,23 +222,7 @@ func (app *App) sendGRPCWithoutRateLimit(ctx context.Context) {
 
 			c := pb.NewMetricsServiceClient(conn)
 
-			var data []*pb.Metric
-			gauges := app.db.GetAllGauge()
-			counters := app.db.GetAllCounter()
-			for metric, value := range gauges {
-				data = append(data, &pb.Metric{
-					Name:  metric,
-					Gauge: value,
-					Type:  "gauge",
-				})
-			}
-			for metric, value := range counters {
-				data = append(data, &pb.Metric{
-					Name:    metric,
-					Counter: value,
-					Type:    "counter",
-				})
-			}
+			data := protoMetrics(app.db.TakeMetrics(app.config.Labels))
 			app.logger.Debug("Sending metrics to the GRPC server in batches")
 			// send metrics
 			err = grpcclient.GRPCSendMetrics(c, data)
@@ -302,6 +261,12 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	// initialize storage
 	memDB := storage.NewAgentStorage()
 
+	// create the collectors enabled by the configuration
+	collectors, err := collector.FromConfig(conf)
+	if err != nil {
+		logger.Fatal("Failed to create collectors", zap.Error(err))
+	}
+
 	app := App{
 		db:     memDB,
 		config: conf,
@@ -312,9 +277,11 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	ctx, cancel := context.WithCancel(context.Background()) //nolint:govet
 	defer cancel()
 
-	// start metrics monitoring
-	go getRuntimeMetrics(ctx, memDB, conf.PollInterval)
-	go getGopsUtilMetrics(ctx, memDB, conf.PollInterval)
+	// start metrics collection, every collector polls on its own interval
+	for _, c := range collectors {
+		logger.Info("Started collector", zap.String("collector", c.Name()), zap.String("interval", c.Interval().String()))
+		go collector.Run(ctx, c, memDB, logger)
+	}
 
 	// start sending metrics to the server depending on the limit
 	if conf.RateLimit > 0 {
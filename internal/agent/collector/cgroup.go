@@ -0,0 +1,249 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// CgroupName - the name of the collector of the cgroup v2 metrics of the container
+const CgroupName = "cgroup"
+
+// defaultCgroupRoot - the cgroup v2 directory read if the root is not set
+const defaultCgroupRoot = "/sys/fs/cgroup"
+
+// ErrNotCgroupV2 - an error that occurs when the root of the cgroup collector is not a cgroup v2 directory.
+var ErrNotCgroupV2 = errors.New("not a cgroup v2 directory")
+
+func init() {
+	Register(CgroupName, newCgroup)
+}
+
+// cpuStatCounters - the counters of cpu.stat and the names of their metrics
+var cpuStatCounters = []struct {
+	key  string
+	name string
+}{
+	{key: "usage_usec", name: "CgroupCPUUsageUsec"},
+	{key: "user_usec", name: "CgroupCPUUserUsec"},
+	{key: "system_usec", name: "CgroupCPUSystemUsec"},
+	{key: "nr_periods", name: "CgroupCPUPeriods"},
+	{key: "nr_throttled", name: "CgroupCPUThrottledPeriods"},
+	{key: "throttled_usec", name: "CgroupCPUThrottledUsec"},
+}
+
+// ioStatCounters - the counters of io.stat and the names of their metrics
+var ioStatCounters = map[string]string{
+	"rbytes": "CgroupIOReadBytes",
+	"wbytes": "CgroupIOWriteBytes",
+	"rios":   "CgroupIOReads",
+	"wios":   "CgroupIOWrites",
+}
+
+// cgroupCollector - the collector of the memory, the CPU, the I/O and the pids of the cgroup v2,
+// the metrics of the container the agent runs in instead of the metrics of the host.
+// The CPU and the I/O counters of the cgroup are cumulative, the collector reports their increments
+// since the previous collection. The files of the controllers not enabled in the cgroup are skipped.
+type cgroupCollector struct {
+	base
+	root string
+	last cumulative
+	now  func() time.Time
+	// cpuAt is the time cpu.stat was read at the previous collection
+	cpuAt time.Time
+}
+
+// cgroupSettings - the settings of the cgroup collector, Root is the cgroup v2 directory it reads
+type cgroupSettings struct {
+	Root string `yaml:"root"`
+}
+
+// newCgroup - function to create the cgroup collector, it returns an error if the root is not a cgroup v2 directory
+func newCgroup(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	var own cgroupSettings
+	if err := settings.Decode(&own); err != nil {
+		return nil, err
+	}
+	root := own.Root
+	if root == "" {
+		root = defaultCgroupRoot
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrNotCgroupV2, root, err)
+	}
+	return &cgroupCollector{
+		base: base{name: CgroupName, interval: settings.Interval},
+		root: root,
+		last: make(cumulative),
+		now:  time.Now,
+	}, nil
+}
+
+// Collect reads the cgroup files, the files that can not be read are skipped.
+func (c *cgroupCollector) Collect(_ context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+	var errs []error
+
+	usage, hasUsage, err := c.readNumber("memory.current")
+	errs = append(errs, err)
+	if hasUsage {
+		metrics = append(metrics, Gauge("CgroupMemoryUsage", float64(usage), nil))
+	}
+	// memory.max is "max" if the memory of the cgroup is not limited
+	limit, hasLimit, err := c.readNumber("memory.max")
+	errs = append(errs, err)
+	if hasLimit {
+		metrics = append(metrics, Gauge("CgroupMemoryLimit", float64(limit), nil))
+		if hasUsage && limit > 0 {
+			metrics = append(metrics, Gauge("CgroupMemoryUsedPercent", float64(usage)/float64(limit)*100, nil))
+		}
+	}
+
+	pids, hasPids, err := c.readNumber("pids.current")
+	errs = append(errs, err)
+	if hasPids {
+		metrics = append(metrics, Gauge("CgroupPids", float64(pids), nil))
+	}
+
+	metrics = append(metrics, c.cpu(&errs)...)
+	metrics = append(metrics, c.io(&errs)...)
+	return metrics, errors.Join(errs...)
+}
+
+// cpu - method to read cpu.stat. Besides the counters it reports the CPU utilization of the cgroup
+// in percent of one CPU and the throttling: CgroupCPUThrottled is 1 if the cgroup was throttled
+// since the previous collection, CgroupCPUThrottledPercent is the share of the throttled periods.
+func (c *cgroupCollector) cpu(errs *[]error) []models.Metric {
+	data, ok, err := c.readFile("cpu.stat")
+	if !ok {
+		*errs = append(*errs, err)
+		return nil
+	}
+	now := c.now()
+	elapsed := now.Sub(c.cpuAt)
+	c.cpuAt = now
+
+	stat := make(map[string]uint64)
+	for _, line := range lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("cpu.stat: %w", err))
+			continue
+		}
+		stat[fields[0]] = v
+	}
+
+	var metrics []models.Metric
+	deltas := make(map[string]uint64, len(cpuStatCounters))
+	for _, counter := range cpuStatCounters {
+		v, ok := stat[counter.key]
+		if !ok {
+			continue
+		}
+		if d, ok := c.last.delta(counter.name, nil, v); ok {
+			deltas[counter.key] = d
+			metrics = append(metrics, Counter(counter.name, int64(d), nil))
+		}
+	}
+	if d, ok := deltas["usage_usec"]; ok && elapsed > 0 {
+		metrics = append(metrics, Gauge("CgroupCPUutilization", float64(d)/float64(elapsed.Microseconds())*100, nil))
+	}
+	if periods, ok := deltas["nr_periods"]; ok {
+		throttled, percent := 0.0, 0.0
+		if deltas["nr_throttled"] > 0 {
+			throttled = 1
+		}
+		if periods > 0 {
+			percent = float64(deltas["nr_throttled"]) / float64(periods) * 100
+		}
+		metrics = append(metrics,
+			Gauge("CgroupCPUThrottled", throttled, nil),
+			Gauge("CgroupCPUThrottledPercent", percent, nil),
+		)
+	}
+	return metrics
+}
+
+// io - method to read io.stat, the lines of the devices are "MAJ:MIN key=value ..."
+func (c *cgroupCollector) io(errs *[]error) []models.Metric {
+	data, ok, err := c.readFile("io.stat")
+	if !ok {
+		*errs = append(*errs, err)
+		return nil
+	}
+	var metrics []models.Metric
+	for _, line := range lines(data) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		labels := map[string]string{"device": fields[0]}
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			name, known := ioStatCounters[key]
+			if !found || !known {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("io.stat: %w", err))
+				continue
+			}
+			metrics = c.last.appendDelta(metrics, name, labels, v)
+		}
+	}
+	return metrics
+}
+
+// readNumber - method to read the file of the single number, the missing file and "max" are reported as no value
+func (c *cgroupCollector) readNumber(name string) (uint64, bool, error) {
+	data, ok, err := c.readFile(name)
+	if !ok {
+		return 0, false, err
+	}
+	s := string(bytes.TrimSpace(data))
+	if s == "max" {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", name, err)
+	}
+	return v, true, nil
+}
+
+// readFile - method to read the file of the cgroup, the missing file is reported as no value without an error
+func (c *cgroupCollector) readFile(name string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(c.root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// lines - function to split the file into the lines
+func lines(data []byte) []string {
+	var res []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		res = append(res, s.Text())
+	}
+	return res
+}
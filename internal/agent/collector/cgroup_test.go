@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+)
+
+func TestCgroupCollect(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, err := newCgroup(nil, config.CollectorConfig{Interval: time.Second, Settings: map[string]any{"root": "testdata/cgroup/first"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgroup := c.(*cgroupCollector)
+
+	tests := []struct {
+		name string
+		root string
+		now  time.Time
+		want []string
+	}{
+		{
+			// the first values of the cumulative counters are only remembered
+			name: "Test 1",
+			root: "testdata/cgroup/first",
+			now:  start,
+			want: []string{
+				"CgroupMemoryLimit 524288", "CgroupMemoryUsage 262144", "CgroupMemoryUsedPercent 50", "CgroupPids 12",
+			},
+		},
+		{
+			// the cgroup used a half of the CPU and was throttled in a quarter of the periods
+			name: "Test 2",
+			root: "testdata/cgroup/second",
+			now:  start.Add(time.Second),
+			want: []string{
+				"CgroupMemoryLimit 524288", "CgroupMemoryUsage 393216", "CgroupMemoryUsedPercent 75", "CgroupPids 12",
+				"CgroupCPUUsageUsec +500000", "CgroupCPUUserUsec +300000", "CgroupCPUSystemUsec +200000",
+				"CgroupCPUPeriods +100", "CgroupCPUThrottledPeriods +25", "CgroupCPUThrottledUsec +20000",
+				"CgroupCPUutilization 50", "CgroupCPUThrottled 1", "CgroupCPUThrottledPercent 25",
+				"CgroupIOReadBytes{device=8:0} +4096", "CgroupIOWriteBytes{device=8:0} +0",
+				"CgroupIOReads{device=8:0} +4", "CgroupIOWrites{device=8:0} +0",
+				"CgroupIOReadBytes{device=253:0} +0", "CgroupIOWriteBytes{device=253:0} +0",
+				"CgroupIOReads{device=253:0} +0", "CgroupIOWrites{device=253:0} +0",
+			},
+		},
+		{
+			// the counters did not change, the cgroup was not throttled
+			name: "Test 3",
+			root: "testdata/cgroup/second",
+			now:  start.Add(2 * time.Second),
+			want: []string{
+				"CgroupMemoryLimit 524288", "CgroupMemoryUsage 393216", "CgroupMemoryUsedPercent 75", "CgroupPids 12",
+				"CgroupCPUUsageUsec +0", "CgroupCPUUserUsec +0", "CgroupCPUSystemUsec +0",
+				"CgroupCPUPeriods +0", "CgroupCPUThrottledPeriods +0", "CgroupCPUThrottledUsec +0",
+				"CgroupCPUutilization 0", "CgroupCPUThrottled 0", "CgroupCPUThrottledPercent 0",
+				"CgroupIOReadBytes{device=8:0} +0", "CgroupIOWriteBytes{device=8:0} +0",
+				"CgroupIOReads{device=8:0} +0", "CgroupIOWrites{device=8:0} +0",
+				"CgroupIOReadBytes{device=253:0} +0", "CgroupIOWriteBytes{device=253:0} +0",
+				"CgroupIOReads{device=253:0} +0", "CgroupIOWrites{device=253:0} +0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroup.root = tt.root
+			cgroup.now = func() time.Time { return tt.now }
+			metrics, err := cgroup.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if got := describe(metrics); !reflect.DeepEqual(got, want) {
+				t.Errorf("Collect() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCgroupUnlimited(t *testing.T) {
+	c, err := newCgroup(nil, config.CollectorConfig{Settings: map[string]any{"root": "testdata/cgroup/unlimited"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the memory is not limited, the files of the io and pids controllers are missing
+	if got, want := describe(metrics), []string{"CgroupMemoryUsage 524288"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+}
+
+func TestNewCgroupNotV2(t *testing.T) {
+	if _, err := newCgroup(nil, config.CollectorConfig{Settings: map[string]any{"root": t.TempDir()}}); !errors.Is(err, ErrNotCgroupV2) {
+		t.Errorf("newCgroup() error = %v, want %v", err, ErrNotCgroupV2)
+	}
+}
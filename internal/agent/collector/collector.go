@@ -0,0 +1,198 @@
+// Package collector contains the metric collectors of the agent and their registry.
+// A collector registers itself by name in the init function of its package,
+// the agent runs the registered collectors enabled by the collectors section of the configuration.
+// A custom collector is added by a package that calls Register and is imported by the agent.
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// ErrUnknownCollector - an error that occurs when no collector is registered with the name.
+var ErrUnknownCollector = errors.New("unknown collector")
+
+const (
+	// errorsMetric - the counter of the failed collections, labeled by the collector name
+	errorsMetric = "CollectorErrors"
+	// defaultInterval - the collection interval used if neither the collector nor the poll interval is set
+	defaultInterval = 2 * time.Second
+)
+
+// Collector - the source of the metrics polled by the agent.
+// Collect may return the metrics it has collected together with the error.
+type Collector interface {
+	Name() string
+	Interval() time.Duration
+	Collect(ctx context.Context) ([]models.Metric, error)
+}
+
+// Factory - the function that creates the collector with the agent configuration and the collector settings,
+// the interval of the settings is already resolved.
+type Factory func(conf *config.AgentConfig, settings config.CollectorConfig) (Collector, error)
+
+// Store - the storage of the collected metrics.
+type Store interface {
+	Store(metrics []models.Metric)
+}
+
+var (
+	mut       sync.RWMutex
+	factories = make(map[string]Factory)
+	builtin   = make(map[string]bool)
+)
+
+// Register makes the collector available by the name.
+// It panics if the factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	mut.Lock()
+	defer mut.Unlock()
+	if factory == nil {
+		panic("collector: Register factory is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic("collector: Register called twice for collector " + name)
+	}
+	factories[name] = factory
+}
+
+// registerBuiltin - function to register the collector that runs unless it is disabled
+func registerBuiltin(name string, factory Factory) {
+	Register(name, factory)
+	mut.Lock()
+	defer mut.Unlock()
+	builtin[name] = true
+}
+
+// Names returns the sorted names of the registered collectors.
+func Names() []string {
+	mut.RLock()
+	defer mut.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromConfig creates the collectors enabled by the configuration sorted by name.
+// A built-in collector runs unless it is disabled, the other collectors run if they are enabled.
+// It returns an error if an unknown collector is configured or a collector can not be created.
+func FromConfig(conf *config.AgentConfig) ([]Collector, error) {
+	for name := range conf.Collectors {
+		mut.RLock()
+		_, ok := factories[name]
+		mut.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w %q (registered: %v)", ErrUnknownCollector, name, Names())
+		}
+	}
+	var collectors []Collector
+	for _, name := range Names() {
+		settings := conf.Collectors[name]
+		mut.RLock()
+		factory, enabled := factories[name], builtin[name]
+		mut.RUnlock()
+		if settings.Enabled != nil {
+			enabled = *settings.Enabled
+		}
+		if !enabled {
+			continue
+		}
+		if settings.Interval <= 0 {
+			settings.Interval = conf.PollInterval
+		}
+		if settings.Interval <= 0 {
+			settings.Interval = defaultInterval
+		}
+		c, err := factory(conf, settings)
+		if err != nil {
+			return nil, fmt.Errorf("collector %s: %w", name, err)
+		}
+		collectors = append(collectors, c)
+	}
+	return collectors, nil
+}
+
+// Run collects the metrics into the storage every interval of the collector until the context is done.
+// A failed collection increments the CollectorErrors counter labeled by the collector name.
+func Run(ctx context.Context, c Collector, db Store, logger *zap.Logger) {
+	t := time.NewTicker(c.Interval())
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			metrics, err := c.Collect(ctx)
+			if err != nil {
+				logger.Debug("collector failed", zap.String("collector", c.Name()), zap.Error(err))
+				metrics = append(metrics, Counter(errorsMetric, 1, map[string]string{"collector": c.Name()}))
+			}
+			db.Store(metrics)
+		}
+	}
+}
+
+// Gauge returns the gauge metric.
+func Gauge(name string, value float64, labels map[string]string) models.Metric {
+	return models.Metric{ID: name, MType: "gauge", Value: &value, Labels: labels}
+}
+
+// Counter returns the counter metric incremented by the delta.
+func Counter(name string, delta int64, labels map[string]string) models.Metric {
+	return models.Metric{ID: name, MType: "counter", Delta: &delta, Labels: labels}
+}
+
+// cumulative - the last values of the cumulative counters by the metric name and the labels
+type cumulative map[string]uint64
+
+// delta - method to remember the value of the cumulative counter and to return its increment since the previous collection.
+// The first value is only remembered, a value below the previous one is taken as a reset.
+func (c cumulative) delta(name string, labels map[string]string, value uint64) (uint64, bool) {
+	key := fmt.Sprintf("%s/%v", name, labels)
+	prev, ok := c[key]
+	c[key] = value
+	if !ok {
+		return 0, false
+	}
+	if value < prev {
+		return value, true
+	}
+	return value - prev, true
+}
+
+// appendDelta - method to append the increment of the cumulative counter since the previous collection
+func (c cumulative) appendDelta(metrics []models.Metric, name string, labels map[string]string, value uint64) []models.Metric {
+	delta, ok := c.delta(name, labels, value)
+	if !ok {
+		return metrics
+	}
+	return append(metrics, Counter(name, int64(delta), labels))
+}
+
+// base - the name and the interval of the built-in collector
+type base struct {
+	name     string
+	interval time.Duration
+}
+
+// Name returns the name of the collector.
+func (b base) Name() string {
+	return b.name
+}
+
+// Interval returns the collection interval of the collector.
+func (b base) Interval() time.Duration {
+	return b.interval
+}
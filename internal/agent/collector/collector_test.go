@@ -0,0 +1,188 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+	"gopkg.in/yaml.v3"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// testCollector - the collector that returns the same metrics and error on every collection
+type testCollector struct {
+	base
+	metrics []models.Metric
+	err     error
+}
+
+func (c *testCollector) Collect(_ context.Context) ([]models.Metric, error) {
+	return c.metrics, c.err
+}
+
+func init() {
+	Register("test", func(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+		return &testCollector{base: base{name: "test", interval: settings.Interval}}, nil
+	})
+}
+
+func TestFromConfig(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name          string
+		collectors    map[string]config.CollectorConfig
+		wantNames     []string
+		wantIntervals []time.Duration
+		wantErr       error
+	}{
+		{
+			name:          "Test 1",
+			wantNames:     []string{GopsutilName, RuntimeName},
+			wantIntervals: []time.Duration{time.Second, time.Second},
+		},
+		{
+			name: "Test 2",
+			collectors: map[string]config.CollectorConfig{
+				RuntimeName: {Enabled: &disabled},
+				"test":      {Enabled: &enabled, Interval: time.Minute},
+			},
+			wantNames:     []string{GopsutilName, "test"},
+			wantIntervals: []time.Duration{time.Second, time.Minute},
+		},
+		{
+			name:       "Test 3",
+			collectors: map[string]config.CollectorConfig{"unknown": {Enabled: &enabled}},
+			wantErr:    ErrUnknownCollector,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collectors, err := FromConfig(&config.AgentConfig{PollInterval: time.Second, Collectors: tt.collectors})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FromConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			var names []string
+			var intervals []time.Duration
+			for _, c := range collectors {
+				names = append(names, c.Name())
+				intervals = append(intervals, c.Interval())
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) || !reflect.DeepEqual(intervals, tt.wantIntervals) {
+				t.Errorf("FromConfig() = %v %v, want %v %v", names, intervals, tt.wantNames, tt.wantIntervals)
+			}
+		})
+	}
+}
+
+// TestFromConfigSettings checks that the own settings of the collector are passed raw from the yaml and the json config.
+func TestFromConfigSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func(data string, v any) error
+		data   string
+	}{
+		{
+			name:   "Test 1",
+			decode: func(data string, v any) error { return yaml.Unmarshal([]byte(data), v) },
+			data:   "collectors:\n  host:\n    enabled: true\n    mounts:\n      exclude: [/boot]\n",
+		},
+		{
+			name:   "Test 2",
+			decode: func(data string, v any) error { return json.Unmarshal([]byte(data), v) },
+			data:   `{"collectors": {"host": {"enabled": true, "mounts": {"exclude": ["/boot"]}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.AgentConfig{PollInterval: time.Second}
+			if err := tt.decode(tt.data, &conf); err != nil {
+				t.Fatal(err)
+			}
+			collectors, err := FromConfig(&conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range collectors {
+				if host, ok := c.(*hostCollector); ok {
+					if !reflect.DeepEqual(host.mounts.exclude, []string{"/boot"}) {
+						t.Errorf("host mounts exclude = %v, want [/boot]", host.mounts.exclude)
+					}
+					return
+				}
+			}
+			t.Error("FromConfig() did not create the host collector")
+		})
+	}
+}
+
+// recordStore - the storage that records the stored metrics
+type recordStore struct {
+	mut     sync.Mutex
+	metrics []models.Metric
+}
+
+func (s *recordStore) Store(metrics []models.Metric) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.metrics = append(s.metrics, metrics...)
+}
+
+func TestRun(t *testing.T) {
+	c := &testCollector{
+		base:    base{name: "test", interval: time.Millisecond},
+		metrics: []models.Metric{Gauge("Partial", 1, nil)},
+		err:     errors.New("source is unavailable"),
+	}
+	db := &recordStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, c, db, zaptest.NewLogger(t))
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	if len(db.metrics) < 2 {
+		t.Fatalf("stored metrics = %+v, want the partial metrics and the error counter", db.metrics)
+	}
+	partial, errorsCounter := db.metrics[0], db.metrics[1]
+	if partial.ID != "Partial" {
+		t.Errorf("the partial metrics are not stored: %+v", partial)
+	}
+	if errorsCounter.ID != errorsMetric || *errorsCounter.Delta != 1 || errorsCounter.Labels["collector"] != "test" {
+		t.Errorf("error counter = %+v, want %s{collector=test} incremented", errorsCounter, errorsMetric)
+	}
+}
+
+func TestRuntimeCollect(t *testing.T) {
+	c, err := newRuntime(&config.AgentConfig{GCPauseBuckets: []float64{1e4, 1e6}}, config.CollectorConfig{Interval: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]models.Metric, len(metrics))
+	for _, m := range metrics {
+		got[m.ID] = m
+	}
+	if m, ok := got["PollCount"]; !ok || m.MType != "counter" || *m.Delta != 1 {
+		t.Errorf("PollCount = %+v, want the counter incremented by 1", m)
+	}
+	if m, ok := got["HeapAlloc"]; !ok || m.MType != "gauge" || *m.Value <= 0 {
+		t.Errorf("HeapAlloc = %+v, want a positive gauge", m)
+	}
+	if m, ok := got["GCPauseNs"]; !ok || m.MType != "histogram" || len(m.Histogram.Counts) != 3 {
+		t.Errorf("GCPauseNs = %+v, want the histogram of 3 buckets", m)
+	}
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+)
+
+// Filter - the settings of the shell patterns of the names to include and to exclude.
+// All names are included if Include is empty, Exclude takes precedence.
+type Filter struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// nameFilter - the shell patterns of the names to include and to exclude
+type nameFilter struct {
+	include []string
+	exclude []string
+}
+
+// newNameFilter - function to check the patterns of the filter
+func newNameFilter(f Filter) (nameFilter, error) {
+	for _, pattern := range append(append([]string(nil), f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nameFilter{}, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nameFilter{include: f.Include, exclude: f.Exclude}, nil
+}
+
+// matches - method to check whether the name is included and not excluded
+func (f nameFilter) matches(name string) bool {
+	for _, pattern := range f.exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/mem"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// GopsutilName - the name of the collector of the host memory and CPU utilization
+const GopsutilName = "gopsutil"
+
+func init() {
+	registerBuiltin(GopsutilName, newGopsutil)
+}
+
+// gopsutilCollector - the collector of the total and free memory and the CPU utilization of the host
+type gopsutilCollector struct {
+	base
+}
+
+// newGopsutil - function to create the gopsutil collector
+func newGopsutil(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	return &gopsutilCollector{base: base{name: GopsutilName, interval: settings.Interval}}, nil
+}
+
+// Collect reads the memory and the CPU utilization of the host, the metrics that can not be read are skipped.
+func (c *gopsutilCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+	memory, memErr := mem.VirtualMemoryWithContext(ctx)
+	if memErr == nil {
+		metrics = append(metrics,
+			Gauge("TotalMemory", float64(memory.Total), nil),
+			Gauge("FreeMemory", float64(memory.Free), nil),
+		)
+	}
+	cp, cpuErr := cpu.PercentWithContext(ctx, 0, false)
+	if cpuErr == nil && len(cp) > 0 {
+		metrics = append(metrics, Gauge("CPUtilization1", cp[0], nil))
+	}
+	return metrics, errors.Join(memErr, cpuErr)
+}
@@ -0,0 +1,197 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// HostName - the name of the collector of the extended host metrics
+const HostName = "host"
+
+func init() {
+	Register(HostName, newHost)
+}
+
+// hostSource - the source of the host statistics
+type hostSource interface {
+	cpuPercent(ctx context.Context) ([]float64, error)
+	loadAvg(ctx context.Context) (*load.AvgStat, error)
+	swap(ctx context.Context) (*mem.SwapMemoryStat, error)
+	partitions(ctx context.Context) ([]disk.PartitionStat, error)
+	usage(ctx context.Context, mount string) (*disk.UsageStat, error)
+	diskIO(ctx context.Context) (map[string]disk.IOCountersStat, error)
+	netIO(ctx context.Context) ([]net.IOCountersStat, error)
+}
+
+// gopsutilSource - the host statistics read by gopsutil
+type gopsutilSource struct{}
+
+func (gopsutilSource) cpuPercent(ctx context.Context) ([]float64, error) {
+	return cpu.PercentWithContext(ctx, 0, true)
+}
+
+func (gopsutilSource) loadAvg(ctx context.Context) (*load.AvgStat, error) {
+	return load.AvgWithContext(ctx)
+}
+
+func (gopsutilSource) swap(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	return mem.SwapMemoryWithContext(ctx)
+}
+
+func (gopsutilSource) partitions(ctx context.Context) ([]disk.PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, false)
+}
+
+func (gopsutilSource) usage(ctx context.Context, mount string) (*disk.UsageStat, error) {
+	return disk.UsageWithContext(ctx, mount)
+}
+
+func (gopsutilSource) diskIO(ctx context.Context) (map[string]disk.IOCountersStat, error) {
+	return disk.IOCountersWithContext(ctx)
+}
+
+func (gopsutilSource) netIO(ctx context.Context) ([]net.IOCountersStat, error) {
+	return net.IOCountersWithContext(ctx, true)
+}
+
+// hostCollector - the collector of the per-core CPU utilization, the load averages, the swap, the disk usage
+// of the mount points, the disk I/O counters and the network counters of the interfaces.
+// The I/O counters of the host are cumulative, the collector reports their increments since the previous collection.
+type hostCollector struct {
+	base
+	source     hostSource
+	mounts     nameFilter
+	interfaces nameFilter
+	last       cumulative
+}
+
+// hostSettings - the settings of the host collector, they select the mount points and the network interfaces
+type hostSettings struct {
+	Mounts     Filter `yaml:"mounts"`
+	Interfaces Filter `yaml:"interfaces"`
+}
+
+// newHost - function to create the host collector, it returns an error if a filter pattern is malformed
+func newHost(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	var own hostSettings
+	if err := settings.Decode(&own); err != nil {
+		return nil, err
+	}
+	mounts, err := newNameFilter(own.Mounts)
+	if err != nil {
+		return nil, fmt.Errorf("mounts: %w", err)
+	}
+	interfaces, err := newNameFilter(own.Interfaces)
+	if err != nil {
+		return nil, fmt.Errorf("interfaces: %w", err)
+	}
+	return &hostCollector{
+		base:       base{name: HostName, interval: settings.Interval},
+		source:     gopsutilSource{},
+		mounts:     mounts,
+		interfaces: interfaces,
+		last:       make(cumulative),
+	}, nil
+}
+
+// Collect reads the host statistics, the statistics that can not be read are skipped.
+func (c *hostCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+	var errs []error
+
+	percents, err := c.source.cpuPercent(ctx)
+	errs = append(errs, err)
+	for i, p := range percents {
+		metrics = append(metrics, Gauge(fmt.Sprintf("CPUutilization%d", i+1), p, nil))
+	}
+
+	if avg, err := c.source.loadAvg(ctx); err == nil {
+		metrics = append(metrics,
+			Gauge("LoadAverage1", avg.Load1, nil),
+			Gauge("LoadAverage5", avg.Load5, nil),
+			Gauge("LoadAverage15", avg.Load15, nil),
+		)
+	} else {
+		errs = append(errs, err)
+	}
+
+	if swap, err := c.source.swap(ctx); err == nil {
+		metrics = append(metrics,
+			Gauge("SwapTotal", float64(swap.Total), nil),
+			Gauge("SwapUsed", float64(swap.Used), nil),
+			Gauge("SwapFree", float64(swap.Free), nil),
+		)
+	} else {
+		errs = append(errs, err)
+	}
+
+	metrics = append(metrics, c.diskUsage(ctx, &errs)...)
+
+	if counters, err := c.source.diskIO(ctx); err == nil {
+		for device, s := range counters {
+			labels := map[string]string{"device": device}
+			metrics = c.last.appendDelta(metrics, "DiskReadBytes", labels, s.ReadBytes)
+			metrics = c.last.appendDelta(metrics, "DiskWriteBytes", labels, s.WriteBytes)
+			metrics = c.last.appendDelta(metrics, "DiskReads", labels, s.ReadCount)
+			metrics = c.last.appendDelta(metrics, "DiskWrites", labels, s.WriteCount)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+
+	if counters, err := c.source.netIO(ctx); err == nil {
+		for _, s := range counters {
+			if !c.interfaces.matches(s.Name) {
+				continue
+			}
+			labels := map[string]string{"interface": s.Name}
+			metrics = c.last.appendDelta(metrics, "NetBytesSent", labels, s.BytesSent)
+			metrics = c.last.appendDelta(metrics, "NetBytesRecv", labels, s.BytesRecv)
+			metrics = c.last.appendDelta(metrics, "NetPacketsSent", labels, s.PacketsSent)
+			metrics = c.last.appendDelta(metrics, "NetPacketsRecv", labels, s.PacketsRecv)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	return metrics, errors.Join(errs...)
+}
+
+// diskUsage - method to read the usage of the selected mount points, a mount point mounted twice is reported once
+func (c *hostCollector) diskUsage(ctx context.Context, errs *[]error) []models.Metric {
+	partitions, err := c.source.partitions(ctx)
+	if err != nil {
+		*errs = append(*errs, err)
+		return nil
+	}
+	var metrics []models.Metric
+	seen := make(map[string]bool, len(partitions))
+	for _, p := range partitions {
+		if seen[p.Mountpoint] || !c.mounts.matches(p.Mountpoint) {
+			continue
+		}
+		seen[p.Mountpoint] = true
+		u, err := c.source.usage(ctx, p.Mountpoint)
+		if err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+		labels := map[string]string{"mount": p.Mountpoint}
+		metrics = append(metrics,
+			Gauge("DiskTotal", float64(u.Total), labels),
+			Gauge("DiskUsed", float64(u.Used), labels),
+			Gauge("DiskFree", float64(u.Free), labels),
+			Gauge("DiskUsedPercent", u.UsedPercent, labels),
+		)
+	}
+	return metrics
+}
@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// fakeHost - the host statistics of the test, the network counters grow by step on every collection
+type fakeHost struct {
+	netBytes uint64
+	step     uint64
+	loadErr  error
+}
+
+func (f *fakeHost) cpuPercent(_ context.Context) ([]float64, error) {
+	return []float64{10, 20}, nil
+}
+
+func (f *fakeHost) loadAvg(_ context.Context) (*load.AvgStat, error) {
+	if f.loadErr != nil {
+		return nil, f.loadErr
+	}
+	return &load.AvgStat{Load1: 1, Load5: 0.5, Load15: 0.25}, nil
+}
+
+func (f *fakeHost) swap(_ context.Context) (*mem.SwapMemoryStat, error) {
+	return &mem.SwapMemoryStat{Total: 100, Used: 40, Free: 60}, nil
+}
+
+func (f *fakeHost) partitions(_ context.Context) ([]disk.PartitionStat, error) {
+	return []disk.PartitionStat{{Mountpoint: "/"}, {Mountpoint: "/boot"}, {Mountpoint: "/"}}, nil
+}
+
+func (f *fakeHost) usage(_ context.Context, mount string) (*disk.UsageStat, error) {
+	return &disk.UsageStat{Path: mount, Total: 1000, Used: 250, Free: 750, UsedPercent: 25}, nil
+}
+
+func (f *fakeHost) diskIO(_ context.Context) (map[string]disk.IOCountersStat, error) {
+	return map[string]disk.IOCountersStat{"sda": {ReadBytes: 10, WriteBytes: 20, ReadCount: 1, WriteCount: 2}}, nil
+}
+
+func (f *fakeHost) netIO(_ context.Context) ([]net.IOCountersStat, error) {
+	f.netBytes += f.step
+	return []net.IOCountersStat{
+		{Name: "eth0", BytesSent: f.netBytes, BytesRecv: f.netBytes, PacketsSent: 1, PacketsRecv: 1},
+		{Name: "lo", BytesSent: f.netBytes},
+	}, nil
+}
+
+// describe - function to print the metrics sorted for the comparison
+func describe(metrics []models.Metric) []string {
+	res := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		s := m.ID + printLabels(m.Labels) + " "
+		if m.Value != nil {
+			s += formatFloat(*m.Value)
+		} else {
+			s += "+" + formatFloat(float64(*m.Delta))
+		}
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestHostCollect(t *testing.T) {
+	c, err := newHost(nil, config.CollectorConfig{
+		Interval: time.Second,
+		Settings: map[string]any{
+			"mounts":     Filter{Exclude: []string{"/boot"}},
+			"interfaces": Filter{Include: []string{"eth*", "lo"}, Exclude: []string{"lo"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeHost{netBytes: 1000, step: 100}
+	host := c.(*hostCollector)
+	host.source = source
+
+	gauges := []string{
+		"CPUutilization1 10", "CPUutilization2 20",
+		"DiskFree{mount=/} 750", "DiskTotal{mount=/} 1000", "DiskUsed{mount=/} 250", "DiskUsedPercent{mount=/} 25",
+		"LoadAverage1 1", "LoadAverage15 0.25", "LoadAverage5 0.5",
+		"SwapFree 60", "SwapTotal 100", "SwapUsed 40",
+	}
+	tests := []struct {
+		name    string
+		setup   func()
+		want    []string
+		wantErr bool
+	}{
+		{
+			// the first values of the cumulative counters are only remembered
+			name: "Test 1",
+			want: gauges,
+		},
+		{
+			name: "Test 2",
+			want: append(append([]string(nil), gauges...),
+				"DiskReadBytes{device=sda} +0", "DiskReads{device=sda} +0", "DiskWriteBytes{device=sda} +0", "DiskWrites{device=sda} +0",
+				"NetBytesRecv{interface=eth0} +100", "NetBytesSent{interface=eth0} +100",
+				"NetPacketsRecv{interface=eth0} +0", "NetPacketsSent{interface=eth0} +0",
+			),
+		},
+		{
+			// the counter below the previous value is taken as reset, the failed source is skipped
+			name: "Test 3",
+			setup: func() {
+				source.netBytes, source.loadErr = 0, errors.New("no load average")
+			},
+			want: append(append([]string(nil), gauges[:6]...), append(gauges[9:],
+				"DiskReadBytes{device=sda} +0", "DiskReads{device=sda} +0", "DiskWriteBytes{device=sda} +0", "DiskWrites{device=sda} +0",
+				"NetBytesRecv{interface=eth0} +100", "NetBytesSent{interface=eth0} +100",
+				"NetPacketsRecv{interface=eth0} +0", "NetPacketsSent{interface=eth0} +0",
+			)...),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			metrics, err := host.Collect(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if got := describe(metrics); !reflect.DeepEqual(got, want) {
+				t.Errorf("Collect() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewHostInvalidFilter(t *testing.T) {
+	if _, err := newHost(nil, config.CollectorConfig{Settings: map[string]any{"mounts": Filter{Include: []string{"[/"}}}}); err == nil {
+		t.Error("newHost() error = nil, want the malformed pattern reported")
+	}
+}
+
+// printLabels - function to print the sorted labels
+func printLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat - function to print the value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
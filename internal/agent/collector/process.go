@@ -0,0 +1,376 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// ProcessName - the name of the collector of the metrics of the watched processes
+const ProcessName = "process"
+
+// ErrInvalidProcess - an error that occurs when the watched process is configured incorrectly.
+var ErrInvalidProcess = errors.New("invalid process")
+
+// errProcessGone - an error that occurs when the process exits while it is read
+var errProcessGone = errors.New("process is gone")
+
+func init() {
+	Register(ProcessName, newProcess)
+}
+
+// processStats - the statistics of the process
+type processStats struct {
+	rss        uint64
+	cpuSeconds float64
+	fds        int32
+	threads    int32
+	created    time.Time
+}
+
+// processSource - the source of the process statistics, it returns errProcessGone for the exited process
+type processSource interface {
+	pids(ctx context.Context) ([]int32, error)
+	name(ctx context.Context, pid int32) (string, error)
+	cmdline(ctx context.Context, pid int32) (string, error)
+	stats(ctx context.Context, pid int32) (processStats, error)
+}
+
+// gopsutilProcesses - the process statistics read by gopsutil
+type gopsutilProcesses struct{}
+
+func (gopsutilProcesses) pids(ctx context.Context) ([]int32, error) {
+	return process.PidsWithContext(ctx)
+}
+
+func (gopsutilProcesses) name(ctx context.Context, pid int32) (string, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return "", gone(err)
+	}
+	name, err := p.NameWithContext(ctx)
+	return name, gone(err)
+}
+
+func (gopsutilProcesses) cmdline(ctx context.Context, pid int32) (string, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return "", gone(err)
+	}
+	cmdline, err := p.CmdlineWithContext(ctx)
+	return cmdline, gone(err)
+}
+
+func (gopsutilProcesses) stats(ctx context.Context, pid int32) (processStats, error) {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	created, err := p.CreateTimeWithContext(ctx)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	mem, err := p.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	times, err := p.TimesWithContext(ctx)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	fds, err := p.NumFDsWithContext(ctx)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	threads, err := p.NumThreadsWithContext(ctx)
+	if err != nil {
+		return processStats{}, gone(err)
+	}
+	return processStats{
+		rss:        mem.RSS,
+		cpuSeconds: times.User + times.System,
+		fds:        fds,
+		threads:    threads,
+		created:    time.UnixMilli(created),
+	}, nil
+}
+
+// gone - function to replace the error of the exited process with errProcessGone
+func gone(err error) error {
+	if errors.Is(err, process.ErrorProcessNotRunning) || errors.Is(err, os.ErrNotExist) {
+		return errProcessGone
+	}
+	return err
+}
+
+// processIdentity - the process ID and the start time that identify the running instance of the process
+type processIdentity struct {
+	pid     int32
+	created time.Time
+}
+
+// cpuSample - the CPU time of the process at the moment of the collection
+type cpuSample struct {
+	seconds float64
+	at      time.Time
+}
+
+// ProcessTarget - the settings of the watched process, Name is the name it is reported with.
+// The process is found by exactly one of the PID file, the exact process name and the regular expression of the command line.
+type ProcessTarget struct {
+	Name        string `yaml:"name"`
+	PIDFile     string `yaml:"pid_file"`
+	ProcessName string `yaml:"process_name"`
+	Cmdline     string `yaml:"cmdline"`
+}
+
+// processSettings - the settings of the process collector,
+// NamePrefix puts the process name into the metric names instead of the process label
+type processSettings struct {
+	Processes  []ProcessTarget `yaml:"processes"`
+	NamePrefix bool            `yaml:"name_prefix"`
+}
+
+// processTarget - the watched process and the state of its previous collection
+type processTarget struct {
+	ProcessTarget
+	cmdline *regexp.Regexp
+	// identity is the oldest matched process of the previous collections, known is false until it is seen
+	identity processIdentity
+	known    bool
+	cpu      map[processIdentity]cpuSample
+}
+
+// processCollector - the collector of the RSS, the CPU utilization, the open file descriptors, the threads,
+// the uptime and the restarts of the watched processes.
+// The metrics of all the processes matched by the target are summed, the uptime is the uptime of the oldest one.
+// The restart is the change of the oldest matched process, it is detected even if the process was down in between.
+type processCollector struct {
+	base
+	source     processSource
+	targets    []*processTarget
+	namePrefix bool
+	now        func() time.Time
+}
+
+// newProcess - function to create the process collector, it returns an error if the processes are configured incorrectly
+func newProcess(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	var own processSettings
+	if err := settings.Decode(&own); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProcess, err)
+	}
+	if len(own.Processes) == 0 {
+		return nil, fmt.Errorf("%w: no processes are configured", ErrInvalidProcess)
+	}
+	targets := make([]*processTarget, 0, len(own.Processes))
+	names := make(map[string]bool, len(own.Processes))
+	for _, p := range own.Processes {
+		if p.Name == "" {
+			return nil, fmt.Errorf("%w: the name is empty", ErrInvalidProcess)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("%w %q: the name is duplicated", ErrInvalidProcess, p.Name)
+		}
+		names[p.Name] = true
+		selectors := 0
+		for _, s := range []string{p.PIDFile, p.ProcessName, p.Cmdline} {
+			if s != "" {
+				selectors++
+			}
+		}
+		if selectors != 1 {
+			return nil, fmt.Errorf("%w %q: exactly one of pid_file, process_name and cmdline must be set", ErrInvalidProcess, p.Name)
+		}
+		t := &processTarget{ProcessTarget: p, cpu: make(map[processIdentity]cpuSample)}
+		if p.Cmdline != "" {
+			re, err := regexp.Compile(p.Cmdline)
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: %w", ErrInvalidProcess, p.Name, err)
+			}
+			t.cmdline = re
+		}
+		targets = append(targets, t)
+	}
+	return &processCollector{
+		base:       base{name: ProcessName, interval: settings.Interval},
+		source:     gopsutilProcesses{},
+		targets:    targets,
+		namePrefix: own.NamePrefix,
+		now:        time.Now,
+	}, nil
+}
+
+// Collect reads the statistics of the watched processes, the process that is not running is reported as down.
+func (c *processCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+	var errs []error
+	var pids []int32
+	// names and cmdlines are read once for all the targets of the collection
+	names := make(map[int32]string)
+	cmdlines := make(map[int32]string)
+	listed := false
+	for _, t := range c.targets {
+		if t.PIDFile == "" && !listed {
+			var err error
+			pids, err = c.source.pids(ctx)
+			if err != nil {
+				return nil, err
+			}
+			listed = true
+		}
+		matched, err := c.match(ctx, t, pids, names, cmdlines)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("process %s: %w", t.Name, err))
+		}
+		metrics = append(metrics, c.collectTarget(ctx, t, matched, &errs)...)
+	}
+	return metrics, errors.Join(errs...)
+}
+
+// match - method to find the process IDs of the target
+func (c *processCollector) match(ctx context.Context, t *processTarget, pids []int32,
+	names, cmdlines map[int32]string) ([]int32, error) {
+	if t.PIDFile != "" {
+		return readPIDFile(t.PIDFile)
+	}
+	var matched []int32
+	var errs []error
+	for _, pid := range pids {
+		var ok bool
+		var err error
+		if t.ProcessName != "" {
+			ok, err = c.cached(ctx, pid, names, c.source.name, func(name string) bool { return name == t.ProcessName })
+		} else {
+			ok, err = c.cached(ctx, pid, cmdlines, c.source.cmdline, t.cmdline.MatchString)
+		}
+		if err != nil && !errors.Is(err, errProcessGone) {
+			errs = append(errs, err)
+		}
+		if ok {
+			matched = append(matched, pid)
+		}
+	}
+	return matched, errors.Join(errs...)
+}
+
+// cached - method to check the name or the command line of the process read once per collection
+func (c *processCollector) cached(ctx context.Context, pid int32, cache map[int32]string,
+	read func(context.Context, int32) (string, error), check func(string) bool) (bool, error) {
+	value, ok := cache[pid]
+	if !ok {
+		var err error
+		if value, err = read(ctx, pid); err != nil {
+			return false, err
+		}
+		cache[pid] = value
+	}
+	return check(value), nil
+}
+
+// readPIDFile - function to read the process ID from the file, the missing file means the process is not running
+func readPIDFile(name string) ([]int32, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("pid file %s: %w", name, err)
+	}
+	return []int32{int32(pid)}, nil
+}
+
+// collectTarget - method to sum the statistics of the matched processes and to detect the restart of the target
+func (c *processCollector) collectTarget(ctx context.Context, t *processTarget, pids []int32, errs *[]error) []models.Metric {
+	now := c.now()
+	var count int
+	var rss uint64
+	var percent float64
+	var fds, threads int32
+	var oldest processIdentity
+	samples := make(map[processIdentity]cpuSample, len(pids))
+	for _, pid := range pids {
+		s, err := c.source.stats(ctx, pid)
+		if err != nil {
+			if !errors.Is(err, errProcessGone) {
+				*errs = append(*errs, fmt.Errorf("process %s: pid %d: %w", t.Name, pid, err))
+			}
+			continue
+		}
+		id := processIdentity{pid: pid, created: s.created}
+		if count == 0 || s.created.Before(oldest.created) {
+			oldest = id
+		}
+		count++
+		rss += s.rss
+		fds += s.fds
+		threads += s.threads
+		percent += cpuPercent(t.cpu[id], s, now)
+		samples[id] = cpuSample{seconds: s.cpuSeconds, at: now}
+	}
+	t.cpu = samples
+
+	metrics := []models.Metric{
+		c.gauge(t, "ProcessCount", float64(count)),
+	}
+	var restarts int64
+	if count > 0 {
+		if t.known && t.identity != oldest {
+			restarts = 1
+		}
+		t.identity, t.known = oldest, true
+		metrics = append(metrics,
+			c.gauge(t, "ProcessUp", 1),
+			c.gauge(t, "ProcessRSS", float64(rss)),
+			c.gauge(t, "ProcessCPUPercent", percent),
+			c.gauge(t, "ProcessOpenFDs", float64(fds)),
+			c.gauge(t, "ProcessThreads", float64(threads)),
+			c.gauge(t, "ProcessUptime", now.Sub(oldest.created).Seconds()),
+		)
+	} else {
+		metrics = append(metrics, c.gauge(t, "ProcessUp", 0))
+	}
+	name, labels := c.metricName(t, "ProcessRestarts")
+	return append(metrics, Counter(name, restarts, labels))
+}
+
+// cpuPercent - function to calculate the CPU utilization of the process since the previous collection,
+// the utilization of the process seen for the first time is averaged over its lifetime
+func cpuPercent(prev cpuSample, s processStats, now time.Time) float64 {
+	seconds, since := s.cpuSeconds, s.created
+	if !prev.at.IsZero() {
+		seconds, since = s.cpuSeconds-prev.seconds, prev.at
+	}
+	elapsed := now.Sub(since).Seconds()
+	if elapsed <= 0 || seconds < 0 {
+		return 0
+	}
+	return seconds / elapsed * 100
+}
+
+// gauge - method to create the gauge of the target
+func (c *processCollector) gauge(t *processTarget, name string, value float64) models.Metric {
+	name, labels := c.metricName(t, name)
+	return Gauge(name, value, labels)
+}
+
+// metricName - method to name the metric of the target by the process label or by the name prefix
+func (c *processCollector) metricName(t *processTarget, name string) (string, map[string]string) {
+	if c.namePrefix {
+		return t.Name + "_" + name, nil
+	}
+	return name, map[string]string{"process": t.Name}
+}
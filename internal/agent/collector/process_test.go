@@ -0,0 +1,200 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+)
+
+// fakeProcess - the process of the test
+type fakeProcess struct {
+	name    string
+	cmdline string
+	stats   processStats
+}
+
+// fakeProcesses - the processes of the test by the process ID
+type fakeProcesses map[int32]*fakeProcess
+
+func (f fakeProcesses) pids(_ context.Context) ([]int32, error) {
+	pids := make([]int32, 0, len(f))
+	for pid := range f {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids, nil
+}
+
+func (f fakeProcesses) name(_ context.Context, pid int32) (string, error) {
+	if p, ok := f[pid]; ok {
+		return p.name, nil
+	}
+	return "", errProcessGone
+}
+
+func (f fakeProcesses) cmdline(_ context.Context, pid int32) (string, error) {
+	if p, ok := f[pid]; ok {
+		return p.cmdline, nil
+	}
+	return "", errProcessGone
+}
+
+func (f fakeProcesses) stats(_ context.Context, pid int32) (processStats, error) {
+	if p, ok := f[pid]; ok {
+		return p.stats, nil
+	}
+	return processStats{}, errProcessGone
+}
+
+func TestProcessCollect(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	pidFile := filepath.Join(t.TempDir(), "db.pid")
+	c, err := newProcess(nil, config.CollectorConfig{
+		Interval: time.Second,
+		Settings: map[string]any{"processes": []ProcessTarget{
+			{Name: "web", ProcessName: "nginx"},
+			{Name: "worker", Cmdline: `worker --queue=\w+`},
+			{Name: "db", PIDFile: pidFile},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := fakeProcesses{
+		10: {name: "nginx", stats: processStats{rss: 100, cpuSeconds: 10, fds: 5, threads: 1, created: start.Add(-100 * time.Second)}},
+		11: {name: "nginx", stats: processStats{rss: 50, cpuSeconds: 5, fds: 3, threads: 2, created: start.Add(-50 * time.Second)}},
+		20: {name: "python", cmdline: "python worker --queue=mail", stats: processStats{rss: 10, created: start.Add(-10 * time.Second)}},
+	}
+	processes := c.(*processCollector)
+	processes.source = source
+	processes.now = func() time.Time { return now }
+
+	tests := []struct {
+		name  string
+		setup func()
+		want  []string
+	}{
+		{
+			// the CPU utilization of the new processes is averaged over their lifetime, the missing pid file means the process is down
+			name: "Test 1",
+			want: []string{
+				"ProcessCount{process=web} 2", "ProcessUp{process=web} 1", "ProcessRSS{process=web} 150",
+				"ProcessCPUPercent{process=web} 20", "ProcessOpenFDs{process=web} 8", "ProcessThreads{process=web} 3",
+				"ProcessUptime{process=web} 100", "ProcessRestarts{process=web} +0",
+				"ProcessCount{process=worker} 1", "ProcessUp{process=worker} 1", "ProcessRSS{process=worker} 10",
+				"ProcessCPUPercent{process=worker} 0", "ProcessOpenFDs{process=worker} 0", "ProcessThreads{process=worker} 0",
+				"ProcessUptime{process=worker} 10", "ProcessRestarts{process=worker} +0",
+				"ProcessCount{process=db} 0", "ProcessUp{process=db} 0", "ProcessRestarts{process=db} +0",
+			},
+		},
+		{
+			// the CPU utilization is calculated since the previous collection, the worker is down
+			name: "Test 2",
+			setup: func() {
+				now = start.Add(10 * time.Second)
+				source[10].stats.cpuSeconds += 1
+				source[11].stats.cpuSeconds += 0.5
+				delete(source, 20)
+				source[30] = &fakeProcess{name: "postgres", stats: processStats{rss: 1000, created: start}}
+				if err := os.WriteFile(pidFile, []byte("30\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{
+				"ProcessCount{process=web} 2", "ProcessUp{process=web} 1", "ProcessRSS{process=web} 150",
+				"ProcessCPUPercent{process=web} 15", "ProcessOpenFDs{process=web} 8", "ProcessThreads{process=web} 3",
+				"ProcessUptime{process=web} 110", "ProcessRestarts{process=web} +0",
+				"ProcessCount{process=worker} 0", "ProcessUp{process=worker} 0", "ProcessRestarts{process=worker} +0",
+				"ProcessCount{process=db} 1", "ProcessUp{process=db} 1", "ProcessRSS{process=db} 1000",
+				"ProcessCPUPercent{process=db} 0", "ProcessOpenFDs{process=db} 0", "ProcessThreads{process=db} 0",
+				"ProcessUptime{process=db} 10", "ProcessRestarts{process=db} +0",
+			},
+		},
+		{
+			// the worker started again after it was down and the oldest web process was replaced
+			name: "Test 3",
+			setup: func() {
+				now = start.Add(20 * time.Second)
+				delete(source, 10)
+				source[21] = &fakeProcess{name: "python", cmdline: "python worker --queue=mail", stats: processStats{created: start.Add(15 * time.Second)}}
+			},
+			want: []string{
+				"ProcessCount{process=web} 1", "ProcessUp{process=web} 1", "ProcessRSS{process=web} 50",
+				"ProcessCPUPercent{process=web} 0", "ProcessOpenFDs{process=web} 3", "ProcessThreads{process=web} 2",
+				"ProcessUptime{process=web} 70", "ProcessRestarts{process=web} +1",
+				"ProcessCount{process=worker} 1", "ProcessUp{process=worker} 1", "ProcessRSS{process=worker} 0",
+				"ProcessCPUPercent{process=worker} 0", "ProcessOpenFDs{process=worker} 0", "ProcessThreads{process=worker} 0",
+				"ProcessUptime{process=worker} 5", "ProcessRestarts{process=worker} +1",
+				"ProcessCount{process=db} 1", "ProcessUp{process=db} 1", "ProcessRSS{process=db} 1000",
+				"ProcessCPUPercent{process=db} 0", "ProcessOpenFDs{process=db} 0", "ProcessThreads{process=db} 0",
+				"ProcessUptime{process=db} 20", "ProcessRestarts{process=db} +0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			metrics, err := processes.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if got := describe(metrics); !reflect.DeepEqual(got, want) {
+				t.Errorf("Collect() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestProcessNamePrefix(t *testing.T) {
+	c, err := newProcess(nil, config.CollectorConfig{Settings: map[string]any{
+		"name_prefix": true,
+		"processes":   []ProcessTarget{{Name: "web", ProcessName: "nginx"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	processes := c.(*processCollector)
+	processes.source = fakeProcesses{}
+	metrics, err := processes.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"web_ProcessCount 0", "web_ProcessRestarts +0", "web_ProcessUp 0"}
+	if got := describe(metrics); !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+}
+
+func TestNewProcessInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]any
+	}{
+		{name: "Test 1"},
+		{name: "Test 2", settings: map[string]any{"processes": []ProcessTarget{{ProcessName: "nginx"}}}},
+		{name: "Test 3", settings: map[string]any{"processes": []ProcessTarget{{Name: "web", ProcessName: "nginx", PIDFile: "/run/nginx.pid"}}}},
+		{name: "Test 4", settings: map[string]any{"processes": []ProcessTarget{{Name: "web", ProcessName: "nginx"}, {Name: "web", ProcessName: "httpd"}}}},
+		{name: "Test 5", settings: map[string]any{"processes": []ProcessTarget{{Name: "web", Cmdline: "nginx ("}}}},
+		// the unknown setting is rejected
+		{name: "Test 6", settings: map[string]any{"process": []ProcessTarget{{Name: "web", ProcessName: "nginx"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newProcess(nil, config.CollectorConfig{Settings: tt.settings}); !errors.Is(err, ErrInvalidProcess) {
+				t.Errorf("newProcess() error = %v, want %v", err, ErrInvalidProcess)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// RuntimeName - the name of the collector of the runtime.MemStats metrics
+const RuntimeName = "runtime"
+
+func init() {
+	registerBuiltin(RuntimeName, newRuntime)
+}
+
+// runtimeCollector - the collector of the memory statistics of the runtime, PollCount and RandomValue.
+// The GC pause histogram is collected only if the bucket bounds are set.
+type runtimeCollector struct {
+	base
+	gcPauseBuckets []float64
+	lastNumGC      uint32
+}
+
+// newRuntime - function to create the runtime collector
+func newRuntime(conf *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	return &runtimeCollector{
+		base:           base{name: RuntimeName, interval: settings.Interval},
+		gcPauseBuckets: conf.GCPauseBuckets,
+	}, nil
+}
+
+// Collect reads the memory statistics of the runtime.
+func (c *runtimeCollector) Collect(_ context.Context) ([]models.Metric, error) {
+	var rtMetrics runtime.MemStats
+
+	runtime.ReadMemStats(&rtMetrics)
+
+	metrics := []models.Metric{
+		Gauge("Alloc", float64(rtMetrics.Alloc), nil),
+		Gauge("BuckHashSys", float64(rtMetrics.BuckHashSys), nil),
+		Gauge("Frees", float64(rtMetrics.Frees), nil),
+		Gauge("GCCPUFraction", rtMetrics.GCCPUFraction, nil),
+		Gauge("GCSys", float64(rtMetrics.GCSys), nil),
+		Gauge("HeapAlloc", float64(rtMetrics.HeapAlloc), nil),
+		Gauge("HeapIdle", float64(rtMetrics.HeapIdle), nil),
+		Gauge("HeapInuse", float64(rtMetrics.HeapInuse), nil),
+		Gauge("HeapObjects", float64(rtMetrics.HeapObjects), nil),
+		Gauge("HeapReleased", float64(rtMetrics.HeapReleased), nil),
+		Gauge("HeapSys", float64(rtMetrics.HeapSys), nil),
+		Gauge("LastGC", float64(rtMetrics.LastGC), nil),
+		Gauge("Lookups", float64(rtMetrics.Lookups), nil),
+		Gauge("MCacheInuse", float64(rtMetrics.MCacheInuse), nil),
+		Gauge("MCacheSys", float64(rtMetrics.MCacheSys), nil),
+		Gauge("MSpanInuse", float64(rtMetrics.MSpanInuse), nil),
+		Gauge("MSpanSys", float64(rtMetrics.MSpanSys), nil),
+		Gauge("Mallocs", float64(rtMetrics.Mallocs), nil),
+		Gauge("NextGC", float64(rtMetrics.NextGC), nil),
+		Gauge("NumForcedGC", float64(rtMetrics.NumForcedGC), nil),
+		Gauge("NumGC", float64(rtMetrics.NumGC), nil),
+		Gauge("OtherSys", float64(rtMetrics.OtherSys), nil),
+		Gauge("PauseTotalNs", float64(rtMetrics.PauseTotalNs), nil),
+		Gauge("StackInuse", float64(rtMetrics.StackInuse), nil),
+		Gauge("StackSys", float64(rtMetrics.StackSys), nil),
+		Gauge("Sys", float64(rtMetrics.Sys), nil),
+		Gauge("TotalAlloc", float64(rtMetrics.TotalAlloc), nil),
+		Gauge("RandomValue", rand.Float64()*10000, nil),
+		Counter("PollCount", 1, nil),
+	}
+
+	// PauseNs is a circular buffer of the last 256 pauses,
+	// the pauses of the collections since the previous poll are observed
+	if len(c.gcPauseBuckets) > 0 {
+		h := models.NewHistogram(c.gcPauseBuckets)
+		first := c.lastNumGC
+		if rtMetrics.NumGC-first > uint32(len(rtMetrics.PauseNs)) {
+			first = rtMetrics.NumGC - uint32(len(rtMetrics.PauseNs))
+		}
+		for i := first; i < rtMetrics.NumGC; i++ {
+			h.Observe(float64(rtMetrics.PauseNs[i%uint32(len(rtMetrics.PauseNs))]))
+		}
+		metrics = append(metrics, models.Metric{ID: "GCPauseNs", MType: "histogram", Histogram: h})
+	}
+	c.lastNumGC = rtMetrics.NumGC
+	return metrics, nil
+}
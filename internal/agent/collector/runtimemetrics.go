@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"strings"
+	"unicode"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+// RuntimeMetricsName - the name of the collector of the runtime/metrics metrics
+const RuntimeMetricsName = "runtime_metrics"
+
+// ErrNoRuntimeMetrics - an error that occurs when no supported runtime metric is selected.
+var ErrNoRuntimeMetrics = errors.New("no runtime metrics are selected")
+
+func init() {
+	Register(RuntimeMetricsName, newRuntimeMetrics)
+}
+
+// runtimeMetric - the selected runtime metric and the name it is sent with
+type runtimeMetric struct {
+	metrics.Description
+	id string
+}
+
+// runtimeMetricsCollector - the collector of the metrics of the runtime/metrics package, it does not stop the world.
+// The cumulative integer metrics are sent as counters incremented since the previous collection,
+// the other scalar metrics are sent as gauges, the cumulative float metrics are gauges of their running totals.
+// The histograms are sent as the observations since the previous collection.
+// The first values of the cumulative metrics and the histograms are only remembered.
+type runtimeMetricsCollector struct {
+	base
+	selected []runtimeMetric
+	samples  []metrics.Sample
+	last     cumulative
+	// lastCounts are the bucket counts of the histograms at the previous collection by the metric name
+	lastCounts map[string][]uint64
+}
+
+// runtimeMetricsSettings - the settings of the runtime_metrics collector, Metrics selects the runtime/metrics names it sends
+type runtimeMetricsSettings struct {
+	Metrics Filter `yaml:"metrics"`
+}
+
+// newRuntimeMetrics - function to create the runtime/metrics collector, it returns an error if a filter pattern
+// is malformed or the filter selects no supported metric
+func newRuntimeMetrics(_ *config.AgentConfig, settings config.CollectorConfig) (Collector, error) {
+	var own runtimeMetricsSettings
+	if err := settings.Decode(&own); err != nil {
+		return nil, err
+	}
+	filter, err := newNameFilter(own.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+	var selected []runtimeMetric
+	var samples []metrics.Sample
+	for _, d := range metrics.All() {
+		if d.Kind == metrics.KindBad || !filter.matches(d.Name) {
+			continue
+		}
+		selected = append(selected, runtimeMetric{Description: d, id: sanitizeName(d.Name)})
+		samples = append(samples, metrics.Sample{Name: d.Name})
+	}
+	if len(selected) == 0 {
+		return nil, ErrNoRuntimeMetrics
+	}
+	return &runtimeMetricsCollector{
+		base:       base{name: RuntimeMetricsName, interval: settings.Interval},
+		selected:   selected,
+		samples:    samples,
+		last:       make(cumulative),
+		lastCounts: make(map[string][]uint64),
+	}, nil
+}
+
+// Collect reads the selected runtime metrics.
+func (c *runtimeMetricsCollector) Collect(_ context.Context) ([]models.Metric, error) {
+	metrics.Read(c.samples)
+	var res []models.Metric
+	for i, s := range c.samples {
+		m := c.selected[i]
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			if m.Cumulative {
+				res = c.last.appendDelta(res, m.id, nil, s.Value.Uint64())
+			} else {
+				res = append(res, Gauge(m.id, float64(s.Value.Uint64()), nil))
+			}
+		case metrics.KindFloat64:
+			res = append(res, Gauge(m.id, s.Value.Float64(), nil))
+		case metrics.KindFloat64Histogram:
+			if h := c.histogram(m.id, s.Value.Float64Histogram()); h != nil {
+				res = append(res, models.Metric{ID: m.id, MType: "histogram", Histogram: h})
+			}
+		}
+	}
+	return res, nil
+}
+
+// histogram - method to convert the runtime histogram into the observations since the previous collection.
+// The runtime bucket [Buckets[i], Buckets[i+1]) is counted with the upper bound Buckets[i+1],
+// the bucket with the infinite upper bound is the last bucket of the histogram above all the bounds.
+// The sum is estimated by the middles of the buckets, the infinite sides are replaced by the finite ones.
+func (c *runtimeMetricsCollector) histogram(id string, rh *metrics.Float64Histogram) *models.Histogram {
+	counts := append([]uint64(nil), rh.Counts...)
+	prev, ok := c.lastCounts[id]
+	c.lastCounts[id] = counts
+	if !ok || len(prev) != len(counts) {
+		return nil
+	}
+
+	bounds := append([]float64(nil), rh.Buckets[1:]...)
+	if len(bounds) > 0 && math.IsInf(bounds[len(bounds)-1], 1) {
+		bounds = bounds[:len(bounds)-1]
+	}
+	h := models.NewHistogram(bounds)
+	for i, n := range counts {
+		delta := n - prev[i]
+		if n < prev[i] {
+			delta = n
+		}
+		h.Counts[i] = delta
+		h.Count += delta
+		h.Sum += float64(delta) * bucketMiddle(rh.Buckets[i], rh.Buckets[i+1])
+	}
+	return h
+}
+
+// bucketMiddle - function to estimate the values of the bucket by its middle
+func bucketMiddle(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+		return 0
+	case math.IsInf(lower, -1):
+		return upper
+	case math.IsInf(upper, 1):
+		return lower
+	}
+	return (lower + upper) / 2
+}
+
+// sanitizeName - function to convert the runtime metric name into the agent metric name,
+// for example /sched/latencies:seconds becomes GoSchedLatenciesSeconds
+func sanitizeName(name string) string {
+	var b strings.Builder
+	b.WriteString("Go")
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
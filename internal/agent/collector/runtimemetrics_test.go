@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"runtime"
+	"runtime/metrics"
+	"testing"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/config"
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "Test 1", value: "/sched/latencies:seconds", want: "GoSchedLatenciesSeconds"},
+		{name: "Test 2", value: "/gc/cycles/total:gc-cycles", want: "GoGcCyclesTotalGcCycles"},
+		{name: "Test 3", value: "/godebug/non-default-behavior/http2client:events", want: "GoGodebugNonDefaultBehaviorHttp2clientEvents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeName(tt.value); got != tt.want {
+				t.Errorf("sanitizeName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeMetricsHistogram(t *testing.T) {
+	c := &runtimeMetricsCollector{lastCounts: make(map[string][]uint64)}
+	buckets := []float64{math.Inf(-1), 1, 2, math.Inf(1)}
+	tests := []struct {
+		name   string
+		counts []uint64
+		want   *models.Histogram
+	}{
+		{
+			// the first counts are only remembered
+			name:   "Test 1",
+			counts: []uint64{1, 2, 3},
+		},
+		{
+			name:   "Test 2",
+			counts: []uint64{1, 4, 4},
+			want:   &models.Histogram{Bounds: []float64{1, 2}, Counts: []uint64{0, 2, 1}, Count: 3, Sum: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.histogram("GoTest", &metrics.Float64Histogram{Counts: tt.counts, Buckets: buckets})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("histogram() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeMetricsCollect(t *testing.T) {
+	c, err := newRuntimeMetrics(nil, config.CollectorConfig{Settings: map[string]any{"metrics": Filter{
+		Include: []string{"/gc/cycles/total:gc-cycles", "/gc/heap/goal:bytes", "/sched/latencies:seconds"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := func() map[string]string {
+		metrics, err := c.Collect(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := make(map[string]string, len(metrics))
+		for _, m := range metrics {
+			res[m.ID] = m.MType
+		}
+		return res
+	}
+	// the cumulative counter and the histogram are sent from the second collection
+	if got, want := kinds(), map[string]string{"GoGcHeapGoalBytes": "gauge"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+	runtime.GC()
+	want := map[string]string{
+		"GoGcCyclesTotalGcCycles": "counter",
+		"GoGcHeapGoalBytes":       "gauge",
+		"GoSchedLatenciesSeconds": "histogram",
+	}
+	if got := kinds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+}
+
+func TestNewRuntimeMetricsNoMetrics(t *testing.T) {
+	settings := config.CollectorConfig{Settings: map[string]any{"metrics": Filter{Include: []string{"/unknown:bytes"}}}}
+	if _, err := newRuntimeMetrics(nil, settings); !errors.Is(err, ErrNoRuntimeMetrics) {
+		t.Errorf("newRuntimeMetrics() error = %v, want %v", err, ErrNoRuntimeMetrics)
+	}
+}
@@ -3,32 +3,77 @@
 package config
 
 import (
+	"bytes"
 	"crypto/rsa"
+	"encoding/json"
 	"net"
 	"os"
 	"time"
 
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 
 	"go.uber.org/zap"
 )
 
 // AgentConfig - a structure that describes the agent configuration.
 type AgentConfig struct {
-	ServerAddress  string        `yaml:"server" json:"address"`
-	Key            string        `yaml:"key"`
-	KeyFile        string        `yaml:"key_file" json:"crypto_key"`
-	LogLevel       string        `yaml:"log_level"`
-	RateLimit      int           `yaml:"rate_limit"`
-	RetryCount     int           `yaml:"retry_count"`
-	RetryWaitTime  time.Duration `yaml:"retry_wait_time"`
-	ReportInterval time.Duration `yaml:"report" json:"report_interval"`
-	PollInterval   time.Duration `yaml:"poll" json:"poll_interval"`
-	UseGRPC        bool          `yaml:"use_grpc" json:"use_grpc"`
+	ServerAddress  string            `yaml:"server" json:"address"`
+	Key            string            `yaml:"key"`
+	KeyFile        string            `yaml:"key_file" json:"crypto_key"`
+	LogLevel       string            `yaml:"log_level"`
+	RateLimit      int               `yaml:"rate_limit"`
+	RetryCount     int               `yaml:"retry_count"`
+	RetryWaitTime  time.Duration     `yaml:"retry_wait_time"`
+	ReportInterval time.Duration     `yaml:"report" json:"report_interval"`
+	PollInterval   time.Duration     `yaml:"poll" json:"poll_interval"`
+	UseGRPC        bool              `yaml:"use_grpc" json:"use_grpc"`
+	Labels         map[string]string `yaml:"labels" json:"labels"`
+	GCPauseBuckets []float64         `yaml:"gc_pause_buckets" json:"gc_pause_buckets"`
 	jsonLoaded     bool
 	PublicKey      *rsa.PublicKey
 	Logger         *zap.Logger
 	IPaddr         *net.IP
+
+	// Collectors are the settings of the metric collectors by name, the built-in collectors run unless disabled
+	Collectors map[string]CollectorConfig `yaml:"collectors" json:"collectors"`
+}
+
+// CollectorConfig - a structure that describes the settings of the metric collector.
+// The collector runs if it is enabled or if it is built-in and Enabled is not set,
+// it collects the metrics every Interval or every poll interval if Interval is not set.
+// The other keys of the collector section are its own settings, they are kept raw and decoded by the collector.
+type CollectorConfig struct {
+	Enabled  *bool          `yaml:"enabled" json:"enabled"`
+	Interval time.Duration  `yaml:"interval" json:"interval"`
+	Settings map[string]any `yaml:",inline" json:"-"`
+}
+
+// UnmarshalJSON decodes the common settings of the collector and keeps the other keys raw.
+func (c *CollectorConfig) UnmarshalJSON(data []byte) error {
+	// common has the fields of CollectorConfig without its methods
+	type common CollectorConfig
+	if err := json.Unmarshal(data, (*common)(c)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.Settings); err != nil {
+		return err
+	}
+	delete(c.Settings, "enabled")
+	delete(c.Settings, "interval")
+	return nil
+}
+
+// Decode decodes the own settings of the collector into v by the yaml tags of its fields,
+// it returns an error if a setting is unknown or has a wrong type.
+func (c CollectorConfig) Decode(v any) error {
+	data, err := yaml.Marshal(c.Settings)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	return decoder.Decode(v)
 }
 
 // GetConfig is a function that returns the agent configuration.
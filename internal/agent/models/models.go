@@ -4,8 +4,76 @@ package models
 
 // Metric is a struct for storing metrics.
 type Metric struct {
-	Value *float64 `json:"value,omitempty"`
-	Delta *int64   `json:"delta,omitempty"`
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
+	Value     *float64          `json:"value,omitempty"`
+	Delta     *int64            `json:"delta,omitempty"`
+	Histogram *Histogram        `json:"histogram,omitempty"`
+	ID        string            `json:"id"`
+	MType     string            `json:"type"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Histogram is a struct for storing observations in buckets.
+// Counts[i] is the number of observations less or equal to Bounds[i],
+// the last element of Counts counts the observations above the last bound.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Count  uint64    `json:"count"`
+	Sum    float64   `json:"sum"`
+}
+
+// NewHistogram creates an empty histogram with the given bucket bounds.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds the value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	i := 0
+	for i < len(h.Bounds) && v > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += v
+}
+
+// Reset removes all observations, the bounds are kept.
+func (h *Histogram) Reset() {
+	for i := range h.Counts {
+		h.Counts[i] = 0
+	}
+	h.Count = 0
+	h.Sum = 0
+}
+
+// Copy returns a deep copy of the histogram.
+func (h *Histogram) Copy() Histogram {
+	return Histogram{
+		Bounds: append([]float64(nil), h.Bounds...),
+		Counts: append([]uint64(nil), h.Counts...),
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
+
+// Merge adds the observations of the other histogram, it reports false if the bounds differ.
+func (h *Histogram) Merge(o Histogram) bool {
+	if len(h.Bounds) != len(o.Bounds) || len(h.Counts) != len(o.Counts) {
+		return false
+	}
+	for i, b := range h.Bounds {
+		if b != o.Bounds[i] {
+			return false
+		}
+	}
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+	return true
 }
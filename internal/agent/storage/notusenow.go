@@ -12,14 +12,14 @@ func (m *MetricStorage) URLMetrics(host string) []string {
 
 	var urls []string
 
-	for metric, value := range m.gauge {
-		generatedURL := fmt.Sprintf("%s/update/gauge/%s/%f", host, metric, value)
+	for key, value := range m.gauge {
+		generatedURL := fmt.Sprintf("%s/update/gauge/%s/%f", host, m.series[key].name, value)
 		urls = append(urls, generatedURL)
 	}
-	for metric, value := range m.counter {
-		generatedURL := fmt.Sprintf("%s/update/counter/%s/%d", host, metric, value)
+	for key, value := range m.counter {
+		generatedURL := fmt.Sprintf("%s/update/counter/%s/%d", host, m.series[key].name, value)
 		urls = append(urls, generatedURL)
+		m.counter[key] = 0
 	}
-	m.counter["PollCount"] = 0
 	return urls
 }
@@ -3,96 +3,79 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/h2p2f/practicum-metrics/internal/agent/models"
 )
 
 // JSONMetrics is a method of the MetricStorage structure that generates a slice of
-// JSON objects to send metrics to the server.
-// Required for backward compatibility. Currently not used.
-func (m *MetricStorage) JSONMetrics() [][]byte {
-
+// JSON objects to send metrics to the server, the labels are attached to every metric.
+func (m *MetricStorage) JSONMetrics(labels map[string]string) [][]byte {
 	var res [][]byte
-	var model models.Metric
-	m.mut.RLock()
-
-	for metric, value := range m.gauge {
-		value := value
-		model = models.Metric{
-			ID:    metric,
-			Value: &value,
-			MType: "gauge",
-		}
+	for _, model := range m.TakeMetrics(labels) {
 		mj, err := json.Marshal(model)
 		if err != nil {
 			fmt.Println(err)
 		}
 		res = append(res, mj)
 	}
-	for metric, value := range m.counter {
-		value := value
-		model = models.Metric{
-			ID:    metric,
-			Delta: &value,
-			MType: "counter",
-		}
-		mj, err := json.Marshal(model)
-		if err != nil {
-			fmt.Println(err)
-		}
-		res = append(res, mj)
-	}
-	m.mut.RUnlock()
-	m.mut.Lock()
-	defer m.mut.Unlock()
-	m.counter["PollCount"] = 0
 	return res
 }
 
 // BatchJSONMetrics is a method of the MetricStorage structure that generates
-// a batch JSON object to send metrics to the server.
-func (m *MetricStorage) BatchJSONMetrics() []byte {
-	var res []byte
-	var modelSlice []models.Metric
-	m.mut.RLock()
-
-	for metric, value := range m.gauge {
-		value := value
-		mt := models.Metric{
-			ID:    metric,
-			Value: &value,
-			MType: "gauge",
-		}
-		modelSlice = append(modelSlice, mt)
-	}
-	for metric, value := range m.counter {
-		value := value
-		mt := models.Metric{
-			ID:    metric,
-			Delta: &value,
-			MType: "counter",
-		}
-		modelSlice = append(modelSlice, mt)
-	}
-	res, err := json.Marshal(modelSlice)
+// a batch JSON object to send metrics to the server, the labels are attached to every metric.
+func (m *MetricStorage) BatchJSONMetrics(labels map[string]string) []byte {
+	res, err := json.Marshal(m.TakeMetrics(labels))
 	if err != nil {
 		fmt.Println(err)
 	}
-	m.mut.RUnlock()
-	m.mut.Lock()
-	defer m.mut.Unlock()
-	m.counter["PollCount"] = 0
 	return res
 }
 
-func (m *MetricStorage) GetAllGauge() map[string]float64 {
-	m.mut.RLock()
-	defer m.mut.RUnlock()
-	return m.gauge
+// TakeMetrics returns the stored metrics sorted by name and labels and resets the counters and the histograms,
+// the server accumulates them, so the increments are sent once.
+// The labels are attached to every metric, the labels of the metric take precedence.
+func (m *MetricStorage) TakeMetrics(labels map[string]string) []models.Metric {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := make([]models.Metric, 0, len(keys))
+	for _, key := range keys {
+		s := m.series[key]
+		model := models.Metric{ID: s.name, Labels: mergeLabels(labels, s.labels)}
+		if value, ok := m.gauge[key]; ok {
+			model.MType, model.Value = "gauge", &value
+		} else if value, ok := m.counter[key]; ok {
+			model.MType, model.Delta = "counter", &value
+			m.counter[key] = 0
+		} else if h, ok := m.histogram[key]; ok {
+			value := h.Copy()
+			model.MType, model.Histogram = "histogram", &value
+			h.Reset()
+		}
+		res = append(res, model)
+	}
+	return res
 }
 
-func (m *MetricStorage) GetAllCounter() map[string]int64 {
-	m.mut.RLock()
-	defer m.mut.RUnlock()
-	return m.counter
+// mergeLabels - function to attach the common labels to the labels of the metric
+func mergeLabels(common, own map[string]string) map[string]string {
+	if len(own) == 0 {
+		return common
+	}
+	if len(common) == 0 {
+		return own
+	}
+	res := make(map[string]string, len(common)+len(own))
+	for name, value := range common {
+		res[name] = value
+	}
+	for name, value := range own {
+		res[name] = value
+	}
+	return res
 }
@@ -2,20 +2,87 @@
 package storage
 
 import (
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
 )
 
-// MetricStorage stores metrics in memory.
+// series - the name and the labels of the stored metric
+type series struct {
+	name   string
+	labels map[string]string
+}
+
+// MetricStorage stores metrics in memory, a metric is identified by its name and labels.
+// The counters and the histograms hold the increments since the last report.
 type MetricStorage struct {
-	gauge   map[string]float64
-	counter map[string]int64
-	mut     sync.RWMutex
+	gauge     map[string]float64
+	counter   map[string]int64
+	histogram map[string]*models.Histogram
+	series    map[string]series
+	mut       sync.RWMutex
 }
 
 // NewAgentStorage creates a new metric storage.
 func NewAgentStorage() *MetricStorage {
 	return &MetricStorage{
-		gauge:   make(map[string]float64),
-		counter: make(map[string]int64),
+		gauge:     make(map[string]float64),
+		counter:   make(map[string]int64),
+		histogram: make(map[string]*models.Histogram),
+		series:    make(map[string]series),
+	}
+}
+
+// seriesKey - function to build the key of the metric from its name and sorted labels
+func seriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(labels))
+	for l := range labels {
+		names = append(names, l)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, l := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l)
+		b.WriteByte('=')
+		b.WriteString(labels[l])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Store saves the collected metrics: a gauge replaces the value, a counter is added to the value
+// and a histogram adds its observations, a histogram with other bounds replaces the stored one.
+func (m *MetricStorage) Store(metrics []models.Metric) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for _, metric := range metrics {
+		key := seriesKey(metric.ID, metric.Labels)
+		switch {
+		case metric.MType == "gauge" && metric.Value != nil:
+			m.gauge[key] = *metric.Value
+		case metric.MType == "counter" && metric.Delta != nil:
+			m.counter[key] += *metric.Delta
+		case metric.MType == "histogram" && metric.Histogram != nil:
+			if h, ok := m.histogram[key]; ok && h.Merge(*metric.Histogram) {
+				break
+			}
+			h := metric.Histogram.Copy()
+			m.histogram[key] = &h
+		default:
+			continue
+		}
+		if _, ok := m.series[key]; !ok {
+			m.series[key] = series{name: metric.ID, labels: metric.Labels}
+		}
 	}
 }
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/h2p2f/practicum-metrics/internal/agent/models"
+)
+
+func TestMetricStorage(t *testing.T) {
+	value, delta := 5.5, int64(2)
+	h := models.NewHistogram([]float64{10})
+	h.Observe(1)
+	db := NewAgentStorage()
+	for i := 0; i < 2; i++ {
+		db.Store([]models.Metric{
+			{ID: "Free", MType: "gauge", Value: &value, Labels: map[string]string{"mount": "/"}},
+			{ID: "PollCount", MType: "counter", Delta: &delta},
+			{ID: "Pauses", MType: "histogram", Histogram: h},
+		})
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "Test 1",
+			want: `[{"value":5.5,"id":"Free","type":"gauge","labels":{"mount":"/","service":"agent"}},` +
+				`{"histogram":{"bounds":[10],"counts":[2,0],"count":2,"sum":2},"id":"Pauses","type":"histogram","labels":{"service":"agent"}},` +
+				`{"delta":4,"id":"PollCount","type":"counter","labels":{"service":"agent"}}]`,
+		},
+		{
+			// the counters and the histograms are sent once
+			name: "Test 2",
+			want: `[{"value":5.5,"id":"Free","type":"gauge","labels":{"mount":"/","service":"agent"}},` +
+				`{"histogram":{"bounds":[10],"counts":[0,0],"count":0,"sum":0},"id":"Pauses","type":"histogram","labels":{"service":"agent"}},` +
+				`{"delta":0,"id":"PollCount","type":"counter","labels":{"service":"agent"}}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(db.TakeMetrics(map[string]string{"service": "agent"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("TakeMetrics() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+)
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	rulesPath := filepath.Join(dir, "rules.yaml")
+	if err := os.WriteFile(rulesPath, []byte("rules:\n  - name: HighHeap\n    expr: HeapInuse / HeapSys > 0.9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		conf       config.AlertingConfig
+		wantSource []string
+		wantErr    bool
+	}{
+		{
+			name: "Test 1",
+			conf: config.AlertingConfig{
+				RulesFile: rulesPath,
+				Rules: []config.AlertRule{
+					{Name: "LowMemory", Metric: `FreeMemory{host="a"}`, Op: "<", Threshold: 1e8, For: time.Minute},
+				},
+			},
+			wantSource: []string{`FreeMemory{host="a"} < 1e+08`, "HeapInuse / HeapSys > 0.9"},
+		},
+		{
+			name:    "Test 2",
+			conf:    config.AlertingConfig{Rules: []config.AlertRule{{Name: "Both", Expr: "a > 1", Metric: "a", Op: ">"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 3",
+			conf:    config.AlertingConfig{Rules: []config.AlertRule{{Name: "Op", Metric: "a", Op: "+"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 4",
+			conf:    config.AlertingConfig{Rules: []config.AlertRule{{Name: "A", Expr: "a > 1"}, {Name: "A", Expr: "b > 1"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 5",
+			conf:    config.AlertingConfig{Rules: []config.AlertRule{{Name: "Syntax", Expr: "a >"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 6",
+			conf:    config.AlertingConfig{Rules: []config.AlertRule{{Name: "Labels", Expr: "a > 1", Labels: map[string]string{"1bad": "x"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := FromConfig(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Errorf("FromConfig() error = %v, want %v", err, ErrInvalidRule)
+				}
+				return
+			}
+			if len(rules) != len(tt.wantSource) {
+				t.Fatalf("FromConfig() returned %d rules, want %d", len(rules), len(tt.wantSource))
+			}
+			for i, r := range rules {
+				if r.Source != tt.wantSource[i] {
+					t.Errorf("rule %d source = %q, want %q", i, r.Source, tt.wantSource[i])
+				}
+			}
+		})
+	}
+}
+
+// memoryStore - the state store that keeps the saved alerts in memory
+type memoryStore struct {
+	alerts []models.Alert
+	saves  int
+}
+
+func (s *memoryStore) LoadAlerts(_ context.Context) ([]models.Alert, error) {
+	return s.alerts, nil
+}
+
+func (s *memoryStore) SaveAlerts(_ context.Context, alerts []models.Alert) error {
+	s.alerts = alerts
+	s.saves++
+	return nil
+}
+
+// recordListener - the listener that records the states of the notified alerts
+type recordListener struct {
+	states []string
+}
+
+func (l *recordListener) AlertChanged(alert models.Alert) {
+	l.states = append(l.states, alert.Labels["host"]+" "+alert.State)
+}
+
+func TestManagerEval(t *testing.T) {
+	ctx := context.Background()
+	db := inmemorystorage.NewMemStorage(zaptest.NewLogger(t), 10)
+	rules, err := FromConfig(config.AlertingConfig{Rules: []config.AlertRule{{
+		Name:      "LowMemory",
+		Metric:    "FreeMemory",
+		Op:        "<",
+		Threshold: 100,
+		For:       time.Minute,
+		Severity:  "critical",
+		Labels:    map[string]string{"team": "ops"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memoryStore{}
+	m := NewManager(rules, db, store, 10*time.Minute, zaptest.NewLogger(t))
+	listener := &recordListener{}
+	m.Subscribe(listener)
+	start := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	hostA := models.Labels{"host": "a", "team": "ops"}
+
+	tests := []struct {
+		name      string
+		free      map[string]float64
+		at        time.Duration
+		wantRule  string
+		wantState map[string]string
+	}{
+		{name: "inactive", free: map[string]float64{"a": 500, "b": 500}, wantRule: models.AlertInactive, wantState: map[string]string{}},
+		{name: "pending", free: map[string]float64{"a": 50, "b": 500}, at: 30 * time.Second, wantRule: models.AlertPending,
+			wantState: map[string]string{"a": models.AlertPending}},
+		{name: "pending dropped", free: map[string]float64{"b": 50, "a": 500}, at: 60 * time.Second, wantRule: models.AlertPending,
+			wantState: map[string]string{"b": models.AlertPending}},
+		{name: "firing", free: map[string]float64{"b": 40}, at: 2 * time.Minute, wantRule: models.AlertFiring,
+			wantState: map[string]string{"b": models.AlertFiring}},
+		{name: "resolved", free: map[string]float64{"b": 500}, at: 3 * time.Minute, wantRule: models.AlertResolved,
+			wantState: map[string]string{"b": models.AlertResolved}},
+		{name: "active again", free: map[string]float64{"b": 10}, at: 4 * time.Minute, wantRule: models.AlertPending,
+			wantState: map[string]string{"b": models.AlertPending}},
+		{name: "new pending", free: map[string]float64{"a": 10, "b": 500}, at: 20 * time.Minute, wantRule: models.AlertPending,
+			wantState: map[string]string{"a": models.AlertPending}},
+	}
+	for _, tt := range tests {
+		for host, free := range tt.free {
+			if err := db.SetGauge(ctx, "FreeMemory", models.Labels{"host": host}, free); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := m.Eval(ctx, start.Add(tt.at)); err != nil {
+			t.Fatalf("%s: Eval() error = %v", tt.name, err)
+		}
+		statuses := m.Alerts(ctx)
+		if len(statuses) != 1 || statuses[0].State != tt.wantRule {
+			t.Fatalf("%s: rule statuses = %+v, want state %s", tt.name, statuses, tt.wantRule)
+		}
+		got := make(map[string]string)
+		for _, a := range statuses[0].Alerts {
+			if a.Severity != "critical" || a.Labels["team"] != "ops" {
+				t.Errorf("%s: alert %+v has no rule severity and labels", tt.name, a)
+			}
+			got[a.Labels["host"]] = a.State
+		}
+		if len(got) != len(tt.wantState) {
+			t.Errorf("%s: alerts = %v, want %v", tt.name, got, tt.wantState)
+		}
+		for host, state := range tt.wantState {
+			if got[host] != state {
+				t.Errorf("%s: alert of host %s is %q, want %q", tt.name, host, got[host], state)
+			}
+		}
+	}
+	// the states of the last evaluation are saved
+	if len(store.alerts) != 1 || store.alerts[0].Key() != models.SeriesKey("LowMemory", hostA) {
+		t.Errorf("saved alerts = %+v", store.alerts)
+	}
+
+	// only the alerts that have fired or resolved are notified
+	if want := []string{"b firing", "b resolved"}; !reflect.DeepEqual(listener.states, want) {
+		t.Errorf("notified alerts = %v, want %v", listener.states, want)
+	}
+
+	// the unchanged states are not saved again
+	saves := store.saves
+	if err := m.Eval(ctx, start.Add(20*time.Minute+30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if store.saves != saves {
+		t.Errorf("the unchanged states were saved, saves = %d, want %d", store.saves, saves)
+	}
+}
+
+func TestManagerRestore(t *testing.T) {
+	ctx := context.Background()
+	db := inmemorystorage.NewMemStorage(zaptest.NewLogger(t), 10)
+	if err := db.SetGauge(ctx, "FreeMemory", nil, 10); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := FromConfig(config.AlertingConfig{Rules: []config.AlertRule{
+		{Name: "LowMemory", Metric: "FreeMemory", Op: "<", Threshold: 100, For: 5 * time.Minute},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	store := &memoryStore{alerts: []models.Alert{
+		{Rule: "LowMemory", State: models.AlertPending, Value: 10, ActiveAt: now.Add(-10 * time.Minute)},
+		{Rule: "Removed", State: models.AlertFiring, ActiveAt: now.Add(-time.Hour)},
+	}}
+	m := NewManager(rules, db, store, 0, zaptest.NewLogger(t))
+	if err := m.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the pending alert restored after the restart keeps its activity time and fires
+	if err := m.Eval(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	statuses := m.Alerts(ctx)
+	if len(statuses) != 1 || len(statuses[0].Alerts) != 1 || statuses[0].Alerts[0].State != models.AlertFiring {
+		t.Errorf("Alerts() = %+v, want one firing alert", statuses)
+	}
+	if len(store.alerts) != 1 {
+		t.Errorf("saved alerts = %+v, want the alert of the removed rule dropped", store.alerts)
+	}
+}
+
+// unsupportedStore - the state store of a storage that does not keep the states
+type unsupportedStore struct{}
+
+func (unsupportedStore) LoadAlerts(_ context.Context) ([]models.Alert, error) {
+	return nil, servererrors.ErrNotImplemented
+}
+
+func (unsupportedStore) SaveAlerts(_ context.Context, _ []models.Alert) error {
+	return servererrors.ErrNotImplemented
+}
+
+func TestManagerUnsupportedStore(t *testing.T) {
+	ctx := context.Background()
+	db := inmemorystorage.NewMemStorage(zaptest.NewLogger(t), 10)
+	rules, err := FromConfig(config.AlertingConfig{Rules: []config.AlertRule{{Name: "Always", Expr: "1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(rules, db, unsupportedStore{}, 0, zaptest.NewLogger(t))
+	if err := m.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Eval(ctx, time.Now()); err != nil {
+		t.Errorf("Eval() error = %v, want the states kept only in memory", err)
+	}
+	if statuses := m.Alerts(ctx); statuses[0].State != models.AlertFiring {
+		t.Errorf("Alerts() = %+v, want the scalar condition firing", statuses)
+	}
+}
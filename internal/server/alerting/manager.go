@@ -0,0 +1,317 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/query"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+const (
+	// defaultInterval - the evaluation period used if it is not set
+	defaultInterval = 30 * time.Second
+	// defaultResolvedRetention - the time the resolved alerts are shown if it is not set
+	defaultResolvedRetention = 15 * time.Minute
+)
+
+// StateStore - the storage that keeps the alert states across restarts.
+// A storage that can not keep them returns servererrors.ErrNotImplemented.
+type StateStore interface {
+	LoadAlerts(ctx context.Context) ([]models.Alert, error)
+	SaveAlerts(ctx context.Context, alerts []models.Alert) error
+}
+
+// Listener - the receiver of the alerts that have fired or resolved.
+type Listener interface {
+	AlertChanged(alert models.Alert)
+}
+
+// RuleStatus - the state of the rule and its alerts, the state of the rule is the most severe state of its alerts.
+type RuleStatus struct {
+	Name      string         `json:"name"`
+	Expr      string         `json:"expr"`
+	For       string         `json:"for"`
+	Severity  string         `json:"severity,omitempty"`
+	Labels    models.Labels  `json:"labels,omitempty"`
+	State     string         `json:"state"`
+	LastEval  *time.Time     `json:"last_evaluation,omitempty"`
+	LastError string         `json:"last_error,omitempty"`
+	Alerts    []models.Alert `json:"alerts"`
+}
+
+// ruleState - the rule and the result of its last evaluation
+type ruleState struct {
+	Rule
+	lastEval time.Time
+	lastErr  error
+}
+
+// Manager - the evaluator of the alerting rules that keeps the states of the alerts.
+type Manager struct {
+	logger            *zap.Logger
+	engine            *query.Engine
+	store             StateStore
+	resolvedRetention time.Duration
+	listeners         []Listener
+
+	mut    sync.RWMutex
+	rules  []*ruleState
+	alerts map[string]*models.Alert
+}
+
+// NewManager creates a new instance of Manager reading the metrics from the storage,
+// the alert states are kept by the store if it is not nil.
+func NewManager(rules []Rule, db query.Storage, store StateStore, resolvedRetention time.Duration, logger *zap.Logger) *Manager {
+	if resolvedRetention <= 0 {
+		resolvedRetention = defaultResolvedRetention
+	}
+	m := &Manager{
+		logger:            logger,
+		engine:            query.NewEngine(db),
+		store:             store,
+		resolvedRetention: resolvedRetention,
+		alerts:            make(map[string]*models.Alert),
+	}
+	for _, r := range rules {
+		m.rules = append(m.rules, &ruleState{Rule: r})
+	}
+	return m
+}
+
+// Subscribe adds the listener that is notified of the alerts that fire or resolve,
+// it must be called before the rules are evaluated.
+func (m *Manager) Subscribe(l Listener) {
+	m.listeners = append(m.listeners, l)
+}
+
+// Restore loads the alert states from the store, the alerts of the rules that are no longer configured are dropped.
+// Without the store or if the storage does not keep the states, the alerts start inactive.
+func (m *Manager) Restore(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+	alerts, err := m.store.LoadAlerts(ctx)
+	if errors.Is(err, servererrors.ErrNotImplemented) {
+		m.logger.Info("the storage does not keep the alert states")
+		m.store = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for i := range alerts {
+		a := alerts[i]
+		if m.rule(a.Rule) == nil {
+			continue
+		}
+		m.alerts[a.Key()] = &a
+	}
+	return nil
+}
+
+// rule - method to find the rule by name
+func (m *Manager) rule(name string) *ruleState {
+	for _, r := range m.rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Eval evaluates every rule, moves the alerts to their new states and saves them if any state has changed.
+// The listeners are notified of the alerts that have fired or resolved. A rule that can not be evaluated keeps the states of its alerts.
+func (m *Manager) Eval(ctx context.Context, now time.Time) error {
+	results := make([]query.Result, len(m.rules))
+	errs := make([]error, len(m.rules))
+	for i, r := range m.rules {
+		results[i], errs[i] = m.engine.Eval(ctx, r.Expr, now)
+	}
+
+	m.mut.Lock()
+	changed := false
+	var transitions []models.Alert
+	for i, r := range m.rules {
+		r.lastEval, r.lastErr = now, errs[i]
+		if errs[i] != nil {
+			m.logger.Error("could not evaluate alerting rule", zap.String("rule", r.Name), zap.Error(errs[i]))
+			continue
+		}
+		if m.update(r.Rule, samples(results[i]), now, &transitions) {
+			changed = true
+		}
+	}
+	var alerts []models.Alert
+	if changed {
+		alerts = m.list()
+	}
+	m.mut.Unlock()
+
+	for _, a := range transitions {
+		for _, l := range m.listeners {
+			l.AlertChanged(a)
+		}
+	}
+	if !changed || m.store == nil {
+		return nil
+	}
+	return m.store.SaveAlerts(ctx, alerts)
+}
+
+// samples - function to get the series of the condition, a non-zero scalar is one series without labels
+func samples(result query.Result) []query.Sample {
+	if result.Type == query.TypeScalar {
+		if result.Scalar != nil && *result.Scalar != 0 {
+			return []query.Sample{{Value: *result.Scalar}}
+		}
+		return nil
+	}
+	return result.Vector
+}
+
+// update - method to move the alerts of the rule to their new states by the series of the condition,
+// the alerts that have fired or resolved are appended to the transitions, it reports whether any state has changed
+func (m *Manager) update(r Rule, series []query.Sample, now time.Time, transitions *[]models.Alert) bool {
+	changed := false
+	active := make(map[string]bool, len(series))
+	for _, s := range series {
+		a := &models.Alert{Rule: r.Name, Labels: mergeLabels(s.Labels, r.Labels), Severity: r.Severity}
+		key := a.Key()
+		active[key] = true
+		if cur, ok := m.alerts[key]; ok && cur.State != models.AlertResolved {
+			a = cur
+		} else {
+			a.State, a.ActiveAt = models.AlertPending, now
+			m.alerts[key] = a
+			changed = true
+		}
+		a.Value = s.Value
+		if a.State == models.AlertPending && now.Sub(a.ActiveAt) >= r.For {
+			fired := now
+			a.State, a.FiredAt = models.AlertFiring, &fired
+			*transitions = append(*transitions, *a)
+			changed = true
+		}
+	}
+	for key, a := range m.alerts {
+		if a.Rule != r.Name || active[key] {
+			continue
+		}
+		switch a.State {
+		case models.AlertPending:
+			delete(m.alerts, key)
+			changed = true
+		case models.AlertFiring:
+			resolved := now
+			a.State, a.ResolvedAt = models.AlertResolved, &resolved
+			*transitions = append(*transitions, *a)
+			changed = true
+		case models.AlertResolved:
+			if a.ResolvedAt == nil || now.Sub(*a.ResolvedAt) >= m.resolvedRetention {
+				delete(m.alerts, key)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// mergeLabels - function to merge the labels of the series and the rule, the labels of the rule take precedence
+func mergeLabels(series, rule models.Labels) models.Labels {
+	if len(series) == 0 && len(rule) == 0 {
+		return nil
+	}
+	labels := make(models.Labels, len(series)+len(rule))
+	for name, value := range series {
+		labels[name] = value
+	}
+	for name, value := range rule {
+		labels[name] = value
+	}
+	return labels
+}
+
+// list - method to copy the alerts sorted by key, the lock must be held
+func (m *Manager) list() []models.Alert {
+	alerts := make([]models.Alert, 0, len(m.alerts))
+	for _, a := range m.alerts {
+		alerts = append(alerts, *a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Key() < alerts[j].Key()
+	})
+	return alerts
+}
+
+// Alerts returns the states of the rules in the configuration order and their alerts sorted by labels.
+func (m *Manager) Alerts(ctx context.Context) []RuleStatus {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	alerts := m.list()
+	statuses := make([]RuleStatus, 0, len(m.rules))
+	for _, r := range m.rules {
+		status := RuleStatus{
+			Name:     r.Name,
+			Expr:     r.Source,
+			For:      r.For.String(),
+			Severity: r.Severity,
+			Labels:   r.Labels,
+			State:    models.AlertInactive,
+			Alerts:   []models.Alert{},
+		}
+		if !r.lastEval.IsZero() {
+			lastEval := r.lastEval
+			status.LastEval = &lastEval
+		}
+		if r.lastErr != nil {
+			status.LastError = r.lastErr.Error()
+		}
+		for _, a := range alerts {
+			if a.Rule != r.Name {
+				continue
+			}
+			status.Alerts = append(status.Alerts, a)
+			if severity[a.State] > severity[status.State] {
+				status.State = a.State
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// severity - the order of the states for the state of the rule
+var severity = map[string]int{
+	models.AlertInactive: 0,
+	models.AlertResolved: 1,
+	models.AlertPending:  2,
+	models.AlertFiring:   3,
+}
+
+// Run evaluates the rules every interval until the context is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if err := m.Eval(ctx, now); err != nil {
+				m.logger.Error("could not save alert states", zap.Error(err))
+			}
+		}
+	}
+}
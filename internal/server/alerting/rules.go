@@ -0,0 +1,102 @@
+// Package alerting evaluates the alerting rules against the storage.
+// A rule is a query expression or a threshold on a metric, every series returned by the condition is an alert.
+// An alert is pending while the condition holds for less than the for duration of the rule, then it is firing,
+// and it is resolved when the condition no longer holds. A pending alert whose condition no longer holds is inactive again.
+// The states are kept by the storage if it implements StateStore, so they survive the restarts.
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/query"
+)
+
+// ErrInvalidRule - an error that occurs when the alerting rule is inconsistent.
+var ErrInvalidRule = errors.New("invalid alerting rule")
+
+// thresholdOps - the comparison operators of the threshold rules
+var thresholdOps = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "==": true, "!=": true}
+
+// Rule - the parsed alerting rule.
+type Rule struct {
+	Name     string
+	Source   string
+	Expr     query.Expr
+	For      time.Duration
+	Severity string
+	Labels   models.Labels
+}
+
+// rulesFile - the structure of the rules file
+type rulesFile struct {
+	Rules []config.AlertRule `yaml:"rules"`
+}
+
+// FromConfig parses the rules of the alerting configuration and of its rules file.
+// It returns an error if the file can not be read, a name is missing or repeated,
+// the condition is not set by exactly one of the expression and the metric, or it can not be parsed.
+func FromConfig(conf config.AlertingConfig) ([]Rule, error) {
+	confs := conf.Rules
+	if conf.RulesFile != "" {
+		data, err := os.ReadFile(conf.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		var file rulesFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, conf.RulesFile, err)
+		}
+		confs = append(append([]config.AlertRule(nil), confs...), file.Rules...)
+	}
+	rules := make([]Rule, 0, len(confs))
+	names := make(map[string]bool, len(confs))
+	for _, c := range confs {
+		rule, err := newRule(c)
+		if err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("%w: the name %q is repeated", ErrInvalidRule, rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// newRule - function to parse the rule configuration, the threshold is turned into the comparison expression
+func newRule(c config.AlertRule) (Rule, error) {
+	if c.Name == "" {
+		return Rule{}, fmt.Errorf("%w: the name is missing", ErrInvalidRule)
+	}
+	source := c.Expr
+	switch {
+	case (c.Expr == "") == (c.Metric == ""):
+		return Rule{}, fmt.Errorf("%w: %s: exactly one of expr and metric must be set", ErrInvalidRule, c.Name)
+	case c.Metric != "":
+		if !thresholdOps[c.Op] {
+			return Rule{}, fmt.Errorf("%w: %s: unknown operator %q", ErrInvalidRule, c.Name, c.Op)
+		}
+		source = c.Metric + " " + c.Op + " " + strconv.FormatFloat(c.Threshold, 'g', -1, 64)
+	}
+	if c.For < 0 {
+		return Rule{}, fmt.Errorf("%w: %s: the for duration is negative", ErrInvalidRule, c.Name)
+	}
+	labels := models.Labels(c.Labels)
+	if err := labels.Validate(); err != nil {
+		return Rule{}, fmt.Errorf("%w: %s: %v", ErrInvalidRule, c.Name, err)
+	}
+	expr, err := query.Parse(source)
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w: %s: %v", ErrInvalidRule, c.Name, err)
+	}
+	return Rule{Name: c.Name, Source: source, Expr: expr, For: c.For, Severity: c.Severity, Labels: labels}, nil
+}
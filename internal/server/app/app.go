@@ -7,9 +7,6 @@ package app
 import (
 	"context"
 	"errors"
-	"github.com/h2p2f/practicum-metrics/internal/server/grpcserver"
-	pb "github.com/h2p2f/practicum-metrics/proto"
-	"google.golang.org/grpc"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
@@ -18,26 +15,22 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 
+	"github.com/h2p2f/practicum-metrics/internal/server/alerting"
 	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/graphite"
+	"github.com/h2p2f/practicum-metrics/internal/server/grpcserver"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver"
+	"github.com/h2p2f/practicum-metrics/internal/server/notifier"
+	"github.com/h2p2f/practicum-metrics/internal/server/statsd"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+	_ "github.com/h2p2f/practicum-metrics/internal/server/storage/drivers"
 	"github.com/h2p2f/practicum-metrics/internal/server/storage/filestorage"
-	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
 	"github.com/h2p2f/practicum-metrics/internal/server/storage/postgrestorage"
+	pb "github.com/h2p2f/practicum-metrics/proto"
 )
 
-// DataBaser interface for working with storage
-// the interface describes the methods of the inmemory storage and the postgreSQL storage
-type DataBaser interface {
-	SetCounter(key string, value int64)
-	SetGauge(key string, value float64)
-	GetCounter(name string) (value int64, err error)
-	GetGauge(name string) (value float64, err error)
-	GetCounters() map[string]int64
-	GetGauges() map[string]float64
-	Ping() error
-}
-
 // Run starts the application
 func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	// read configuration
@@ -45,52 +38,82 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	if err != nil {
 		panic(err)
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	// create inmemory storage
-	memDB := inmemorystorage.NewMemStorage(logger)
-	// assign the db variable to the inmemory storage
-	var db DataBaser = memDB
-	// initialize file storage
-	file := filestorage.NewFileDB(conf.File.Path, conf.File.StoreInterval, logger)
-	// create postgreSQL storage
-	pgDB := postgrestorage.NewPostgresDB(conf.DB.Dsn, logger)
-	defer pgDB.Close()
-	// if the config specifies to use postgreSQL - create tables
-	if conf.DB.UsePG {
-		err := pgDB.Create()
+	// in the migrate mode only the migration command is run
+	if conf.DB.MigrateCommand != "" {
+		pgDB, err := postgrestorage.NewPostgresDB(context.Background(), conf.DB, logger)
 		if err != nil {
-			logger.Sugar().Errorf("Error creating DB: %s", err)
+			logger.Fatal("could not open database", zap.Error(err))
 		}
-		// assign the db variable to the postgreSQL storage
-		db = pgDB
+		err = pgDB.Migrate(conf.DB.MigrateCommand)
+		pgDB.Close(context.Background()) //nolint:errcheck
+		if err != nil {
+			// the failed migration exits with a non-zero code for the deploy scripts
+			logger.Fatal("migration failed", zap.Error(err))
+		}
+		close(connectionsClosed)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// open the storage of the configured driver, its background work stops when storageCtx is done
+	storageCtx, storageCancel := context.WithCancel(context.Background())
+	defer storageCancel()
+	db, err := storage.Open(storageCtx, conf.Storage.Driver, conf, logger)
+	if err != nil {
+		logger.Fatal("could not open storage", zap.String("driver", conf.Storage.Driver), zap.Error(err))
+	}
+	// create the alerting rules, their states are kept by the storage if it supports it
+	rules, err := alerting.FromConfig(conf.Alerting)
+	if err != nil {
+		logger.Fatal("could not read alerting rules", zap.Error(err))
+	}
+	store, _ := db.(alerting.StateStore)
+	alerts := alerting.NewManager(rules, db, store, conf.Alerting.ResolvedRetention, logger)
+	// create the webhook notifier of the alerts and the watched metrics, the bodies are signed with the server key
+	notify, err := notifier.New(conf.Notifier, conf.HTTP.Key, logger)
+	if err != nil {
+		logger.Fatal("could not create notifier", zap.Error(err))
 	}
-	// if the config specifies not to use postgreSQL, but a file - restore metrics from file
-	if !conf.DB.UsePG && conf.File.UseFile && conf.File.Restore {
-		restoreFromFile(ctx, logger, file, memDB)
+	alerts.Subscribe(notify)
+	db = notifier.Watch(db, notify)
+	notifyDone := make(chan struct{})
+	go func() {
+		notify.Run(storageCtx)
+		close(notifyDone)
+	}()
+	if err := alerts.Restore(ctx); err != nil {
+		logger.Error("could not restore alert states", zap.Error(err))
 	}
-	// if the config specifies not to use postgreSQL, but a file
-	// without restoring saved data - start writing metrics to file
-	if !conf.DB.UsePG && conf.File.UseFile {
-		go saveToFile(ctx, conf.File.StoreInterval, file, logger, memDB)
+	alertsDone := make(chan struct{})
+	if len(rules) > 0 {
+		logger.Info("Started alerting", zap.Int("rules", len(rules)))
+		go func() {
+			alerts.Run(storageCtx, conf.Alerting.Interval)
+			close(alertsDone)
+		}()
+	} else {
+		close(alertsDone)
 	}
 	// collect fields for logger
 	fields := []zapcore.Field{
 		zap.String("address", conf.HTTP.Address),
 		zap.String("log_level", conf.LogLevel),
+		zap.String("storage", conf.Storage.Driver),
 	}
-	if conf.DB.UsePG {
+	switch conf.Storage.Driver {
+	case postgrestorage.Driver:
 		fields = append(fields, zap.String("postgreSQL", conf.DB.Dsn))
-	} else if conf.File.UseFile {
+	case filestorage.Driver:
 		fields = append(fields, zap.String("file_path", conf.File.Path))
 		fields = append(fields, zap.String("store_interval", conf.File.StoreInterval.String()))
 		fields = append(fields, zap.Bool("restore_file", conf.File.Restore))
+		fields = append(fields, zap.Bool("wal", conf.File.WAL))
 	}
 	logger.Info("Started http server", fields...)
 	// create http server
 	srv := &http.Server{
 		Addr:    conf.HTTP.Address,
-		Handler: httpserver.MetricRouter(logger, db, conf),
+		Handler: httpserver.MetricRouter(logger, db, alerts, conf),
 	}
 	// start http server
 	go func() {
@@ -114,6 +137,39 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 		}
 	}()
 
+	// the listeners stop before the storage, so their last metrics are stored
+	listenersCtx, listenersCancel := context.WithCancel(context.Background())
+	defer listenersCancel()
+	// start statsd listener if it is configured
+	statsdDone := make(chan struct{})
+	if conf.StatsD.Address != "" {
+		logger.Info("Started statsd server", zap.String("address", conf.StatsD.Address))
+		statsdServer := statsd.NewServer(conf.StatsD.Address, conf.StatsD.FlushInterval, conf.StatsD.TimerBuckets, db, logger)
+		go func() {
+			if err := statsdServer.Run(listenersCtx); err != nil {
+				logger.Error("statsd server error", zap.Error(err))
+			}
+			close(statsdDone)
+		}()
+	} else {
+		close(statsdDone)
+	}
+
+	// start graphite listener if it is configured
+	graphiteDone := make(chan struct{})
+	if conf.Graphite.Address != "" {
+		logger.Info("Started graphite server", zap.String("address", conf.Graphite.Address))
+		graphiteServer := graphite.NewServer(conf.Graphite.Address, db, logger)
+		go func() {
+			if err := graphiteServer.Run(listenersCtx); err != nil {
+				logger.Error("graphite server error", zap.Error(err))
+			}
+			close(graphiteDone)
+		}()
+	} else {
+		close(graphiteDone)
+	}
+
 	// wait for done signal
 	<-sigint
 	logger.Info("Shutting down server...")
@@ -121,8 +177,14 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	if err := srv.Shutdown(ctx2); err != nil {
 		logger.Fatal("server shutdown error", zap.Error(err))
 	}
-	if conf.DB.UsePG {
-		pgDB.Close()
+	listenersCancel()
+	<-statsdDone
+	<-graphiteDone
+	storageCancel()
+	<-alertsDone
+	<-notifyDone
+	if err := db.Close(ctx2); err != nil {
+		logger.Error("could not close storage", zap.Error(err))
 	}
 	cancel2()
 	close(sigint)
@@ -130,42 +192,3 @@ func Run(sigint chan os.Signal, connectionsClosed chan<- struct{}) {
 	close(connectionsClosed)
 	cancel()
 }
-
-// saveToFile - function for writing metrics to a file
-func saveToFile(
-	ctx context.Context,
-	interval time.Duration,
-	file *filestorage.FileDB,
-	logger *zap.Logger,
-	memDB *inmemorystorage.MemStorage) {
-
-	t := time.NewTicker(interval)
-	defer t.Stop()
-
-	for range t.C {
-		metrics := memDB.GetAllSerialized()
-		err := file.Write(ctx, metrics)
-		if err != nil {
-			logger.Error("could not write metrics to file", zap.Error(err))
-		}
-		if ctx.Err() != nil && errors.Is(context.Canceled, ctx.Err()) {
-			return
-		}
-	}
-}
-
-// restoreFromFile - function for restoring metrics from a file
-func restoreFromFile(
-	ctx context.Context,
-	logger *zap.Logger,
-	file *filestorage.FileDB,
-	memDB *inmemorystorage.MemStorage) {
-	metrics, err := file.Read(ctx)
-	if err != nil {
-		logger.Error("could not read metrics from file", zap.Error(err))
-	}
-	err = memDB.RestoreFromSerialized(metrics)
-	if err != nil {
-		logger.Error("could not restore metrics from file", zap.Error(err))
-	}
-}
@@ -13,11 +13,18 @@ import (
 
 // ServerConfig - server configuration structure
 type ServerConfig struct {
-	LogLevel string            `yaml:"log_level"`
-	HTTP     HTTPServerParams  `yaml:"http_server"`
-	GRPC     GRPCServerParams  `yaml:"grpc_server"`
-	DB       DatabaseConfig    `yaml:"database"`
-	File     FileStorageConfig `yaml:"file_storage"`
+	LogLevel string               `yaml:"log_level"`
+	HTTP     HTTPServerParams     `yaml:"http_server"`
+	GRPC     GRPCServerParams     `yaml:"grpc_server"`
+	Storage  StorageConfig        `yaml:"storage"`
+	DB       DatabaseConfig       `yaml:"database"`
+	Cache    CacheConfig          `yaml:"cache"`
+	File     FileStorageConfig    `yaml:"file_storage"`
+	History  HistoryConfig        `yaml:"history"`
+	StatsD   StatsDServerParams   `yaml:"statsd_server"`
+	Graphite GraphiteServerParams `yaml:"graphite_server"`
+	Alerting AlertingConfig       `yaml:"alerting"`
+	Notifier NotifierConfig       `yaml:"notifier"`
 }
 
 // ServerParams - server parameters structure
@@ -31,24 +38,159 @@ type HTTPServerParams struct {
 	TrustSubnet       *net.IPNet
 }
 
+// StorageConfig - storage configuration structure, Driver is the name of the backend in the storage registry:
+// memory, file, postgres or cache. The options of the file, postgres and cache drivers are in their own sections.
+type StorageConfig struct {
+	Driver string `yaml:"driver" json:"storage_driver"`
+	// Shards is the number of the lock shards of the memory storage
+	Shards int `yaml:"shards" json:"storage_shards"`
+	// fileSet and dsnSet are true if the file path or the database DSN is set by a flag or an environment variable
+	fileSet bool
+	dsnSet  bool
+}
+
 // FileStorageConfig - file storage configuration structure
 type FileStorageConfig struct {
 	Path          string        `yaml:"path" json:"store_file"`
 	StoreInterval time.Duration `yaml:"flush_interval"`
 	Restore       bool          `yaml:"restore" json:"restore"`
-	UseFile       bool          `yaml:"use_file"`
+	// Base is the storage driver whose metrics are persisted to the file, memory by default
+	Base string `yaml:"base" json:"file_storage_base"`
+	// WAL enables the write-ahead log, every update is appended to the log and the flush interval sets the compaction period
+	WAL bool `yaml:"wal" json:"wal"`
+	// WALSync is the fsync policy of the write-ahead log: always, interval or never
+	WALSync string `yaml:"wal_sync" json:"wal_sync"`
+	// Generations is the number of the previous snapshots kept to restore from if the last one is corrupted
+	Generations int `yaml:"generations" json:"generations"`
+	// Compress enables the gzip compression of the snapshots
+	Compress bool `yaml:"compress" json:"compress"`
+}
+
+// CacheConfig - write-back cache configuration structure, the pending updates are flushed to the base storage
+// every FlushInterval or when MaxPending series are pending
+type CacheConfig struct {
+	Base          string        `yaml:"base" json:"cache_base"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	MaxPending    int           `yaml:"max_pending" json:"cache_max_pending"`
 }
 
-// DatabaseConfig - database configuration structure
+// DatabaseConfig - database configuration structure, the pool options left zero keep the pgxpool defaults
 type DatabaseConfig struct {
-	Dsn   string `yaml:"dsn" json:"database_dsn"`
-	UsePG bool   `yaml:"use_pg"`
+	Dsn            string `yaml:"dsn" json:"database_dsn"`
+	AutoMigrate    bool   `yaml:"auto_migrate" json:"auto_migrate"`
+	MigrateCommand string `yaml:"-" json:"-"`
+	// MaxConns and MinConns are the limits of the connection pool size
+	MaxConns int32 `yaml:"max_conns" json:"database_max_conns"`
+	MinConns int32 `yaml:"min_conns" json:"database_min_conns"`
+	// MaxConnLifetime and MaxConnIdleTime are the times after which a connection is closed
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	// HealthCheckPeriod is the period of the health check of the idle connections
+	HealthCheckPeriod time.Duration `yaml:"health_check_period"`
+	// CopyThreshold is the batch size from which the metrics are written with COPY through a staging table
+	CopyThreshold int `yaml:"copy_threshold" json:"database_copy_threshold"`
+}
+
+// HistoryConfig - metrics history configuration structure
+type HistoryConfig struct {
+	Depth int `yaml:"depth" json:"history_depth"`
+	// Retention are the retention policies of the metric names, the first policy whose pattern matches the name is used
+	Retention []RetentionPolicy `yaml:"retention"`
+	// CompactInterval is the period of the history downsampling
+	CompactInterval time.Duration `yaml:"compact_interval"`
+}
+
+// RetentionPolicy - retention policy configuration structure, Pattern is the shell pattern of the metric name.
+// The raw points are kept for Raw, then they are rolled up into 1-minute points kept up to the age of Minute
+// and into 1-hour points kept up to the age of Hour, a zero age disables the rollup.
+type RetentionPolicy struct {
+	Pattern string        `yaml:"pattern"`
+	Raw     time.Duration `yaml:"raw"`
+	Minute  time.Duration `yaml:"minute"`
+	Hour    time.Duration `yaml:"hour"`
+}
+
+// AlertingConfig - alerting configuration structure, the rules are evaluated every Interval.
+// The rules of RulesFile are added to the rules of the section, the resolved alerts are shown for ResolvedRetention.
+type AlertingConfig struct {
+	Interval          time.Duration `yaml:"interval"`
+	ResolvedRetention time.Duration `yaml:"resolved_retention"`
+	RulesFile         string        `yaml:"rules_file" json:"alert_rules_file"`
+	Rules             []AlertRule   `yaml:"rules"`
+}
+
+// AlertRule - alerting rule configuration structure, the condition is the query expression Expr
+// or the threshold Op Threshold on the Metric selector, for example FreeMemory < 1e8.
+// The alert fires when the condition holds for the For duration.
+type AlertRule struct {
+	Name      string            `yaml:"name"`
+	Expr      string            `yaml:"expr"`
+	Metric    string            `yaml:"metric"`
+	Op        string            `yaml:"op"`
+	Threshold float64           `yaml:"threshold"`
+	For       time.Duration     `yaml:"for"`
+	Severity  string            `yaml:"severity"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+// NotifierConfig - webhook notifications configuration structure.
+// A failed delivery is retried Retries times waiting Backoff doubled after every attempt up to MaxBackoff,
+// the events that can not be delivered are appended to DeadLetterFile.
+type NotifierConfig struct {
+	Receivers      []ReceiverConfig `yaml:"receivers"`
+	QueueSize      int              `yaml:"queue_size"`
+	Timeout        time.Duration    `yaml:"timeout"`
+	Retries        int              `yaml:"retries"`
+	Backoff        time.Duration    `yaml:"backoff"`
+	MaxBackoff     time.Duration    `yaml:"max_backoff"`
+	DeadLetterFile string           `yaml:"dead_letter_file" json:"dead_letter_file"`
+}
+
+// ReceiverConfig - webhook receiver configuration structure. Events are the types of the events sent to the URL,
+// Metrics are the shell patterns of the metric names whose changes are sent.
+// Template is the text/template of the request body, the event is sent in JSON if it is empty.
+type ReceiverConfig struct {
+	Name     string            `yaml:"name"`
+	URL      string            `yaml:"url"`
+	Events   []string          `yaml:"events"`
+	Metrics  []string          `yaml:"metrics"`
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
 }
 
 type GRPCServerParams struct {
 	Address string `yaml:"host" json:"grpc_address"`
 }
 
+// GraphiteServerParams - Graphite plaintext listener parameters structure, the listener is started if the address is set
+type GraphiteServerParams struct {
+	Address string `yaml:"host" json:"graphite_address"`
+}
+
+// StatsDServerParams - StatsD listener parameters structure, the listener is started if the address is set
+type StatsDServerParams struct {
+	Address       string        `yaml:"host" json:"statsd_address"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	TimerBuckets  []float64     `yaml:"timer_buckets"`
+}
+
+// selectDriver - method of selecting the storage driver if it is not set explicitly by the configuration file,
+// the -storage flag or the STORAGE_DRIVER variable: for backward compatibility the database DSN selects postgres,
+// the file path selects file, otherwise memory is used
+func (config *ServerConfig) selectDriver() {
+	if config.Storage.Driver != "" {
+		return
+	}
+	switch {
+	case config.Storage.dsnSet:
+		config.Storage.Driver = "postgres"
+	case config.Storage.fileSet:
+		config.Storage.Driver = "file"
+	default:
+		config.Storage.Driver = "memory"
+	}
+}
+
 // GetConfig - function of obtaining the server configuration, processes the yaml file, flags and environment variables
 func GetConfig() (*ServerConfig, *zap.Logger, error) {
 
@@ -83,6 +225,7 @@ func GetConfig() (*ServerConfig, *zap.Logger, error) {
 
 	// overwrite config with environment variables
 	config.envLoader(logger)
+	config.selectDriver()
 
 	// load crypto key
 	err = config.cryptoLoader(logger)
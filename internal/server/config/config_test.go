@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSelectDriver(t *testing.T) {
+	tests := []struct {
+		name   string
+		yaml   string
+		args   []string
+		env    map[string]string
+		driver string
+	}{
+		{
+			name:   "Test 1",
+			driver: "memory",
+		},
+		{
+			name:   "Test 2",
+			args:   []string{"-f", "/tmp/metrics.json"},
+			driver: "file",
+		},
+		{
+			name:   "Test 3",
+			args:   []string{"-f", "/tmp/metrics.json"},
+			env:    map[string]string{"DATABASE_DSN": "postgres://localhost/metrics"},
+			driver: "postgres",
+		},
+		// the explicit driver is not switched by the DSN
+		{
+			name:   "Test 4",
+			args:   []string{"-storage", "memory"},
+			env:    map[string]string{"DATABASE_DSN": "postgres://localhost/metrics"},
+			driver: "memory",
+		},
+		{
+			name:   "Test 5",
+			args:   []string{"-d", "postgres://localhost/metrics"},
+			env:    map[string]string{"STORAGE_DRIVER": "file"},
+			driver: "file",
+		},
+		{
+			name:   "Test 6",
+			yaml:   "cache",
+			args:   []string{"-d", "postgres://localhost/metrics", "-f", "/tmp/metrics.json"},
+			driver: "cache",
+		},
+		{
+			name:   "Test 7",
+			yaml:   "file",
+			args:   []string{"-storage", "memory"},
+			env:    map[string]string{"FILE_STORAGE_PATH": "/tmp/metrics.json"},
+			driver: "memory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CONFIG", "DATABASE_DSN", "FILE_STORAGE_PATH", "STORAGE_DRIVER"} {
+				t.Setenv(name, tt.env[name])
+			}
+			args := os.Args
+			os.Args = append([]string{"server"}, tt.args...)
+			defer func() { os.Args = args }()
+
+			var config ServerConfig
+			config.Storage.Driver = tt.yaml
+			logger := zaptest.NewLogger(t)
+			config.flagLoader(logger)
+			config.envLoader(logger)
+			config.selectDriver()
+			if config.Storage.Driver != tt.driver {
+				t.Errorf("driver = %q, want %q", config.Storage.Driver, tt.driver)
+			}
+		})
+	}
+}
@@ -15,7 +15,7 @@ func (config *ServerConfig) envLoader(logger *zap.Logger) {
 	}
 	if envFilePath := os.Getenv("FILE_STORAGE_PATH"); envFilePath != "" {
 		config.File.Path = envFilePath
-		config.File.UseFile = true
+		config.Storage.fileSet = true
 	}
 	if envStoreInterval := os.Getenv("STORE_INTERVAL"); envStoreInterval != "" {
 		if isNumeric(envStoreInterval) {
@@ -30,12 +30,31 @@ func (config *ServerConfig) envLoader(logger *zap.Logger) {
 		}
 		config.File.Restore = envRestore
 	}
+	if envWAL := os.Getenv("FILE_STORAGE_WAL"); envWAL != "" {
+		envWAL, err := strconv.ParseBool(envWAL)
+		if err != nil {
+			logger.Debug("failed to parse FILE_STORAGE_WAL env variable", zap.Error(err))
+		}
+		config.File.WAL = envWAL
+	}
+	if envWALSync := os.Getenv("WAL_SYNC"); envWALSync != "" {
+		config.File.WALSync = envWALSync
+	}
 	if envDSN := os.Getenv("DATABASE_DSN"); envDSN != "" {
 		config.DB.Dsn = envDSN
-		config.DB.UsePG = true
+		config.Storage.dsnSet = true
+	}
+	if envDriver := os.Getenv("STORAGE_DRIVER"); envDriver != "" {
+		config.Storage.Driver = envDriver
 	}
 	if envKey := os.Getenv("KEY"); envKey != "" {
 		config.HTTP.Key = envKey
 	}
+	if envGraphite := os.Getenv("GRAPHITE_ADDRESS"); envGraphite != "" {
+		config.Graphite.Address = envGraphite
+	}
+	if envRules := os.Getenv("ALERT_RULES_FILE"); envRules != "" {
+		config.Alerting.RulesFile = envRules
+	}
 
 }
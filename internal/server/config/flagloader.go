@@ -56,19 +56,20 @@ func (config *ServerConfig) flagLoader(logger *zap.Logger) {
 	fs.StringVar(&config.File.Path, "f", config.File.Path, "File path")
 	fs.DurationVar(&config.File.StoreInterval, "i", config.File.StoreInterval, "Store interval")
 	fs.BoolVar(&config.File.Restore, "r", config.File.Restore, "Restore")
+	fs.BoolVar(&config.File.WAL, "wal", config.File.WAL, "Append every update to the write-ahead log")
 	fs.StringVar(&config.DB.Dsn, "d", config.DB.Dsn, "Database DSN")
+	fs.StringVar(&config.Storage.Driver, "storage", config.Storage.Driver, "Storage driver (memory, file, postgres or cache)")
 	fs.StringVar(&config.HTTP.Key, "k", config.HTTP.Key, "Key")
 	fs.StringVar(&config.HTTP.KeyFile, "crypto-key", config.HTTP.KeyFile, "RSA key file")
+	fs.StringVar(&config.Graphite.Address, "graphite", config.Graphite.Address, "Graphite server address")
+	fs.StringVar(&config.Alerting.RulesFile, "alert-rules", config.Alerting.RulesFile, "Alerting rules file")
+	fs.StringVar(&config.DB.MigrateCommand, "migrate", "", "Run the migration command (up, down or version) and exit")
 	err = fs.Parse(os.Args[1:]) //nolint:errcheck
 	if err != nil {
 		log.Println(err)
 	}
-	if isSet(fs, "f") {
-		config.File.UseFile = true
-	}
-	if isSet(fs, "d") {
-		config.DB.UsePG = true
-	}
+	config.Storage.fileSet = config.Storage.fileSet || isSet(fs, "f")
+	config.Storage.dsnSet = config.Storage.dsnSet || isSet(fs, "d")
 	if !isSet(fs, "k") {
 		config.HTTP.Key = ""
 	}
@@ -0,0 +1,161 @@
+// Package graphite implements a TCP listener of the Graphite plaintext protocol,
+// the received metrics are stored as gauges.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// errSyntax - an error that occurs when the line does not follow the Graphite plaintext protocol
+var errSyntax = errors.New("invalid graphite syntax")
+
+// Updater is an interface that updates gauges at the given time.
+//
+//go:generate mockery --name Updater --output ./mocks --filename mocks_graphite.go
+type Updater interface {
+	SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error
+}
+
+// Server - a Graphite plaintext server that listens on TCP.
+type Server struct {
+	address string
+	db      Updater
+	logger  *zap.Logger
+}
+
+// NewServer creates a Graphite server.
+func NewServer(address string, db Updater, logger *zap.Logger) *Server {
+	return &Server{
+		address: address,
+		db:      db,
+		logger:  logger,
+	}
+}
+
+// Run accepts connections until the context is done, every connection is served in its own goroutine.
+// On stop the reading of the connections is interrupted, the lines already received are stored
+// and Run returns after all the connections are served.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the reading is interrupted on stop by the deadline
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-ctx.Done():
+					conn.SetReadDeadline(time.Now()) //nolint:errcheck
+				case <-done:
+				}
+			}()
+			// the writes are not cancelled on stop, so the received lines reach the storage
+			s.Serve(context.Background(), conn)
+			close(done)
+		}()
+	}
+}
+
+// Serve reads the lines from the connection until it is closed, the invalid lines are logged and skipped.
+// The lines that the storage fails to write are logged and dropped.
+func (s *Server) Serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, labels, value, ts, err := parseLine(line, time.Now())
+		if err != nil {
+			s.logger.Info("could not parse graphite line", zap.String("line", line), zap.Error(err))
+			continue
+		}
+		if err := s.db.SetGaugeAt(ctx, name, labels, value, ts); err != nil {
+			s.logger.Error("could not store graphite metric", zap.String("name", name), zap.Error(err))
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, os.ErrDeadlineExceeded) {
+		s.logger.Error("could not read graphite connection", zap.Error(err))
+	}
+}
+
+// parseLine parses one line of the plaintext protocol:
+//
+//	path[;tag=value...] value [timestamp]
+//
+// The timestamp is in unix seconds, now is used if it is missing or equal to -1.
+// The tags of the Graphite tagged series become labels.
+// The value must be finite and not negative as the value of any gauge.
+func parseLine(line string, now time.Time) (string, models.Labels, float64, time.Time, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", nil, 0, time.Time{}, errSyntax
+	}
+	parts := strings.Split(fields[0], ";")
+	name := parts[0]
+	if name == "" {
+		return "", nil, 0, time.Time{}, fmt.Errorf("%w: empty path", errSyntax)
+	}
+	var labels models.Labels
+	for _, tag := range parts[1:] {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok || k == "" || v == "" {
+			return "", nil, 0, time.Time{}, fmt.Errorf("%w: bad tag %q", errSyntax, tag)
+		}
+		if labels == nil {
+			labels = make(models.Labels)
+		}
+		labels[k] = v
+	}
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", nil, 0, time.Time{}, fmt.Errorf("%w: bad value %q", errSyntax, fields[1])
+	}
+	// the gauge is checked as the metrics of the other protocols
+	if err := (&models.Metric{ID: name, MType: "gauge", Value: &value, Labels: labels}).Validate(); err != nil {
+		return "", nil, 0, time.Time{}, err
+	}
+	ts := now
+	if len(fields) == 3 && fields[2] != "-1" {
+		sec, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || math.IsNaN(sec) || math.IsInf(sec, 0) {
+			return "", nil, 0, time.Time{}, fmt.Errorf("%w: bad timestamp %q", errSyntax, fields[2])
+		}
+		whole, frac := math.Modf(sec)
+		ts = time.Unix(int64(whole), int64(frac*1e9))
+	}
+	return name, labels, value, ts, nil
+}
@@ -0,0 +1,172 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/graphite/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name       string
+		line       string
+		wantName   string
+		wantLabels models.Labels
+		wantValue  float64
+		wantTS     time.Time
+		wantErr    bool
+	}{
+		{
+			name:      "Test 1",
+			line:      "servers.a.load 0.5 1700000000",
+			wantName:  "servers.a.load",
+			wantValue: 0.5,
+			wantTS:    time.Unix(1700000000, 0),
+		},
+		{
+			name:       "Test 2",
+			line:       "disk.used;host=a;dc=eu 42 -1",
+			wantName:   "disk.used",
+			wantLabels: models.Labels{"host": "a", "dc": "eu"},
+			wantValue:  42,
+			wantTS:     now,
+		},
+		{
+			name:      "Test 3",
+			line:      "disk.used 42",
+			wantName:  "disk.used",
+			wantValue: 42,
+			wantTS:    now,
+		},
+		{
+			name:    "Test 4",
+			line:    "disk.used abc 1700000000",
+			wantErr: true,
+		},
+		{
+			name:    "Test 5",
+			line:    "disk.used;host 1 1700000000",
+			wantErr: true,
+		},
+		{
+			name:    "Test 6",
+			line:    "disk.used",
+			wantErr: true,
+		},
+		{
+			name:    "Test 7",
+			line:    "disk.used 1 yesterday",
+			wantErr: true,
+		},
+		// the non-finite and negative values are rejected
+		{
+			name:    "Test 8",
+			line:    "disk.used nan 1700000000",
+			wantErr: true,
+		},
+		{
+			name:    "Test 9",
+			line:    "disk.used +Inf",
+			wantErr: true,
+		},
+		{
+			name:    "Test 10",
+			line:    "disk.used -1 1700000000",
+			wantErr: true,
+		},
+		{
+			name:    "Test 11",
+			line:    "disk.used 1 NaN",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, labels, value, ts, err := parseLine(tt.line, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if name != tt.wantName || !reflect.DeepEqual(labels, tt.wantLabels) || value != tt.wantValue || !ts.Equal(tt.wantTS) {
+				t.Errorf("parseLine() = %v, %v, %v, %v", name, labels, value, ts)
+			}
+		})
+	}
+}
+
+func TestServerServe(t *testing.T) {
+	updaterMock := mocks.NewUpdater(t)
+	updaterMock.On("SetGaugeAt", mock.Anything, "servers.a.load", models.Labels(nil), 0.5, time.Unix(1700000000, 0)).Return(nil).Once()
+	updaterMock.On("SetGaugeAt", mock.Anything, "disk.used", models.Labels{"host": "a"}, float64(42), time.Unix(1700000010, 0)).Return(nil).Once()
+
+	s := NewServer("localhost:0", updaterMock, zaptest.NewLogger(t))
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.Serve(context.Background(), server)
+		close(done)
+	}()
+	if _, err := client.Write([]byte("servers.a.load 0.5 1700000000\nbad\ndisk.used;host=a 42 1700000010\n")); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	<-done
+}
+
+func TestServerRunStop(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notCancelled := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil })
+	updaterMock := mocks.NewUpdater(t)
+	// the stop comes while the first line is stored, the second line is already received and stored as well
+	updaterMock.On("SetGaugeAt", notCancelled, "first", models.Labels(nil), float64(1), time.Unix(1700000000, 0)).
+		Run(func(mock.Arguments) { cancel() }).Return(nil).Once()
+	updaterMock.On("SetGaugeAt", notCancelled, "second", models.Labels(nil), float64(2), time.Unix(1700000000, 0)).Return(nil).Once()
+
+	s := NewServer(address, updaterMock, zaptest.NewLogger(t))
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	var client net.Conn
+	for i := 0; i < 100; i++ {
+		if client, err = net.Dial("tcp", address); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("first 1 1700000000\nsecond 2 1700000000\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the stop")
+	}
+}
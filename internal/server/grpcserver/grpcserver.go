@@ -2,15 +2,23 @@ package grpcserver
 
 import (
 	"context"
+	"errors"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	pb "github.com/h2p2f/practicum-metrics/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Updater interface {
-	SetGauge(name string, value float64)
-	SetCounter(name string, value int64)
-	GetCounter(name string) (value int64, err error)
-	GetGauge(name string) (value float64, err error)
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
+	GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error)
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error
+	GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error)
+	SetMetrics(ctx context.Context, metrics []models.Metric) error
 }
 
 type Server struct {
@@ -35,19 +43,25 @@ func (s *Server) UpdateMetric(
 		zap.String("metric", req.Metric.Name),
 		zap.String("type", req.Metric.Type),
 		zap.Float64("gauge", req.Metric.Gauge),
-		zap.Int64("counter", req.Metric.Counter))
+		zap.Int64("counter", req.Metric.Counter),
+		zap.Any("labels", req.Metric.Labels))
+	labels := models.Labels(req.Metric.Labels)
+	if labels.Validate() != nil {
+		s.logger.Info("response from server:", zap.Bool("success", false))
+		return &response, nil
+	}
 	switch req.Metric.Type {
 	case "gauge":
 		if req.Metric.Gauge < 0 {
 			response.Success = false
 		} else {
-			s.db.SetGauge(req.Metric.Name, req.Metric.Gauge)
-
+			if err = s.db.SetGauge(ctx, req.Metric.Name, labels, req.Metric.Gauge); err != nil {
+				return nil, s.unavailable(err)
+			}
 			response.Metric = req.Metric
-			response.Metric.Gauge, err = s.db.GetGauge(req.Metric.Name)
+			response.Metric.Gauge, err = s.db.GetGauge(ctx, req.Metric.Name, labels)
 			if err != nil {
-				response.Success = false
-				response.Metric = nil
+				return nil, s.unavailable(err)
 			}
 			response.Success = true
 		}
@@ -55,21 +69,43 @@ func (s *Server) UpdateMetric(
 		if req.Metric.Counter < 0 {
 			response.Success = false
 		} else {
-			s.db.SetCounter(req.Metric.Name, req.Metric.Counter)
+			if err = s.db.SetCounter(ctx, req.Metric.Name, labels, req.Metric.Counter); err != nil {
+				return nil, s.unavailable(err)
+			}
 			response.Metric = req.Metric
-			response.Metric.Counter, err = s.db.GetCounter(req.Metric.Name)
+			response.Metric.Counter, err = s.db.GetCounter(ctx, req.Metric.Name, labels)
 			if err != nil {
-				response.Success = false
-				response.Metric = nil
+				return nil, s.unavailable(err)
 			}
 			response.Success = true
 		}
+	case "histogram":
+		if req.Metric.Histogram == nil {
+			response.Success = false
+			break
+		}
+		err = s.db.SetHistogram(ctx, req.Metric.Name, labels, fromPB(req.Metric.Histogram))
+		if errors.Is(err, models.ErrInvalidHistogram) || errors.Is(err, models.ErrBucketsMismatch) {
+			response.Success = false
+			break
+		}
+		if err != nil {
+			return nil, s.unavailable(err)
+		}
+		var h models.Histogram
+		h, err = s.db.GetHistogram(ctx, req.Metric.Name, labels)
+		if err != nil {
+			return nil, s.unavailable(err)
+		}
+		response.Metric = req.Metric
+		response.Metric.Histogram = toPB(h)
+		response.Success = true
 	default:
 		response.Metric = nil
 		response.Success = false
 	}
 	s.logger.Info("response from server:", zap.Bool("success", response.Success))
-	return &response, err
+	return &response, nil
 }
 
 func (s *Server) UpdateMetrics(
@@ -81,26 +117,76 @@ func (s *Server) UpdateMetrics(
 	s.logger.Info(
 		"request from client:",
 		zap.Int("number of metrics", len(req.Metrics)))
+	// the batch is stored all or nothing, an invalid metric rejects the whole batch
+	metrics := make([]models.Metric, 0, len(req.Metrics))
 	for _, metric := range req.Metrics {
-		switch metric.Type {
-		case "gauge":
-			if metric.Gauge < 0 {
-				response.Success = false
-			} else {
-				s.db.SetGauge(metric.Name, metric.Gauge)
-				response.Success = true
-			}
-		case "counter":
-			if metric.Counter < 0 {
-				response.Success = false
-			} else {
-				s.db.SetCounter(metric.Name, metric.Counter)
-				response.Success = true
-			}
-		default:
-			response.Success = false
+		m := metricFromPB(metric)
+		if err := m.Validate(); err != nil {
+			s.logger.Info("invalid metric in batch", zap.String("metric", metric.Name), zap.Error(err))
+			s.logger.Info("response to agent:", zap.Bool("success", false))
+			return &response, nil
 		}
+		metrics = append(metrics, m)
+	}
+	err := s.db.SetMetrics(ctx, metrics)
+	if errors.Is(err, models.ErrBucketsMismatch) {
+		s.logger.Info("could not merge histogram in batch", zap.Error(err))
+		s.logger.Info("response to agent:", zap.Bool("success", false))
+		return &response, nil
 	}
+	if err != nil {
+		return nil, s.unavailable(err)
+	}
+	response.Success = true
 	s.logger.Info("response to agent:", zap.Bool("success", response.Success))
 	return &response, nil
 }
+
+// unavailable logs the storage failure and converts it to the gRPC status of an unavailable service.
+func (s *Server) unavailable(err error) error {
+	s.logger.Error("could not access the storage", zap.Error(err))
+	return status.Error(codes.Unavailable, "storage is unavailable")
+}
+
+// metricFromPB converts the protobuf metric to the data model, only the value of its type is set.
+func metricFromPB(m *pb.Metric) models.Metric {
+	metric := models.Metric{
+		ID:     m.Name,
+		MType:  m.Type,
+		Labels: models.Labels(m.Labels),
+	}
+	switch m.Type {
+	case "gauge":
+		value := m.Gauge
+		metric.Value = &value
+	case "counter":
+		delta := m.Counter
+		metric.Delta = &delta
+	case "histogram":
+		if m.Histogram != nil {
+			h := fromPB(m.Histogram)
+			metric.Histogram = &h
+		}
+	}
+	return metric
+}
+
+// fromPB converts the protobuf histogram to the data model.
+func fromPB(h *pb.Histogram) models.Histogram {
+	return models.Histogram{
+		Bounds: h.Bounds,
+		Counts: h.Counts,
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
+
+// toPB converts the histogram data model to protobuf.
+func toPB(h models.Histogram) *pb.Histogram {
+	return &pb.Histogram{
+		Bounds: h.Bounds,
+		Counts: h.Counts,
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
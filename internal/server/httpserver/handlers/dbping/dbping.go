@@ -2,6 +2,7 @@
 package dbping
 
 import (
+	"context"
 	"net/http"
 
 	"go.uber.org/zap"
@@ -11,7 +12,7 @@ import (
 //
 //go:generate mockery --name Pinger --output ./mocks --filename mocks_ping.go
 type Pinger interface {
-	Ping() error
+	Ping(ctx context.Context) error
 }
 
 // Handler returns a http.HandlerFunc that handles GET requests and pings the database.
@@ -25,7 +26,7 @@ func Handler(logger *zap.Logger, db Pinger) http.HandlerFunc {
 			return
 		}
 		// Ping the database.
-		err := db.Ping()
+		err := db.Ping(r.Context())
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
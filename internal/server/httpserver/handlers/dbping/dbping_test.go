@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/dbping/mocks"
@@ -30,7 +31,7 @@ func Example() {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	db.On("Ping").Return(nil)
+	db.On("Ping", mock.Anything).Return(nil)
 
 	//создаем объект запроса
 	//
@@ -75,7 +76,7 @@ func BenchmarkHandler(b *testing.B) {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	db.On("Ping").Return(nil)
+	db.On("Ping", mock.Anything).Return(nil)
 
 	//создаем объект запроса
 	//
@@ -119,7 +120,7 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//прописываем ожидаемый результат
 		//
 		//specify the expected result
-		db.On("Ping").Return(nil)
+		db.On("Ping", mock.Anything).Return(nil)
 
 		//создаем объект запроса
 		//
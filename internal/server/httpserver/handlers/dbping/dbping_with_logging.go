@@ -1,12 +1,14 @@
 // Code generated by gowrap. DO NOT EDIT.
-// template: ../../../../../../gowrap/templates/zap
+// template: ../../../../../templates/gowrap/zap
 // gowrap: http://github.com/hexdigest/gowrap
 
 package dbping
 
-//go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/dbping -i Pinger -t ../../../../../templates/gowrap/zap-o dbping_with_logging.go -l ""
+//go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/dbping -i Pinger -t ../../../../../templates/gowrap/zap -o dbping_with_logging.go -l ""
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 )
 
@@ -25,8 +27,9 @@ func NewPingerWithZap(base Pinger, log *zap.Logger) PingerWithZap {
 }
 
 // Ping implements Pinger
-func (_d PingerWithZap) Ping() (err error) {
-	_d._log.Debug("PingerWithZap: calling Ping")
+func (_d PingerWithZap) Ping(ctx context.Context) (err error) {
+	_d._log.Debug("PingerWithZap: calling Ping", zap.Reflect("params", map[string]interface{}{
+		"ctx": ctx}))
 	defer func() {
 		if err != nil {
 			_d._log.Error("PingerWithZap: method Ping returned an error", zap.Error(err))
@@ -35,5 +38,5 @@ func (_d PingerWithZap) Ping() (err error) {
 				"err": err}))
 		}
 	}()
-	return _d._base.Ping()
+	return _d._base.Ping(ctx)
 }
@@ -0,0 +1,47 @@
+// Package getalerts contains an http.Handler that returns the states of the alerting rules and their alerts.
+package getalerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/alerting"
+)
+
+// Alerter is an interface that gets the states of the alerting rules.
+//
+//go:generate mockery --name Alerter --output ./mocks --filename mocks_getalerts.go
+type Alerter interface {
+	Alerts(ctx context.Context) []alerting.RuleStatus
+}
+
+// Handler returns a http.HandlerFunc that handles GET requests and returns the alerting rules in JSON
+// with their states (inactive, pending, firing or resolved) and the alerts of their series.
+// Otherwise, it returns a method not allowed error.
+func Handler(logger *zap.Logger, alerts Alerter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if the request method is not GET
+		if r.Method != http.MethodGet {
+			logger.Sugar().Infow("method not allowed")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		wrappedIFace := NewAlerterWithZap(alerts, logger)
+		resp, err := json.Marshal(wrappedIFace.Alerts(r.Context()))
+		if err != nil {
+			logger.Error("could not marshal json", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		// Set response headers and write the response
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			logger.Error("could not write response", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package getalerts
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/alerting"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getalerts/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestHandler(t *testing.T) {
+	active := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		method   string
+		statuses []alerting.RuleStatus
+		want     int
+		wantBody string
+	}{
+		{
+			name:   "Test 1",
+			method: http.MethodGet,
+			statuses: []alerting.RuleStatus{{
+				Name:     "LowMemory",
+				Expr:     "FreeMemory < 1e+08",
+				For:      "5m0s",
+				Severity: "critical",
+				State:    models.AlertPending,
+				Alerts: []models.Alert{{
+					Rule:     "LowMemory",
+					Labels:   models.Labels{"host": "a"},
+					Severity: "critical",
+					State:    models.AlertPending,
+					Value:    1000,
+					ActiveAt: active,
+				}},
+			}},
+			want: http.StatusOK,
+			wantBody: `[{"name":"LowMemory","expr":"FreeMemory \u003c 1e+08","for":"5m0s","severity":"critical","state":"pending",` +
+				`"alerts":[{"rule":"LowMemory","labels":{"host":"a"},"severity":"critical","state":"pending","value":1000,` +
+				`"active_at":"2024-01-10T12:00:00Z"}]}]`,
+		},
+		{
+			name:     "Test 2",
+			method:   http.MethodGet,
+			statuses: []alerting.RuleStatus{},
+			want:     http.StatusOK,
+			wantBody: `[]`,
+		},
+		{
+			name:   "Test 3",
+			method: http.MethodPost,
+			want:   http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+			alerterMock := mocks.NewAlerter(t)
+			if tt.method == http.MethodGet {
+				alerterMock.On("Alerts", mock.Anything).Return(tt.statuses)
+			}
+			logger := zaptest.NewLogger(t)
+			handler := Handler(logger, alerterMock)
+
+			request := httptest.NewRequest(tt.method, "/alerts", nil)
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.want {
+				t.Errorf("Handler() = %v, want %v", response.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && response.Body.String() != tt.wantBody {
+				t.Errorf("Handler() body = %q, want %q", response.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func Example() {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект правил оповещений
+	//
+	//create a mock alerting rules object
+	alerterMock := mocks.NewAlerter(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	alerterMock.On("Alerts", mock.Anything).Return([]alerting.RuleStatus{
+		{Name: "LowMemory", Expr: "FreeMemory < 1e+08", For: "0s", State: models.AlertInactive, Alerts: []models.Alert{}},
+	})
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/alerts", nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	Handler(logger, alerterMock).ServeHTTP(rr, req)
+
+	//выводим результат
+	//
+	//display the result
+	fmt.Print(rr.Body.String())
+
+	// Output:
+	// [{"name":"LowMemory","expr":"FreeMemory \u003c 1e+08","for":"0s","state":"inactive","alerts":[]}]
+}
+
+func BenchmarkHandler(b *testing.B) {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект правил оповещений
+	//
+	//create a mock alerting rules object
+	alerterMock := mocks.NewAlerter(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	alerterMock.On("Alerts", mock.Anything).Return([]alerting.RuleStatus{
+		{Name: "LowMemory", Expr: "FreeMemory < 1e+08", For: "0s", State: models.AlertInactive, Alerts: []models.Alert{}},
+	})
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/alerts", nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	for i := 0; i < b.N; i++ {
+		Handler(logger, alerterMock).ServeHTTP(rr, req)
+	}
+}
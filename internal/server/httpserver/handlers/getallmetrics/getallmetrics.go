@@ -2,22 +2,26 @@
 package getallmetrics
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 )
 
 // Getter is an interface that gets all the metrics.
 //
 //go:generate mockery --name Getter --output ./mocks --filename mocks_getmetrics.go
 type Getter interface {
-	GetCounters() map[string]int64
-	GetGauges() map[string]float64
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
 }
 
 // Handler returns a http.HandlerFunc that handles GET requests and returns all the metrics.
-// It writes the counters and gauges to the response body.
+// It writes the counters and gauges to the response body,
+// the query parameters are used as a filter by the labels of the series.
 // Otherwise, it returns a method not allowed error.
 func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -29,19 +33,28 @@ func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
 		}
 
 		wrappedIFace := NewGetterWithZap(db, logger)
+		filter := models.LabelsFromQuery(r.URL.Query())
 		// Get the counters from the database
-		counters := wrappedIFace.GetCounters()
-		//counters := db.GetCounters()
+		counters, err := wrappedIFace.GetCounters(r.Context(), filter)
+		if err != nil {
+			logger.Error("could not get counters", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
 		// Get the gauges from the database
-		gauges := wrappedIFace.GetGauges()
-		//gauges := db.GetGauges()
+		gauges, err := wrappedIFace.GetGauges(r.Context(), filter)
+		if err != nil {
+			logger.Error("could not get gauges", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
 		// Set the Content-Type header to text/html
 		w.Header().Add("Content-Type", "text/html")
 
 		// Write the "counters:" text to the response writer
-		_, err := w.Write([]byte("counters:<br>"))
+		_, err = w.Write([]byte("counters:<br>"))
 		if err != nil {
 			logger.Error("could not write response", zap.Error(err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -1,14 +1,17 @@
 package getallmetrics
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getallmetrics/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 )
 
 func TestGetAllMetrics(t *testing.T) {
@@ -16,6 +19,7 @@ func TestGetAllMetrics(t *testing.T) {
 	tests := []struct {
 		name   string
 		method string
+		err    error
 		want   int
 	}{
 		{
@@ -28,14 +32,20 @@ func TestGetAllMetrics(t *testing.T) {
 			method: http.MethodPost,
 			want:   http.StatusMethodNotAllowed,
 		},
+		{
+			name:   "Test 3",
+			method: http.MethodGet,
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
 	}
 	for _, tt := range tests {
 
 		t.Run(tt.name, func(t *testing.T) {
 			getterMock := mocks.NewGetter(t)
 			if tt.method == http.MethodGet {
-				getterMock.On("GetCounters").Return(map[string]int64{"testKey": 1})
-				getterMock.On("GetGauges").Return(map[string]float64{"test1": 10})
+				getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"testKey": 1}, tt.err)
+				getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"test1": 10}, nil).Maybe()
 			}
 			logger := zaptest.NewLogger(t)
 			handler := Handler(logger, getterMock)
@@ -72,8 +82,8 @@ func Example() {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	getterMock.On("GetCounters").Return(map[string]int64{"testKey": 1})
-	getterMock.On("GetGauges").Return(map[string]float64{"test1": 10})
+	getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"testKey": 1}, nil)
+	getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"test1": 10}, nil)
 
 	//создаем объект запроса
 	//
@@ -118,8 +128,8 @@ func BenchmarkHandler(b *testing.B) {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	getterMock.On("GetCounters").Return(map[string]int64{"testKey": 1})
-	getterMock.On("GetGauges").Return(map[string]float64{"test1": 10})
+	getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"testKey": 1}, nil)
+	getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"test1": 10}, nil)
 
 	//создаем объект запроса
 	//
@@ -162,8 +172,8 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//прописываем ожидаемый результат
 		//
 		//specify the expected result
-		getterMock.On("GetCounters").Return(map[string]int64{"testKey": 1})
-		getterMock.On("GetGauges").Return(map[string]float64{"test1": 10})
+		getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"testKey": 1}, nil)
+		getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"test1": 10}, nil)
 
 		//создаем объект запроса
 		//
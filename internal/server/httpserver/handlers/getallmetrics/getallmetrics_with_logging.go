@@ -1,5 +1,5 @@
 // Code generated by gowrap. DO NOT EDIT.
-// template: ../../../../../../gowrap/templates/zap
+// template: ../../../../../templates/gowrap/zap
 // gowrap: http://github.com/hexdigest/gowrap
 
 package getallmetrics
@@ -7,6 +7,9 @@ package getallmetrics
 //go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getallmetrics -i Getter -t ../../../../../templates/gowrap/zap -o getallmetrics_with_logging.go -l ""
 
 import (
+	"context"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	"go.uber.org/zap"
 )
 
@@ -25,21 +28,35 @@ func NewGetterWithZap(base Getter, log *zap.Logger) GetterWithZap {
 }
 
 // GetCounters implements Getter
-func (_d GetterWithZap) GetCounters() (m1 map[string]int64) {
-	_d._log.Debug("GetterWithZap: calling GetCounters")
+func (_d GetterWithZap) GetCounters(ctx context.Context, filter models.Labels) (m1 map[string]int64, err error) {
+	_d._log.Debug("GetterWithZap: calling GetCounters", zap.Reflect("params", map[string]interface{}{
+		"ctx":    ctx,
+		"filter": filter}))
 	defer func() {
-		_d._log.Debug("GetterWithZap: method GetCounters finished", zap.Reflect("results", map[string]interface{}{
-			"m1": m1}))
+		if err != nil {
+			_d._log.Error("GetterWithZap: method GetCounters returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("GetterWithZap: method GetCounters finished", zap.Reflect("results", map[string]interface{}{
+				"m1":  m1,
+				"err": err}))
+		}
 	}()
-	return _d._base.GetCounters()
+	return _d._base.GetCounters(ctx, filter)
 }
 
 // GetGauges implements Getter
-func (_d GetterWithZap) GetGauges() (m1 map[string]float64) {
-	_d._log.Debug("GetterWithZap: calling GetGauges")
+func (_d GetterWithZap) GetGauges(ctx context.Context, filter models.Labels) (m1 map[string]float64, err error) {
+	_d._log.Debug("GetterWithZap: calling GetGauges", zap.Reflect("params", map[string]interface{}{
+		"ctx":    ctx,
+		"filter": filter}))
 	defer func() {
-		_d._log.Debug("GetterWithZap: method GetGauges finished", zap.Reflect("results", map[string]interface{}{
-			"m1": m1}))
+		if err != nil {
+			_d._log.Error("GetterWithZap: method GetGauges returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("GetterWithZap: method GetGauges finished", zap.Reflect("results", map[string]interface{}{
+				"m1":  m1,
+				"err": err}))
+		}
 	}()
-	return _d._base.GetGauges()
+	return _d._base.GetGauges(ctx, filter)
 }
@@ -2,43 +2,68 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	models "github.com/h2p2f/practicum-metrics/internal/server/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Getter is an autogenerated mock type for the Getter type
 type Getter struct {
 	mock.Mock
 }
 
-// GetCounters provides a mock function with given fields:
-func (_m *Getter) GetCounters() map[string]int64 {
-	ret := _m.Called()
+// GetCounters provides a mock function with given fields: ctx, filter
+func (_m *Getter) GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 map[string]int64
-	if rf, ok := ret.Get(0).(func() map[string]int64); ok {
-		r0 = rf()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Labels) (map[string]int64, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Labels) map[string]int64); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string]int64)
 		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, models.Labels) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetGauges provides a mock function with given fields:
-func (_m *Getter) GetGauges() map[string]float64 {
-	ret := _m.Called()
+// GetGauges provides a mock function with given fields: ctx, filter
+func (_m *Getter) GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error) {
+	ret := _m.Called(ctx, filter)
 
 	var r0 map[string]float64
-	if rf, ok := ret.Get(0).(func() map[string]float64); ok {
-		r0 = rf()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Labels) (map[string]float64, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Labels) map[string]float64); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(map[string]float64)
 		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, models.Labels) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewGetter creates a new instance of Getter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -0,0 +1,98 @@
+// Package gethistory contains an http.Handler that returns the history of the metric.
+package gethistory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+// Historian is an interface that gets the history of the metric.
+//
+//go:generate mockery --name Historian --output ./mocks --filename mocks_gethistory.go
+type Historian interface {
+	GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error)
+}
+
+// Handler returns a http.HandlerFunc that handles GET requests and returns the history of the metric in JSON.
+// The time range is set by the optional from and to query parameters
+// in RFC3339 format or as a unix timestamp in seconds, the other query parameters are the labels of the series.
+// It returns a not found error if the metric is unknown, a metric without points in the range gets an empty list.
+func Handler(logger *zap.Logger, db Historian) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if the request method is not GET
+		if r.Method != http.MethodGet {
+			logger.Sugar().Infow("method not allowed")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		wrappedIFace := NewHistorianWithZap(db, logger)
+		// Get the metric type and key from the URL parameters.
+		metric := chi.URLParam(r, "metric")
+		key := chi.URLParam(r, "key")
+		if key == "" || (metric != "gauge" && metric != "counter" && metric != "histogram") {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		// Get the time range from the query parameters.
+		from, err := parseTime(r.URL.Query().Get("from"), time.Time{})
+		if err != nil {
+			logger.Error("could not parse from", zap.Error(err))
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		to, err := parseTime(r.URL.Query().Get("to"), time.Now())
+		if err != nil {
+			logger.Error("could not parse to", zap.Error(err))
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		// Get the history from the database.
+		labels := models.LabelsFromQuery(r.URL.Query(), "from", "to")
+		points, err := wrappedIFace.GetHistory(r.Context(), metric, key, labels, from, to)
+		if errors.Is(err, servererrors.ErrNotFound) {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if points == nil {
+			points = []models.Point{}
+		}
+		resp, err := json.Marshal(points)
+		if err != nil {
+			logger.Error("could not marshal json", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// Set response headers and write the response
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			logger.Error("could not write response", zap.Error(err))
+		}
+	}
+}
+
+// parseTime - function to parse the time from RFC3339 or unix seconds, def is returned for an empty string
+func parseTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
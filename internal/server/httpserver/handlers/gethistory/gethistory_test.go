@@ -0,0 +1,174 @@
+package gethistory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/gethistory/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+func TestHandler(t *testing.T) {
+	var gauge = 0.5
+	points := []models.Point{{Timestamp: time.Unix(100, 0), Value: &gauge}}
+
+	tests := []struct {
+		name   string
+		metric string
+		key    string
+		query  string
+		points []models.Point
+		err    error
+		want   int
+	}{
+		{
+			name:   "Test 1",
+			metric: "gauge",
+			key:    "testKey",
+			query:  "?from=50&to=2006-01-02T15:04:05Z",
+			points: points,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "Test 2",
+			metric: "counter",
+			key:    "testKey",
+			err:    servererrors.ErrNotFound,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "Test 3",
+			metric: "summary",
+			key:    "testKey",
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "Test 4",
+			metric: "gauge",
+			key:    "testKey",
+			query:  "?from=yesterday",
+			want:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			historianMock := mocks.NewHistorian(t)
+			if tt.points != nil || tt.err != nil {
+				historianMock.On("GetHistory", mock.Anything, tt.metric, tt.key, models.Labels(nil), mock.Anything, mock.Anything).Return(tt.points, tt.err)
+			}
+			logger := zaptest.NewLogger(t)
+			handler := Handler(logger, historianMock)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("metric", tt.metric)
+			rctx.URLParams.Add("key", tt.key)
+
+			link := fmt.Sprintf("/history/%s/%s%s", tt.metric, tt.key, tt.query)
+			request := httptest.NewRequest(http.MethodGet, link, nil)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.want {
+				t.Errorf("Handler() = %v, want %v", response.Code, tt.want)
+			}
+		})
+	}
+}
+
+func Example() {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+	//создаем моки
+	//
+	//create mocks
+	historianMock := mocks.NewHistorian(t)
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	var gauge float64 = 10
+	points := []models.Point{{Timestamp: time.Unix(0, 0).UTC(), Value: &gauge}}
+	historianMock.On("GetHistory", mock.Anything, "gauge", "testKey", models.Labels(nil), time.Unix(0, 0), time.Unix(60, 0)).Return(points, nil)
+	//создаем логгер
+	//
+	//create logger
+	logger := zaptest.NewLogger(t)
+	//создаем тестовый запрос
+	//
+	//create test request
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("metric", "gauge")
+	rctx.URLParams.Add("key", "testKey")
+	request := httptest.NewRequest(http.MethodGet, "/history/gauge/testKey?from=0&to=60", nil)
+	request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+	//создаем тестовый ответ
+	//
+	//create test response
+	response := httptest.NewRecorder()
+	//обрабатываем запрос
+	//
+	//handle request
+	Handler(logger, historianMock).ServeHTTP(response, request)
+	//получаем результат
+	//
+	//get result
+	fmt.Println(response.Code)
+	fmt.Println(response.Body.String())
+	//Output: 200
+	//[{"timestamp":"1970-01-01T00:00:00Z","value":10}]
+}
+
+func BenchmarkHandler(b *testing.B) {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+	//создаем моки
+	//
+	//create mocks
+	historianMock := mocks.NewHistorian(t)
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	var gauge float64 = 10
+	points := []models.Point{{Timestamp: time.Unix(0, 0), Value: &gauge}}
+	historianMock.On("GetHistory", mock.Anything, "gauge", "testKey", models.Labels(nil), mock.Anything, mock.Anything).Return(points, nil)
+	//создаем логгер
+	//
+	//create logger
+	logger := zaptest.NewLogger(t)
+	//создаем тестовый запрос
+	//
+	//create test request
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("metric", "gauge")
+	rctx.URLParams.Add("key", "testKey")
+	request := httptest.NewRequest(http.MethodGet, "/history/gauge/testKey", nil)
+	request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+	//создаем тестовый ответ
+	//
+	//create test response
+	response := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	//обрабатываем запрос
+	//
+	//handle request
+	for i := 0; i < b.N; i++ {
+		Handler(logger, historianMock).ServeHTTP(response, request)
+	}
+}
@@ -2,24 +2,32 @@
 package getmetric
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
 )
 
 // Getter is an interface that gets the metric.
 //
 //go:generate mockery --name Getter --output ./mocks --filename mocks_getmetric.go
 type Getter interface {
-	GetCounter(name string) (value int64, err error)
-	GetGauge(name string) (value float64, err error)
+	GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error)
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error)
 }
 
 // Handler returns a http.HandlerFunc that handles GET requests and gets the metric.
-// It writes the metric value to the response body if the metric is found.
-// Otherwise, it returns a not found error.
+// The labels of the series are taken from the query parameters.
+// It writes the metric value to the response body if the metric is found, a histogram is written in JSON.
+// It returns a not found error if the metric is not found and an internal server error if the storage fails.
 func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check if the request method is not GET
@@ -39,11 +47,15 @@ func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
 			return
 		}
 		// Get the metric value from the database.
-		value, err := getterMetric(&wrappedIFace, logger, metric, key)
-		if err != nil {
+		value, err := getterMetric(r.Context(), &wrappedIFace, logger, metric, key, models.LabelsFromQuery(r.URL.Query()))
+		if errors.Is(err, servererrors.ErrNotFound) {
 			http.Error(w, "Not found", http.StatusNotFound)
 			return
 		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 		_, err = w.Write([]byte(value))
 		if err != nil {
 			logger.Error("could not write response", zap.Error(err))
@@ -58,7 +70,7 @@ func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
 }
 
 // getterMetric - function to get the metric
-func getterMetric(getter *GetterWithZap, logger *zap.Logger, metric, key string) (string, error) {
+func getterMetric(ctx context.Context, getter *GetterWithZap, logger *zap.Logger, metric, key string, labels models.Labels) (string, error) {
 	var (
 		i   int64
 		f   float64
@@ -67,7 +79,7 @@ func getterMetric(getter *GetterWithZap, logger *zap.Logger, metric, key string)
 	switch metric {
 	case "gauge":
 		// Get the gauge value from the database.
-		f, err = getter.GetGauge(key)
+		f, err = getter.GetGauge(ctx, key, labels)
 		if err != nil {
 			logger.Error("could not get gauge", zap.Error(err))
 			return "", err
@@ -75,12 +87,24 @@ func getterMetric(getter *GetterWithZap, logger *zap.Logger, metric, key string)
 		return strconv.FormatFloat(f, 'f', -1, 64), nil
 	case "counter":
 		// Get the counter value from the database.
-		i, err = getter.GetCounter(key)
+		i, err = getter.GetCounter(ctx, key, labels)
 		if err != nil {
 			logger.Error("could not get counter", zap.Error(err))
 			return "", err
 		}
 		return strconv.FormatInt(i, 10), nil
+	case "histogram":
+		// Get the histogram from the database.
+		h, err := getter.GetHistogram(ctx, key, labels)
+		if err != nil {
+			logger.Error("could not get histogram", zap.Error(err))
+			return "", err
+		}
+		out, err := json.Marshal(h)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
 	default:
 		return "", err
 	}
@@ -2,15 +2,19 @@ package getmetric
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getmetric/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
 )
 
 func TestGetMetric(t *testing.T) {
@@ -18,6 +22,7 @@ func TestGetMetric(t *testing.T) {
 		name   string
 		metric string
 		key    string
+		err    error
 		want   int
 	}{
 		{
@@ -44,16 +49,30 @@ func TestGetMetric(t *testing.T) {
 			key:    "",
 			want:   http.StatusBadRequest,
 		},
+		{
+			name:   "Test 5",
+			metric: "gauge",
+			key:    "testKey",
+			err:    servererrors.ErrNotFound,
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "Test 6",
+			metric: "counter",
+			key:    "testKey",
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			getterMock := mocks.NewGetter(t)
-			if tt.want == http.StatusOK && tt.metric == "gauge" {
-				getterMock.On("GetGauge", tt.key).Return(float64(10), nil)
+			if tt.want != http.StatusBadRequest && tt.metric == "gauge" {
+				getterMock.On("GetGauge", mock.Anything, tt.key, models.Labels(nil)).Return(float64(10), tt.err)
 			}
-			if tt.want == http.StatusOK && tt.metric == "counter" {
-				getterMock.On("GetCounter", tt.key).Return(int64(1), nil)
+			if tt.want != http.StatusBadRequest && tt.metric == "counter" {
+				getterMock.On("GetCounter", mock.Anything, tt.key, models.Labels(nil)).Return(int64(1), tt.err)
 			}
 			logger := zaptest.NewLogger(t)
 
@@ -91,7 +110,7 @@ func Example() {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	getterMock.On("GetGauge", "testKey").Return(float64(10), nil)
+	getterMock.On("GetGauge", mock.Anything, "testKey", models.Labels(nil)).Return(float64(10), nil)
 	//создаем логгер
 	//
 	//create logger
@@ -133,7 +152,7 @@ func BenchmarkHandler(b *testing.B) {
 	//прописываем ожидаемый результат
 	//
 	//specify the expected result
-	getterMock.On("GetGauge", "testKey").Return(float64(10), nil)
+	getterMock.On("GetGauge", mock.Anything, "testKey", models.Labels(nil)).Return(float64(10), nil)
 	//создаем логгер
 	//
 	//create logger
@@ -175,7 +194,7 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//прописываем ожидаемый результат
 		//
 		//specify the expected result
-		getterMock.On("GetGauge", "testKey").Return(float64(10), nil)
+		getterMock.On("GetGauge", mock.Anything, "testKey", models.Labels(nil)).Return(float64(10), nil)
 		//создаем логгер
 		//
 		//create logger
@@ -7,6 +7,9 @@ package getmetric
 //go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getmetric -i Getter -t ../../../../../templates/gowrap/zap -o getmetric_with_logging.go -l ""
 
 import (
+	"context"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	"go.uber.org/zap"
 )
 
@@ -25,9 +28,11 @@ func NewGetterWithZap(base Getter, log *zap.Logger) GetterWithZap {
 }
 
 // GetCounter implements Getter
-func (_d GetterWithZap) GetCounter(name string) (value int64, err error) {
+func (_d GetterWithZap) GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error) {
 	_d._log.Debug("GetterWithZap: calling GetCounter", zap.Reflect("params", map[string]interface{}{
-		"name": name}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
 	defer func() {
 		if err != nil {
 			_d._log.Error("GetterWithZap: method GetCounter returned an error", zap.Error(err))
@@ -37,13 +42,15 @@ func (_d GetterWithZap) GetCounter(name string) (value int64, err error) {
 				"err":   err}))
 		}
 	}()
-	return _d._base.GetCounter(name)
+	return _d._base.GetCounter(ctx, name, labels)
 }
 
 // GetGauge implements Getter
-func (_d GetterWithZap) GetGauge(name string) (value float64, err error) {
+func (_d GetterWithZap) GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error) {
 	_d._log.Debug("GetterWithZap: calling GetGauge", zap.Reflect("params", map[string]interface{}{
-		"name": name}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
 	defer func() {
 		if err != nil {
 			_d._log.Error("GetterWithZap: method GetGauge returned an error", zap.Error(err))
@@ -53,5 +60,23 @@ func (_d GetterWithZap) GetGauge(name string) (value float64, err error) {
 				"err":   err}))
 		}
 	}()
-	return _d._base.GetGauge(name)
+	return _d._base.GetGauge(ctx, name, labels)
+}
+
+// GetHistogram implements Getter
+func (_d GetterWithZap) GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error) {
+	_d._log.Debug("GetterWithZap: calling GetHistogram", zap.Reflect("params", map[string]interface{}{
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
+	defer func() {
+		if err != nil {
+			_d._log.Error("GetterWithZap: method GetHistogram returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("GetterWithZap: method GetHistogram finished", zap.Reflect("results", map[string]interface{}{
+				"value": value,
+				"err":   err}))
+		}
+	}()
+	return _d._base.GetHistogram(ctx, name, labels)
 }
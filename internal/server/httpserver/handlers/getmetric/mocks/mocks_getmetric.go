@@ -2,30 +2,35 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	models "github.com/h2p2f/practicum-metrics/internal/server/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Getter is an autogenerated mock type for the Getter type
 type Getter struct {
 	mock.Mock
 }
 
-// GetCounter provides a mock function with given fields: name
-func (_m *Getter) GetCounter(name string) (int64, error) {
-	ret := _m.Called(name)
+// GetCounter provides a mock function with given fields: ctx, name, labels
+func (_m *Getter) GetCounter(ctx context.Context, name string, labels models.Labels) (int64, error) {
+	ret := _m.Called(ctx, name, labels)
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (int64, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (int64, error)); ok {
+		return rf(ctx, name, labels)
 	}
-	if rf, ok := ret.Get(0).(func(string) int64); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) int64); ok {
+		r0 = rf(ctx, name, labels)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -33,23 +38,47 @@ func (_m *Getter) GetCounter(name string) (int64, error) {
 	return r0, r1
 }
 
-// GetGauge provides a mock function with given fields: name
-func (_m *Getter) GetGauge(name string) (float64, error) {
-	ret := _m.Called(name)
+// GetGauge provides a mock function with given fields: ctx, name, labels
+func (_m *Getter) GetGauge(ctx context.Context, name string, labels models.Labels) (float64, error) {
+	ret := _m.Called(ctx, name, labels)
 
 	var r0 float64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (float64, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (float64, error)); ok {
+		return rf(ctx, name, labels)
 	}
-	if rf, ok := ret.Get(0).(func(string) float64); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) float64); ok {
+		r0 = rf(ctx, name, labels)
 	} else {
 		r0 = ret.Get(0).(float64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHistogram provides a mock function with given fields: ctx, name, labels
+func (_m *Getter) GetHistogram(ctx context.Context, name string, labels models.Labels) (models.Histogram, error) {
+	ret := _m.Called(ctx, name, labels)
+
+	var r0 models.Histogram
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (models.Histogram, error)); ok {
+		return rf(ctx, name, labels)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) models.Histogram); ok {
+		r0 = rf(ctx, name, labels)
+	} else {
+		r0 = ret.Get(0).(models.Histogram)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,182 @@
+// Package getprometheus contains an http.Handler that returns all the metrics in the Prometheus text format.
+package getprometheus
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// Getter is an interface that gets all the metrics.
+//
+//go:generate mockery --name Getter --output ./mocks --filename mocks_getprometheus.go
+type Getter interface {
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
+}
+
+// contentType - the content type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// sample - one line of the exposition
+type sample struct {
+	name   string
+	labels models.Labels
+	value  string
+}
+
+// Handler returns a http.HandlerFunc that handles GET requests and returns the counters and gauges
+// in the Prometheus text exposition format, so the server can be scraped directly.
+// The metric names are sanitized to match the Prometheus naming rules,
+// the query parameters are used as a filter by the labels of the series.
+// Otherwise, it returns a method not allowed error.
+func Handler(logger *zap.Logger, db Getter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if the request method is not GET
+		if r.Method != http.MethodGet {
+			logger.Sugar().Infow("method not allowed")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		wrappedIFace := NewGetterWithZap(db, logger)
+		filter := models.LabelsFromQuery(r.URL.Query())
+
+		storedCounters, err := wrappedIFace.GetCounters(r.Context(), filter)
+		if err != nil {
+			logger.Error("could not get counters", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		storedGauges, err := wrappedIFace.GetGauges(r.Context(), filter)
+		if err != nil {
+			logger.Error("could not get gauges", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// Group the series by the sanitized name, every name gets one TYPE line
+		counters := make(map[string][]sample)
+		for key, value := range storedCounters {
+			s, err := newSample(key, strconv.FormatInt(value, 10))
+			if err != nil {
+				logger.Error("could not parse series key", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			counters[s.name] = append(counters[s.name], s)
+		}
+		gauges := make(map[string][]sample)
+		for key, value := range storedGauges {
+			s, err := newSample(key, strconv.FormatFloat(value, 'g', -1, 64))
+			if err != nil {
+				logger.Error("could not parse series key", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			// Prometheus does not allow one name with two types
+			if _, ok := counters[s.name]; ok {
+				logger.Warn("gauge name is already used by a counter", zap.String("key", key))
+				continue
+			}
+			gauges[s.name] = append(gauges[s.name], s)
+		}
+
+		var buf bytes.Buffer
+		writeFamilies(&buf, "counter", counters)
+		writeFamilies(&buf, "gauge", gauges)
+
+		// Set response headers and write the response
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			logger.Error("could not write response", zap.Error(err))
+		}
+	}
+}
+
+// newSample - function to make a sample from the series key and the formatted value
+func newSample(key, value string) (sample, error) {
+	name, labels, err := models.ParseSeriesKey(key)
+	if err != nil {
+		return sample{}, err
+	}
+	return sample{name: sanitizeName(name), labels: labels, value: value}, nil
+}
+
+// writeFamilies - function to write the metric families of one type sorted by name
+func writeFamilies(buf *bytes.Buffer, mType string, families map[string][]sample) {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		samples := families[name]
+		sort.Slice(samples, func(i, j int) bool {
+			return models.SeriesKey(name, samples[i].labels) < models.SeriesKey(name, samples[j].labels)
+		})
+		buf.WriteString("# TYPE " + name + " " + mType + "\n")
+		for _, s := range samples {
+			buf.WriteString(name)
+			writeLabels(buf, s.labels)
+			buf.WriteByte(' ')
+			buf.WriteString(s.value)
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// writeLabels - function to write the labels sorted by name with escaped values
+func writeLabels(buf *bytes.Buffer, labels models.Labels) {
+	if len(labels) == 0 {
+		return
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(name)
+		buf.WriteString(`="`)
+		buf.WriteString(labelValueReplacer.Replace(labels[name]))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+}
+
+// labelValueReplacer escapes the label values as the text format requires
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sanitizeName - function to replace the characters that are not allowed in the Prometheus metric name
+// with underscores, a name that starts with a digit gets an underscore prefix
+func sanitizeName(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		switch {
+		case c == '_', c == ':', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package getprometheus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getprometheus/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestHandler(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		method   string
+		link     string
+		filter   models.Labels
+		counters map[string]int64
+		gauges   map[string]float64
+		err      error
+		want     int
+		wantBody string
+	}{
+		{
+			name:     "Test 1",
+			method:   http.MethodGet,
+			link:     "/metrics",
+			counters: map[string]int64{"PollCount": 5},
+			gauges:   map[string]float64{"Alloc": 1.5},
+			want:     http.StatusOK,
+			wantBody: "# TYPE PollCount counter\nPollCount 5\n# TYPE Alloc gauge\nAlloc 1.5\n",
+		},
+		{
+			name:   "Test 2",
+			method: http.MethodGet,
+			link:   "/metrics?host=a",
+			filter: models.Labels{"host": "a"},
+			gauges: map[string]float64{
+				`Heap.Alloc{host="a",service="b"}`: 2,
+				`Heap-Alloc{host="a"}`:             1,
+				`1m_load{host="a"}`:                0.5,
+			},
+			want: http.StatusOK,
+			wantBody: "# TYPE Heap_Alloc gauge\nHeap_Alloc{host=\"a\",service=\"b\"} 2\nHeap_Alloc{host=\"a\"} 1\n" +
+				"# TYPE _1m_load gauge\n_1m_load{host=\"a\"} 0.5\n",
+		},
+		{
+			name:     "Test 3",
+			method:   http.MethodGet,
+			link:     "/metrics",
+			counters: map[string]int64{"Requests": 1},
+			gauges:   map[string]float64{"Requests": 3, `Latency{path="/a\"b"}`: 0.25},
+			want:     http.StatusOK,
+			wantBody: "# TYPE Requests counter\nRequests 1\n# TYPE Latency gauge\nLatency{path=\"/a\\\"b\"} 0.25\n",
+		},
+		{
+			name:   "Test 4",
+			method: http.MethodPost,
+			link:   "/metrics",
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "Test 5",
+			method: http.MethodGet,
+			link:   "/metrics",
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+			getterMock := mocks.NewGetter(t)
+			if tt.method == http.MethodGet {
+				getterMock.On("GetCounters", mock.Anything, tt.filter).Return(tt.counters, tt.err)
+				getterMock.On("GetGauges", mock.Anything, tt.filter).Return(tt.gauges, nil).Maybe()
+			}
+			logger := zaptest.NewLogger(t)
+			handler := Handler(logger, getterMock)
+
+			request := httptest.NewRequest(tt.method, tt.link, nil)
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.want {
+				t.Errorf("Handler() = %v, want %v", response.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && response.Body.String() != tt.wantBody {
+				t.Errorf("Handler() body = %q, want %q", response.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "HeapAlloc", want: "HeapAlloc"},
+		{name: "http:requests_total", want: "http:requests_total"},
+		{name: "cpu.usage-percent", want: "cpu_usage_percent"},
+		{name: "5xx", want: "_5xx"},
+		{name: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeName(tt.name); got != tt.want {
+				t.Errorf("sanitizeName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Example() {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	getterMock := mocks.NewGetter(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"PollCount": 1}, nil)
+	getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{`HeapAlloc{service="agent"}`: 10}, nil)
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	Handler(logger, getterMock).ServeHTTP(rr, req)
+
+	//выводим результат
+	//
+	//display the result
+	fmt.Print(rr.Body.String())
+
+	// Output:
+	// # TYPE PollCount counter
+	// PollCount 1
+	// # TYPE HeapAlloc gauge
+	// HeapAlloc{service="agent"} 10
+}
+
+func BenchmarkHandler(b *testing.B) {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	getterMock := mocks.NewGetter(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	getterMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{"PollCount": 1}, nil)
+	getterMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{`HeapAlloc{service="agent"}`: 10}, nil)
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	for i := 0; i < b.N; i++ {
+		Handler(logger, getterMock).ServeHTTP(rr, req)
+	}
+}
@@ -0,0 +1,67 @@
+// Package getquery contains an http.Handler that evaluates the query expression over the metrics.
+package getquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/query"
+)
+
+// Reader is an interface that gets the current values and the history of the metrics.
+//
+//go:generate mockery --name Reader --output ./mocks --filename mocks_getquery.go
+type Reader interface {
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
+	GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error)
+}
+
+// Handler returns a http.HandlerFunc that handles GET requests and returns the value of the expr query parameter
+// in JSON, for example /query?expr=HeapInuse/HeapSys. The ranges of the history end at the time of the request.
+// It returns a bad request error with the reason if the expression is missing, malformed or can not be evaluated.
+func Handler(logger *zap.Logger, db Reader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if the request method is not GET
+		if r.Method != http.MethodGet {
+			logger.Sugar().Infow("method not allowed")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		expr := r.URL.Query().Get("expr")
+		if expr == "" {
+			http.Error(w, "Bad request: expr is required", http.StatusBadRequest)
+			return
+		}
+		wrappedIFace := NewReaderWithZap(db, logger)
+		result, err := query.NewEngine(wrappedIFace).Query(r.Context(), expr, time.Now())
+		if errors.Is(err, query.ErrSyntax) || errors.Is(err, query.ErrInvalidQuery) {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("could not evaluate query", zap.String("expr", expr), zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		resp, err := json.Marshal(result)
+		if err != nil {
+			logger.Error("could not marshal json", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		// Set response headers and write the response
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			logger.Error("could not write response", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package getquery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getquery/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Now()
+	first, last := int64(10), int64(70)
+	history := []models.Point{
+		{Timestamp: now.Add(-time.Minute), Delta: &first},
+		{Timestamp: now, Delta: &last},
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		expr     string
+		counters map[string]int64
+		gauges   map[string]float64
+		history  []models.Point
+		err      error
+		want     int
+		wantBody string
+	}{
+		{
+			name:   "Test 1",
+			method: http.MethodGet,
+			expr:   "HeapInuse / HeapSys",
+			gauges: map[string]float64{
+				`HeapInuse{host="a"}`: 25,
+				`HeapSys{host="a"}`:   100,
+				`HeapSys{host="b"}`:   100,
+			},
+			want:     http.StatusOK,
+			wantBody: `{"type":"vector","vector":[{"labels":{"host":"a"},"value":0.25}]}`,
+		},
+		{
+			name:     "Test 2",
+			method:   http.MethodGet,
+			expr:     "increase(PollCount[5m])",
+			counters: map[string]int64{"PollCount": 70},
+			history:  history,
+			want:     http.StatusOK,
+			wantBody: `{"type":"vector","vector":[{"value":60}]}`,
+		},
+		{
+			name:     "Test 3",
+			method:   http.MethodGet,
+			expr:     "2 * 3",
+			want:     http.StatusOK,
+			wantBody: `{"type":"scalar","scalar":6}`,
+		},
+		{
+			name:   "Test 4",
+			method: http.MethodGet,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "Test 5",
+			method: http.MethodGet,
+			expr:   "rate(PollCount)",
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "Test 6",
+			method: http.MethodGet,
+			expr:   "PollCount",
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
+		{
+			name:   "Test 7",
+			method: http.MethodPost,
+			expr:   "PollCount",
+			want:   http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+			readerMock := mocks.NewReader(t)
+			readerMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(tt.counters, tt.err).Maybe()
+			readerMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(tt.gauges, nil).Maybe()
+			readerMock.On("GetHistory", mock.Anything, "counter", "PollCount", models.Labels(nil), mock.Anything, mock.Anything).
+				Return(tt.history, nil).Maybe()
+			logger := zaptest.NewLogger(t)
+			handler := Handler(logger, readerMock)
+
+			request := httptest.NewRequest(tt.method, "/query?expr="+url.QueryEscape(tt.expr), nil)
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.want {
+				t.Errorf("Handler() = %v, want %v", response.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && response.Body.String() != tt.wantBody {
+				t.Errorf("Handler() body = %q, want %q", response.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func Example() {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	readerMock := mocks.NewReader(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	readerMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{}, nil)
+	readerMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"HeapInuse": 25, "HeapSys": 100}, nil)
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/query?expr="+url.QueryEscape("HeapInuse / HeapSys"), nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	Handler(logger, readerMock).ServeHTTP(rr, req)
+
+	//выводим результат
+	//
+	//display the result
+	fmt.Print(rr.Body.String())
+
+	// Output:
+	// {"type":"vector","vector":[{"value":0.25}]}
+}
+
+func BenchmarkHandler(b *testing.B) {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+
+	//создаем тестовый объект логгера
+	//
+	//create a test logger object
+	logger := zaptest.NewLogger(t)
+
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	readerMock := mocks.NewReader(t)
+
+	//прописываем ожидаемый результат
+	//
+	//specify the expected result
+	readerMock.On("GetCounters", mock.Anything, models.Labels(nil)).Return(map[string]int64{}, nil)
+	readerMock.On("GetGauges", mock.Anything, models.Labels(nil)).Return(map[string]float64{"HeapInuse": 25, "HeapSys": 100}, nil)
+
+	//создаем объект запроса
+	//
+	//create a request object
+	req, _ := http.NewRequest(http.MethodGet, "/query?expr="+url.QueryEscape("HeapInuse / HeapSys"), nil)
+
+	//создаем объект записи ответа
+	//
+	//create a response record object
+	rr := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	for i := 0; i < b.N; i++ {
+		Handler(logger, readerMock).ServeHTTP(rr, req)
+	}
+}
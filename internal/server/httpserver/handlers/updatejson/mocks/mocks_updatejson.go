@@ -2,30 +2,35 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	models "github.com/h2p2f/practicum-metrics/internal/server/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Updater is an autogenerated mock type for the Updater type
 type Updater struct {
 	mock.Mock
 }
 
-// GetCounter provides a mock function with given fields: name
-func (_m *Updater) GetCounter(name string) (int64, error) {
-	ret := _m.Called(name)
+// GetCounter provides a mock function with given fields: ctx, name, labels
+func (_m *Updater) GetCounter(ctx context.Context, name string, labels models.Labels) (int64, error) {
+	ret := _m.Called(ctx, name, labels)
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (int64, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (int64, error)); ok {
+		return rf(ctx, name, labels)
 	}
-	if rf, ok := ret.Get(0).(func(string) int64); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) int64); ok {
+		r0 = rf(ctx, name, labels)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -33,23 +38,47 @@ func (_m *Updater) GetCounter(name string) (int64, error) {
 	return r0, r1
 }
 
-// GetGauge provides a mock function with given fields: name
-func (_m *Updater) GetGauge(name string) (float64, error) {
-	ret := _m.Called(name)
+// GetGauge provides a mock function with given fields: ctx, name, labels
+func (_m *Updater) GetGauge(ctx context.Context, name string, labels models.Labels) (float64, error) {
+	ret := _m.Called(ctx, name, labels)
 
 	var r0 float64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (float64, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (float64, error)); ok {
+		return rf(ctx, name, labels)
 	}
-	if rf, ok := ret.Get(0).(func(string) float64); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) float64); ok {
+		r0 = rf(ctx, name, labels)
 	} else {
 		r0 = ret.Get(0).(float64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHistogram provides a mock function with given fields: ctx, name, labels
+func (_m *Updater) GetHistogram(ctx context.Context, name string, labels models.Labels) (models.Histogram, error) {
+	ret := _m.Called(ctx, name, labels)
+
+	var r0 models.Histogram
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) (models.Histogram, error)); ok {
+		return rf(ctx, name, labels)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels) models.Histogram); ok {
+		r0 = rf(ctx, name, labels)
+	} else {
+		r0 = ret.Get(0).(models.Histogram)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Labels) error); ok {
+		r1 = rf(ctx, name, labels)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -57,14 +86,46 @@ func (_m *Updater) GetGauge(name string) (float64, error) {
 	return r0, r1
 }
 
-// SetCounter provides a mock function with given fields: name, value
-func (_m *Updater) SetCounter(name string, value int64) {
-	_m.Called(name, value)
+// SetCounter provides a mock function with given fields: ctx, name, labels, value
+func (_m *Updater) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	ret := _m.Called(ctx, name, labels, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels, int64) error); ok {
+		r0 = rf(ctx, name, labels, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetGauge provides a mock function with given fields: ctx, name, labels, value
+func (_m *Updater) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	ret := _m.Called(ctx, name, labels, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels, float64) error); ok {
+		r0 = rf(ctx, name, labels, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// SetGauge provides a mock function with given fields: name, value
-func (_m *Updater) SetGauge(name string, value float64) {
-	_m.Called(name, value)
+// SetHistogram provides a mock function with given fields: ctx, name, labels, value
+func (_m *Updater) SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error {
+	ret := _m.Called(ctx, name, labels, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Labels, models.Histogram) error); ok {
+		r0 = rf(ctx, name, labels, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // NewUpdater creates a new instance of Updater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
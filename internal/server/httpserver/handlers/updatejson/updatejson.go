@@ -3,12 +3,15 @@
 package updatejson
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"go.uber.org/zap"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
 )
 
 // Updater is an interface that updates the metric.
@@ -16,15 +19,17 @@ import (
 //
 //go:generate mockery --name Updater --output ./mocks --filename mocks_updatejson.go
 type Updater interface {
-	SetGauge(name string, value float64)
-	SetCounter(name string, value int64)
-	GetCounter(name string) (value int64, err error)
-	GetGauge(name string) (value float64, err error)
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
+	GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error)
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error
+	GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error)
 }
 
 // Handler returns a http.HandlerFunc that handles POST requests and updates the metric in JSON.
 // It writes the updated value to the response body if the update is successful.
-// Otherwise, it returns an internal server error.
+// It returns a bad request error if the metric is invalid and an internal server error if the storage fails.
 func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check if the method is POST
@@ -47,6 +52,10 @@ func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		if err := metric.Labels.Validate(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
 
 		// Check if delta is negative
 		if metric.Delta != nil && *metric.Delta < 0 {
@@ -61,40 +70,74 @@ func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 		}
 		// Get the path from the URL
 		path := r.URL.Path
+		ctx := r.Context()
+		var err error
 		// if path is /update/, update the metric and return updated value
 		switch path {
 		case "/update/":
 			{
 				switch metric.MType {
 				case "gauge":
-					wrappedIFace.SetGauge(metric.ID, *metric.Value)
+					err = wrappedIFace.SetGauge(ctx, metric.ID, metric.Labels, *metric.Value)
 				case "counter":
 					{
-						wrappedIFace.SetCounter(metric.ID, *metric.Delta)
-						*metric.Delta, _ = wrappedIFace.GetCounter(metric.ID)
+						if err = wrappedIFace.SetCounter(ctx, metric.ID, metric.Labels, *metric.Delta); err == nil {
+							*metric.Delta, err = wrappedIFace.GetCounter(ctx, metric.ID, metric.Labels)
+						}
+					}
+				case "histogram":
+					{
+						if metric.Histogram == nil {
+							http.Error(w, "Bad request", http.StatusBadRequest)
+							return
+						}
+						err = wrappedIFace.SetHistogram(ctx, metric.ID, metric.Labels, *metric.Histogram)
+						if errors.Is(err, models.ErrInvalidHistogram) || errors.Is(err, models.ErrBucketsMismatch) {
+							http.Error(w, "Bad request", http.StatusBadRequest)
+							return
+						}
+						if err == nil {
+							*metric.Histogram, err = wrappedIFace.GetHistogram(ctx, metric.ID, metric.Labels)
+						}
 					}
 				default:
 					http.Error(w, "Bad request", http.StatusBadRequest)
 					return
 				}
 			}
-		// if path is /value/, get the metric and return the value
+		// if path is /value/, get the metric and return the value, a missing metric has a zero value
 		case "/value/":
 			{
 				switch metric.MType {
 				case "gauge":
 					{
-						n, _ := wrappedIFace.GetGauge(metric.ID)
+						var n float64
+						n, err = wrappedIFace.GetGauge(ctx, metric.ID, metric.Labels)
 						metric.Value = &n
 					}
 				case "counter":
 					{
-						n, _ := wrappedIFace.GetCounter(metric.ID)
+						var n int64
+						n, err = wrappedIFace.GetCounter(ctx, metric.ID, metric.Labels)
 						metric.Delta = &n
 					}
+				case "histogram":
+					{
+						var n models.Histogram
+						n, err = wrappedIFace.GetHistogram(ctx, metric.ID, metric.Labels)
+						metric.Histogram = &n
+					}
+				}
+				if errors.Is(err, servererrors.ErrNotFound) {
+					err = nil
 				}
 			}
 		}
+		if err != nil {
+			log.Error("could not access the storage", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 		// Marshal the metric struct into JSON
 		resp, err := json.Marshal(metric)
 		if err != nil {
@@ -3,11 +3,13 @@ package updatejson
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatejson/mocks"
@@ -15,14 +17,18 @@ import (
 )
 
 func TestHandler(t *testing.T) {
+	histogram := models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Count: 1, Sum: 0.5}
+
 	tests := []struct {
-		method string
-		name   string
-		metric string
-		key    string
-		value  float64
-		delta  int64
-		want   int
+		method    string
+		name      string
+		metric    string
+		key       string
+		value     float64
+		delta     int64
+		histogram *models.Histogram
+		err       error
+		want      int
 	}{
 		{
 			method: "POST",
@@ -64,30 +70,62 @@ func TestHandler(t *testing.T) {
 			value:  10.01,
 			want:   http.StatusMethodNotAllowed,
 		},
+		{
+			method:    "POST",
+			name:      "Test 6",
+			metric:    "histogram",
+			key:       "testKey",
+			histogram: &histogram,
+			want:      http.StatusOK,
+		},
+		{
+			method:    "POST",
+			name:      "Test 7",
+			metric:    "histogram",
+			key:       "testKey",
+			histogram: &histogram,
+			err:       models.ErrBucketsMismatch,
+			want:      http.StatusBadRequest,
+		},
+		{
+			method: "POST",
+			name:   "Test 8",
+			metric: "gauge",
+			key:    "testKey",
+			value:  10.01,
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			updaterMock := mocks.NewUpdater(t)
 
-			if tt.want == http.StatusOK {
+			if tt.want == http.StatusOK || tt.want == http.StatusInternalServerError {
 				switch tt.metric {
 				case "gauge":
-					updaterMock.On("SetGauge", tt.key, tt.value).Return(nil)
+					updaterMock.On("SetGauge", mock.Anything, tt.key, models.Labels(nil), tt.value).Return(tt.err)
 				case "counter":
-					updaterMock.On("SetCounter", tt.key, tt.value).Return(nil)
-					updaterMock.On("GetCounter", tt.key).Return(tt.value, nil)
+					updaterMock.On("SetCounter", mock.Anything, tt.key, models.Labels(nil), tt.value).Return(nil)
+					updaterMock.On("GetCounter", mock.Anything, tt.key, models.Labels(nil)).Return(tt.value, nil)
+				case "histogram":
+					updaterMock.On("GetHistogram", mock.Anything, tt.key, models.Labels(nil)).Return(*tt.histogram, nil)
 				}
 			}
+			if tt.histogram != nil {
+				updaterMock.On("SetHistogram", mock.Anything, tt.key, models.Labels(nil), *tt.histogram).Return(tt.err)
+			}
 
 			logger := zaptest.NewLogger(t)
 			handler := Handler(logger, updaterMock)
 
 			metric := models.Metric{
-				Delta: &tt.delta,
-				Value: &tt.value,
-				ID:    tt.key,
-				MType: tt.metric,
+				Delta:     &tt.delta,
+				Value:     &tt.value,
+				Histogram: tt.histogram,
+				ID:        tt.key,
+				MType:     tt.metric,
 			}
 
 			body, _ := json.Marshal(metric)
@@ -126,7 +164,7 @@ func Example() {
 	//
 	//create a mock database object
 	updaterMock := mocks.NewUpdater(t)
-	updaterMock.On("SetGauge", metric.ID, gauge).Return(nil)
+	updaterMock.On("SetGauge", mock.Anything, metric.ID, models.Labels(nil), gauge).Return(nil)
 	//создаем логгер
 	//
 	//create a logger
@@ -174,7 +212,7 @@ func BenchmarkHandler(b *testing.B) {
 	//
 	//create a mock database object
 	updaterMock := mocks.NewUpdater(t)
-	updaterMock.On("SetGauge", metric.ID, gauge).Return(nil)
+	updaterMock.On("SetGauge", mock.Anything, metric.ID, models.Labels(nil), gauge).Return(nil)
 	//создаем логгер
 	//
 	//create a logger
@@ -219,7 +257,7 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//
 		//create a mock database object
 		updaterMock := mocks.NewUpdater(t)
-		updaterMock.On("SetGauge", metric.ID, gauge).Return(nil)
+		updaterMock.On("SetGauge", mock.Anything, metric.ID, models.Labels(nil), gauge).Return(nil)
 		//создаем логгер
 		//
 		//create a logger
@@ -7,6 +7,9 @@ package updatejson
 //go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatejson -i Updater -t ../../../../../templates/gowrap/zap -o updatejson_with_logging.go -l ""
 
 import (
+	"context"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	"go.uber.org/zap"
 )
 
@@ -25,9 +28,11 @@ func NewUpdaterWithZap(base Updater, log *zap.Logger) UpdaterWithZap {
 }
 
 // GetCounter implements Updater
-func (_d UpdaterWithZap) GetCounter(name string) (value int64, err error) {
+func (_d UpdaterWithZap) GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error) {
 	_d._log.Debug("UpdaterWithZap: calling GetCounter", zap.Reflect("params", map[string]interface{}{
-		"name": name}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
 	defer func() {
 		if err != nil {
 			_d._log.Error("UpdaterWithZap: method GetCounter returned an error", zap.Error(err))
@@ -37,13 +42,15 @@ func (_d UpdaterWithZap) GetCounter(name string) (value int64, err error) {
 				"err":   err}))
 		}
 	}()
-	return _d._base.GetCounter(name)
+	return _d._base.GetCounter(ctx, name, labels)
 }
 
 // GetGauge implements Updater
-func (_d UpdaterWithZap) GetGauge(name string) (value float64, err error) {
+func (_d UpdaterWithZap) GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error) {
 	_d._log.Debug("UpdaterWithZap: calling GetGauge", zap.Reflect("params", map[string]interface{}{
-		"name": name}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
 	defer func() {
 		if err != nil {
 			_d._log.Error("UpdaterWithZap: method GetGauge returned an error", zap.Error(err))
@@ -53,29 +60,77 @@ func (_d UpdaterWithZap) GetGauge(name string) (value float64, err error) {
 				"err":   err}))
 		}
 	}()
-	return _d._base.GetGauge(name)
+	return _d._base.GetGauge(ctx, name, labels)
+}
+
+// GetHistogram implements Updater
+func (_d UpdaterWithZap) GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error) {
+	_d._log.Debug("UpdaterWithZap: calling GetHistogram", zap.Reflect("params", map[string]interface{}{
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels}))
+	defer func() {
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method GetHistogram returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method GetHistogram finished", zap.Reflect("results", map[string]interface{}{
+				"value": value,
+				"err":   err}))
+		}
+	}()
+	return _d._base.GetHistogram(ctx, name, labels)
 }
 
 // SetCounter implements Updater
-func (_d UpdaterWithZap) SetCounter(name string, value int64) {
+func (_d UpdaterWithZap) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) (err error) {
 	_d._log.Debug("UpdaterWithZap: calling SetCounter", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels,
+		"value":  value}))
 	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetCounter finished")
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetCounter returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetCounter finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
 	}()
-	_d._base.SetCounter(name, value)
-	return
+	return _d._base.SetCounter(ctx, name, labels, value)
 }
 
 // SetGauge implements Updater
-func (_d UpdaterWithZap) SetGauge(name string, value float64) {
+func (_d UpdaterWithZap) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) (err error) {
 	_d._log.Debug("UpdaterWithZap: calling SetGauge", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels,
+		"value":  value}))
 	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetGauge finished")
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetGauge returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetGauge finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
+	}()
+	return _d._base.SetGauge(ctx, name, labels, value)
+}
+
+// SetHistogram implements Updater
+func (_d UpdaterWithZap) SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) (err error) {
+	_d._log.Debug("UpdaterWithZap: calling SetHistogram", zap.Reflect("params", map[string]interface{}{
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels,
+		"value":  value}))
+	defer func() {
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetHistogram returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetHistogram finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
 	}()
-	_d._base.SetGauge(name, value)
-	return
+	return _d._base.SetHistogram(ctx, name, labels, value)
 }
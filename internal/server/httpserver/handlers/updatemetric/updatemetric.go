@@ -2,26 +2,30 @@
 package updatemetric
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 )
 
 // Updater is an interface that updates the metric.
 //
 //go:generate mockery --name Updater --output ./mocks --filename mocks_updatemetric.go
 type Updater interface {
-	SetGauge(name string, value float64)
-	SetCounter(name string, value int64)
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
 }
 
 // Handler returns a http.HandlerFunc that handles POST requests and updates the metric.
 // It returns http.StatusOK if successful.
-// Otherwise, it returns an internal server error.
-// data to update receive in URI
+// It returns a bad request error if the metric is invalid and an internal server error if the storage fails.
+// data to update receive in URI, the labels of the series are taken from the query parameters
 func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check if the method is POST
@@ -46,17 +50,26 @@ func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		labels := models.LabelsFromQuery(r.URL.Query())
+		if err := labels.Validate(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
 		// Processing and validation of the received data
-		err := updaterMetric(&wrappedIFace, log, metric, key, value)
-		if err != nil {
+		err := updaterMetric(r.Context(), &wrappedIFace, log, metric, key, labels, value)
+		if errors.Is(err, models.ErrInvalidMetric) {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
-// updaterMetric - function to update the metric
-func updaterMetric(updater *UpdaterWithZap, log *zap.Logger, metric, key, value string) error {
+// updaterMetric - function to update the metric, an invalid metric is reported as models.ErrInvalidMetric
+func updaterMetric(ctx context.Context, updater *UpdaterWithZap, log *zap.Logger, metric, key string, labels models.Labels, value string) error {
 	var (
 		i   int64
 		f   float64
@@ -68,33 +81,31 @@ func updaterMetric(updater *UpdaterWithZap, log *zap.Logger, metric, key, value
 		f, err = strconv.ParseFloat(value, 64)
 		if err != nil {
 			log.Error("could not parse float", zap.Error(err))
-			return err
+			return fmt.Errorf("%w: %v", models.ErrInvalidMetric, err)
 		}
 		// Check if the value is negative
 		if f < 0 {
 			log.Error("value must be positive")
-			return errors.New("value must be positive")
+			return fmt.Errorf("%w: value must be positive", models.ErrInvalidMetric)
 		}
 		// Update the metric
-		updater.SetGauge(key, f)
+		return updater.SetGauge(ctx, key, labels, f)
 	case "counter":
 		// Parse the value to int64
 		i, err = strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			log.Error("could not parse int", zap.Error(err))
-			return err
+			return fmt.Errorf("%w: %v", models.ErrInvalidMetric, err)
 		}
 		// Check if the value is negative
 		if i < 0 {
 			log.Error("value must be positive")
-			return errors.New("value must be positive")
+			return fmt.Errorf("%w: value must be positive", models.ErrInvalidMetric)
 		}
 		// Update the metric
-		updater.SetCounter(key, i)
+		return updater.SetCounter(ctx, key, labels, i)
 	default:
 		log.Error("invalid metric type")
-		return errors.New("invalid metric type")
+		return fmt.Errorf("%w: invalid metric type", models.ErrInvalidMetric)
 	}
-	return nil
-
 }
@@ -2,6 +2,7 @@ package updatemetric
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatemetric/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 )
 
 func TestUpdateMetric(t *testing.T) {
@@ -22,6 +24,7 @@ func TestUpdateMetric(t *testing.T) {
 		metric string
 		key    string
 		value  string
+		err    error
 		want   int
 	}{
 		{
@@ -56,16 +59,26 @@ func TestUpdateMetric(t *testing.T) {
 			value:  "10",
 			want:   http.StatusMethodNotAllowed,
 		},
+		{
+			method: "POST",
+			name:   "Test 5",
+			metric: "counter",
+			key:    "testKey",
+			value:  "10",
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			updaterMock := mocks.NewUpdater(t)
-			if tt.want == http.StatusOK && tt.metric == "gauge" {
-				updaterMock.On("SetGauge", tt.key, mock.Anything).Return(nil)
+			stored := tt.want == http.StatusOK || tt.want == http.StatusInternalServerError
+			if stored && tt.metric == "gauge" {
+				updaterMock.On("SetGauge", mock.Anything, tt.key, models.Labels(nil), mock.Anything).Return(tt.err)
 			}
-			if tt.want == http.StatusOK && tt.metric == "counter" {
-				updaterMock.On("SetCounter", tt.key, mock.Anything).Return(nil)
+			if stored && tt.metric == "counter" {
+				updaterMock.On("SetCounter", mock.Anything, tt.key, models.Labels(nil), mock.Anything).Return(tt.err)
 			}
 			logger := zaptest.NewLogger(t)
 			handler := Handler(logger, updaterMock)
@@ -99,7 +112,7 @@ func Example() {
 	//
 	//create a mock database object
 	updaterMock := mocks.NewUpdater(t)
-	updaterMock.On("SetGauge", "testKey", mock.Anything).Return(nil)
+	updaterMock.On("SetGauge", mock.Anything, "testKey", models.Labels(nil), mock.Anything).Return(nil)
 	//создаем тестовый объект логгера
 	//
 	//create a test logger object
@@ -139,7 +152,7 @@ func BenchmarkHandler(b *testing.B) {
 	//
 	//create a mock database object
 	updaterMock := mocks.NewUpdater(t)
-	updaterMock.On("SetGauge", "testKey", mock.Anything).Return(nil)
+	updaterMock.On("SetGauge", mock.Anything, "testKey", models.Labels(nil), mock.Anything).Return(nil)
 	//создаем тестовый объект логгера
 	//
 	//create a test logger object
@@ -179,7 +192,7 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//
 		//create a mock database object
 		updaterMock := mocks.NewUpdater(t)
-		updaterMock.On("SetGauge", "testKey", mock.Anything).Return(nil)
+		updaterMock.On("SetGauge", mock.Anything, "testKey", models.Labels(nil), mock.Anything).Return(nil)
 		//создаем тестовый объект логгера
 		//
 		//create a test logger object
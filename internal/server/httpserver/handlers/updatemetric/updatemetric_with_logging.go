@@ -7,6 +7,9 @@ package updatemetric
 //go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatemetric -i Updater -t ../../../../../templates/gowrap/zap -o updatemetric_with_logging.go -l ""
 
 import (
+	"context"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	"go.uber.org/zap"
 )
 
@@ -25,25 +28,37 @@ func NewUpdaterWithZap(base Updater, log *zap.Logger) UpdaterWithZap {
 }
 
 // SetCounter implements Updater
-func (_d UpdaterWithZap) SetCounter(name string, value int64) {
+func (_d UpdaterWithZap) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) (err error) {
 	_d._log.Debug("UpdaterWithZap: calling SetCounter", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels,
+		"value":  value}))
 	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetCounter finished")
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetCounter returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetCounter finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
 	}()
-	_d._base.SetCounter(name, value)
-	return
+	return _d._base.SetCounter(ctx, name, labels, value)
 }
 
 // SetGauge implements Updater
-func (_d UpdaterWithZap) SetGauge(name string, value float64) {
+func (_d UpdaterWithZap) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) (err error) {
 	_d._log.Debug("UpdaterWithZap: calling SetGauge", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
+		"ctx":    ctx,
+		"name":   name,
+		"labels": labels,
+		"value":  value}))
 	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetGauge finished")
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetGauge returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetGauge finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
 	}()
-	_d._base.SetGauge(name, value)
-	return
+	return _d._base.SetGauge(ctx, name, labels, value)
 }
@@ -2,21 +2,30 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	models "github.com/h2p2f/practicum-metrics/internal/server/models"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Updater is an autogenerated mock type for the Updater type
 type Updater struct {
 	mock.Mock
 }
 
-// SetCounter provides a mock function with given fields: name, value
-func (_m *Updater) SetCounter(name string, value int64) {
-	_m.Called(name, value)
-}
+// SetMetrics provides a mock function with given fields: ctx, metrics
+func (_m *Updater) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	ret := _m.Called(ctx, metrics)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Metric) error); ok {
+		r0 = rf(ctx, metrics)
+	} else {
+		r0 = ret.Error(0)
+	}
 
-// SetGauge provides a mock function with given fields: name, value
-func (_m *Updater) SetGauge(name string, value float64) {
-	_m.Called(name, value)
+	return r0
 }
 
 // NewUpdater creates a new instance of Updater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -3,7 +3,9 @@ package updatesmetrics
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"go.uber.org/zap"
@@ -15,11 +17,11 @@ import (
 //
 //go:generate mockery --name Updater --output ./mocks --filename mocks_updatesmetrics.go
 type Updater interface {
-	SetGauge(name string, value float64)
-	SetCounter(name string, value int64)
+	SetMetrics(ctx context.Context, metrics []models.Metric) error
 }
 
 // Handler returns a http.HandlerFunc that handles POST requests and updates the batch metric in JSON.
+// The batch is stored all or nothing, it returns a bad request error if any metric is invalid.
 // Otherwise, it returns an internal server error.
 func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -49,27 +51,25 @@ func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		// Iterate over the slice of metrics
-		for _, metric := range metrics {
-			switch metric.MType {
-			case "gauge":
-				// Check if value is negative
-				if *metric.Value < 0 {
-					http.Error(w, "Bad request", http.StatusBadRequest)
-					return
-				}
-				// Update the metric
-				wrappedIFace.SetGauge(metric.ID, *metric.Value)
-			case "counter":
-				// Check if delta is negative
-				if *metric.Delta < 0 {
-					http.Error(w, "Bad request", http.StatusBadRequest)
-					return
-				}
-				// Update the metric
-				wrappedIFace.SetCounter(metric.ID, *metric.Delta)
+		// Check every metric before the batch is stored
+		for i := range metrics {
+			if err := metrics[i].Validate(); err != nil {
+				log.Info("invalid metric in batch", zap.String("id", metrics[i].ID), zap.Error(err))
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
 			}
 		}
+		// Update the batch, histograms with other buckets are rejected by the storage
+		err = wrappedIFace.SetMetrics(r.Context(), metrics)
+		if errors.Is(err, models.ErrBucketsMismatch) {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Error("could not store batch", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
 		w.WriteHeader(http.StatusOK)
 	}
@@ -3,11 +3,13 @@ package updatesmetrics
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatesmetrics/mocks"
@@ -22,6 +24,7 @@ func TestHandler(t *testing.T) {
 	tests := []struct {
 		name    string
 		metrics []models.Metric
+		err     error
 		want    int
 	}{
 		{
@@ -33,9 +36,10 @@ func TestHandler(t *testing.T) {
 					Value: &gauge,
 				},
 				{
-					ID:    "testKey2",
-					MType: "counter",
-					Delta: &counter,
+					ID:     "testKey2",
+					MType:  "counter",
+					Delta:  &counter,
+					Labels: models.Labels{"host": "a"},
 				},
 			},
 			want: http.StatusOK,
@@ -51,14 +55,61 @@ func TestHandler(t *testing.T) {
 			},
 			want: http.StatusBadRequest,
 		},
+		{
+			name: "Test 3",
+			metrics: []models.Metric{
+				{
+					ID:     "testKey2",
+					MType:  "counter",
+					Delta:  &counter,
+					Labels: models.Labels{"not a label": "a"},
+				},
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "Test 4",
+			metrics: []models.Metric{
+				{
+					ID:        "testKey3",
+					MType:     "histogram",
+					Histogram: &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Count: 1, Sum: 0.5},
+				},
+			},
+			err:  models.ErrBucketsMismatch,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "Test 5",
+			metrics: []models.Metric{
+				{
+					ID:    "testKey",
+					MType: "gauge",
+					Value: &gauge,
+				},
+			},
+			err:  errors.New("connection refused"),
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "Test 6",
+			metrics: []models.Metric{
+				{
+					ID:    "testKey",
+					MType: "gauge",
+				},
+			},
+			want: http.StatusBadRequest,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			updatersMock := mocks.NewUpdater(t)
 			if tt.want == http.StatusOK {
-				updatersMock.On("SetGauge", tt.metrics[0].ID, *tt.metrics[0].Value).Return(nil)
-				updatersMock.On("SetCounter", tt.metrics[1].ID, *tt.metrics[1].Delta).Return(nil)
-
+				updatersMock.On("SetMetrics", mock.Anything, tt.metrics).Return(nil)
+			}
+			if tt.err != nil {
+				updatersMock.On("SetMetrics", mock.Anything, tt.metrics).Return(tt.err)
 			}
 			logger := zaptest.NewLogger(t)
 			handler := Handler(logger, updatersMock)
@@ -91,8 +142,7 @@ func Example() {
 	//
 
 	updatersMock := mocks.NewUpdater(t)
-	updatersMock.On("SetGauge", "testKey", gauge).Return(nil)
-	updatersMock.On("SetCounter", "testKey", counter).Return(nil)
+	updatersMock.On("SetMetrics", mock.Anything, mock.Anything).Return(nil)
 	//создаем логгер
 	//
 	//create logger
@@ -143,8 +193,7 @@ func BenchmarkHandler(b *testing.B) {
 	//
 
 	updatersMock := mocks.NewUpdater(t)
-	updatersMock.On("SetGauge", "testKey", gauge).Return(nil)
-	updatersMock.On("SetCounter", "testKey", counter).Return(nil)
+	updatersMock.On("SetMetrics", mock.Anything, mock.Anything).Return(nil)
 	//создаем логгер
 	//
 	//create logger
@@ -197,8 +246,7 @@ func BenchmarkHandlerParallel(b *testing.B) {
 		//
 
 		updatersMock := mocks.NewUpdater(t)
-		updatersMock.On("SetGauge", "testKey", gauge).Return(nil)
-		updatersMock.On("SetCounter", "testKey", counter).Return(nil)
+		updatersMock.On("SetMetrics", mock.Anything, mock.Anything).Return(nil)
 		//создаем логгер
 		//
 		//create logger
@@ -7,6 +7,9 @@ package updatesmetrics
 //go:generate gowrap gen -p github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatesmetrics -i Updater -t ../../../../../templates/gowrap/zap -o updatesmetrics_with_logging.go -l ""
 
 import (
+	"context"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 	"go.uber.org/zap"
 )
 
@@ -24,26 +27,18 @@ func NewUpdaterWithZap(base Updater, log *zap.Logger) UpdaterWithZap {
 	}
 }
 
-// SetCounter implements Updater
-func (_d UpdaterWithZap) SetCounter(name string, value int64) {
-	_d._log.Debug("UpdaterWithZap: calling SetCounter", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
-	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetCounter finished")
-	}()
-	_d._base.SetCounter(name, value)
-	return
-}
-
-// SetGauge implements Updater
-func (_d UpdaterWithZap) SetGauge(name string, value float64) {
-	_d._log.Debug("UpdaterWithZap: calling SetGauge", zap.Reflect("params", map[string]interface{}{
-		"name":  name,
-		"value": value}))
+// SetMetrics implements Updater
+func (_d UpdaterWithZap) SetMetrics(ctx context.Context, metrics []models.Metric) (err error) {
+	_d._log.Debug("UpdaterWithZap: calling SetMetrics", zap.Reflect("params", map[string]interface{}{
+		"ctx":     ctx,
+		"metrics": metrics}))
 	defer func() {
-		_d._log.Debug("UpdaterWithZap: SetGauge finished")
+		if err != nil {
+			_d._log.Error("UpdaterWithZap: method SetMetrics returned an error", zap.Error(err))
+		} else {
+			_d._log.Debug("UpdaterWithZap: method SetMetrics finished", zap.Reflect("results", map[string]interface{}{
+				"err": err}))
+		}
 	}()
-	_d._base.SetGauge(name, value)
-	return
+	return _d._base.SetMetrics(ctx, metrics)
 }
@@ -0,0 +1,186 @@
+package writeinflux
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// errSyntax - an error that occurs when the line does not follow the line protocol
+var errSyntax = errors.New("invalid line protocol syntax")
+
+// point - the metrics of one line, integer fields are counters and float fields are gauges
+type point struct {
+	labels    models.Labels
+	counters  map[string]int64
+	gauges    map[string]float64
+	timestamp time.Time
+}
+
+// parseLine parses one line of the Influx line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+//
+// The metric name is the measurement and the field key joined by an underscore, the tags become labels.
+// Integer fields (with the i or u suffix) are counters and float fields are gauges,
+// string and boolean fields are skipped. The timestamp is in the given precision, now is used if it is missing.
+func parseLine(line string, precision time.Duration, now time.Time) (point, error) {
+	sections := split(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return point{}, errSyntax
+	}
+
+	// measurement and tags
+	key := split(sections[0], ',', false)
+	measurement := unescape(key[0])
+	if measurement == "" {
+		return point{}, fmt.Errorf("%w: empty measurement", errSyntax)
+	}
+	p := point{
+		counters:  make(map[string]int64),
+		gauges:    make(map[string]float64),
+		timestamp: now,
+	}
+	for _, tag := range key[1:] {
+		name, value, ok := cutUnescaped(tag)
+		if !ok || name == "" || value == "" {
+			return point{}, fmt.Errorf("%w: bad tag %q", errSyntax, tag)
+		}
+		if p.labels == nil {
+			p.labels = make(models.Labels)
+		}
+		p.labels[unescape(name)] = unescape(value)
+	}
+	if err := p.labels.Validate(); err != nil {
+		return point{}, err
+	}
+
+	// fields
+	for _, field := range split(sections[1], ',', true) {
+		name, value, ok := cutUnescaped(field)
+		if !ok || name == "" || value == "" {
+			return point{}, fmt.Errorf("%w: bad field %q", errSyntax, field)
+		}
+		metric := measurement + "_" + unescape(name)
+		switch {
+		case value[0] == '"':
+			// string field
+			if len(value) < 2 || value[len(value)-1] != '"' {
+				return point{}, fmt.Errorf("%w: bad string field %q", errSyntax, field)
+			}
+		case isBool(value):
+		case strings.HasSuffix(value, "i"):
+			i, err := strconv.ParseInt(strings.TrimSuffix(value, "i"), 10, 64)
+			if err != nil {
+				return point{}, fmt.Errorf("bad integer field %q: %w", field, err)
+			}
+			if i < 0 {
+				return point{}, fmt.Errorf("counter field %q must be positive", field)
+			}
+			p.counters[metric] = i
+		case strings.HasSuffix(value, "u"):
+			u, err := strconv.ParseInt(strings.TrimSuffix(value, "u"), 10, 64)
+			if err != nil || u < 0 {
+				return point{}, fmt.Errorf("bad unsigned field %q", field)
+			}
+			p.counters[metric] = u
+		default:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return point{}, fmt.Errorf("bad float field %q: %w", field, err)
+			}
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return point{}, fmt.Errorf("float field %q must be finite", field)
+			}
+			p.gauges[metric] = f
+		}
+	}
+
+	// timestamp
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return point{}, fmt.Errorf("bad timestamp %q: %w", sections[2], err)
+		}
+		p.timestamp = time.Unix(0, ts*int64(precision))
+	}
+	return p, nil
+}
+
+// split - function to split s by the separator, escaped separators and separators in quoted strings are kept
+func split(s string, sep byte, quotes bool) []string {
+	var (
+		parts    []string
+		start    int
+		inQuotes bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quotes && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// cutUnescaped - function to split s around the first unescaped equal sign
+func cutUnescaped(s string) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// unescape - function to remove the backslashes before escaped characters
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// isBool - function to check if the field value is a boolean
+func isBool(value string) bool {
+	switch value {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return true
+	}
+	return false
+}
+
+// parsePrecision - function to get the timestamp unit from the precision query parameter, nanoseconds by default
+func parsePrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	}
+	return 0, fmt.Errorf("unknown precision %q", precision)
+}
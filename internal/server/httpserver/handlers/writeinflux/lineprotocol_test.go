@@ -0,0 +1,121 @@
+package writeinflux
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name    string
+		line    string
+		want    point
+		wantErr bool
+	}{
+		{
+			name: "Test 1",
+			line: "cpu,host=a,region=eu usage=0.5,requests=10i 1700000000000000000",
+			want: point{
+				labels:    models.Labels{"host": "a", "region": "eu"},
+				counters:  map[string]int64{"cpu_requests": 10},
+				gauges:    map[string]float64{"cpu_usage": 0.5},
+				timestamp: time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name: "Test 2",
+			line: `disk\ io,path=C:\,\ x read=1,msg="a b,c=d",ok=true`,
+			want: point{
+				labels:    models.Labels{"path": "C:, x"},
+				counters:  map[string]int64{},
+				gauges:    map[string]float64{"disk io_read": 1},
+				timestamp: now,
+			},
+		},
+		{
+			name: "Test 3",
+			line: `disk\ io,path=C:\\\,x read=1,msg="a b,c=d",ok=true`,
+			want: point{
+				labels:    models.Labels{"path": `C:\,x`},
+				counters:  map[string]int64{},
+				gauges:    map[string]float64{"disk io_read": 1},
+				timestamp: now,
+			},
+		},
+		{
+			name: "Test 4",
+			line: "mem free=3u,temp=-1.5",
+			want: point{
+				counters:  map[string]int64{"mem_free": 3},
+				gauges:    map[string]float64{"mem_temp": -1.5},
+				timestamp: now,
+			},
+		},
+		{
+			name:    "Test 5",
+			line:    "cpu",
+			wantErr: true,
+		},
+		{
+			name:    "Test 6",
+			line:    "cpu usage=abc",
+			wantErr: true,
+		},
+		{
+			name:    "Test 7",
+			line:    "cpu requests=-1i",
+			wantErr: true,
+		},
+		{
+			name:    "Test 8",
+			line:    "cpu,1host=a usage=1",
+			wantErr: true,
+		},
+		{
+			name:    "Test 9",
+			line:    "cpu usage=1 yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line, time.Nanosecond, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrecision(t *testing.T) {
+	tests := []struct {
+		precision string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{precision: "", want: time.Nanosecond},
+		{precision: "us", want: time.Microsecond},
+		{precision: "ms", want: time.Millisecond},
+		{precision: "s", want: time.Second},
+		{precision: "h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.precision, func(t *testing.T) {
+			got, err := parsePrecision(tt.precision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parsePrecision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
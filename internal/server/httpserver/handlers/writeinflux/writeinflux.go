@@ -0,0 +1,122 @@
+// Package writeinflux contains an http.Handler that updates metrics sent in the Influx line protocol.
+package writeinflux
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// maxLineSize - the maximum length of one line of the request body
+const maxLineSize = 1 << 20
+
+// Updater is an interface that updates metrics at the given time.
+//
+//go:generate mockery --name Updater --output ./mocks --filename mocks_writeinflux.go
+type Updater interface {
+	SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error
+	SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error
+}
+
+// LineError - the error of one line of the request body, lines are numbered from 1.
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Report - the response body with the lines that were not stored.
+type Report struct {
+	Errors []LineError `json:"errors"`
+}
+
+// Handler returns a http.HandlerFunc that handles POST requests with metrics in the Influx line protocol.
+// Integer fields are stored as counters and float fields as gauges named measurement_field, the tags become labels.
+// The timestamps are read in the precision set by the precision query parameter (ns, us, ms or s), nanoseconds by default.
+// It returns http.StatusNoContent if every line is stored. Otherwise, the valid lines are still stored
+// and it returns a bad request error with the report of the lines that failed to parse.
+// If the storage fails, it stops and returns an internal server error.
+func Handler(log *zap.Logger, db Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if the method is POST
+		if r.Method != http.MethodPost {
+			log.Sugar().Infow("method not allowed")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		wrappedIFace := NewUpdaterWithZap(db, log)
+
+		precision, err := parsePrecision(r.URL.Query().Get("precision"))
+		if err != nil {
+			log.Info("bad precision", zap.Error(err))
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		var report Report
+		now := time.Now()
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			// Skip empty lines and comments
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			p, err := parseLine(line, precision, now)
+			if err != nil {
+				report.Errors = append(report.Errors, LineError{Line: n, Error: err.Error()})
+				continue
+			}
+			// Update the metrics of the line
+			if err := store(r.Context(), &wrappedIFace, p); err != nil {
+				log.Error("could not store the line", zap.Int("line", n), zap.Error(err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Error("could not read from body", zap.Error(err))
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if len(report.Errors) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resp, err := json.Marshal(report)
+		if err != nil {
+			log.Error("could not marshal json", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// Set response headers and write the report
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write(resp); err != nil {
+			log.Error("could not write response", zap.Error(err))
+		}
+	}
+}
+
+// store - function to update the metrics of the parsed line
+func store(ctx context.Context, updater *UpdaterWithZap, p point) error {
+	for name, value := range p.counters {
+		if err := updater.SetCounterAt(ctx, name, p.labels, value, p.timestamp); err != nil {
+			return err
+		}
+	}
+	for name, value := range p.gauges {
+		if err := updater.SetGaugeAt(ctx, name, p.labels, value, p.timestamp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
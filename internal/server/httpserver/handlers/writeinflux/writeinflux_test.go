@@ -0,0 +1,171 @@
+package writeinflux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/writeinflux/mocks"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		method   string
+		name     string
+		link     string
+		body     string
+		counters int
+		gauges   int
+		err      error
+		want     int
+		wantBody string
+	}{
+		{
+			method:   http.MethodPost,
+			name:     "Test 1",
+			link:     "/write",
+			body:     "cpu,host=a usage=0.5,requests=10i\n# comment\n\nmem free=1.5\n",
+			counters: 1,
+			gauges:   2,
+			want:     http.StatusNoContent,
+		},
+		{
+			method:   http.MethodPost,
+			name:     "Test 2",
+			link:     "/write",
+			body:     "cpu usage=0.5\ncpu usage=abc\ncpu\n",
+			gauges:   1,
+			want:     http.StatusBadRequest,
+			wantBody: `{"errors":[{"line":2,"error":"bad float field \"usage=abc\": strconv.ParseFloat: parsing \"abc\": invalid syntax"},{"line":3,"error":"invalid line protocol syntax"}]}`,
+		},
+		{
+			method: http.MethodPost,
+			name:   "Test 3",
+			link:   "/write?precision=h",
+			body:   "cpu usage=0.5 1\n",
+			want:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodGet,
+			name:   "Test 4",
+			link:   "/write",
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			method: http.MethodPost,
+			name:   "Test 5",
+			link:   "/write",
+			body:   "cpu usage=0.5\nmem free=1.5\n",
+			gauges: 1,
+			err:    errors.New("storage is unavailable"),
+			want:   http.StatusInternalServerError,
+		},
+		{
+			method:   http.MethodPost,
+			name:     "Test 6",
+			link:     "/write",
+			body:     "cpu usage=NaN\ncpu usage=Inf\n",
+			want:     http.StatusBadRequest,
+			wantBody: `{"errors":[{"line":1,"error":"float field \"usage=NaN\" must be finite"},{"line":2,"error":"float field \"usage=Inf\" must be finite"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updaterMock := mocks.NewUpdater(t)
+			if tt.counters > 0 {
+				updaterMock.On("SetCounterAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(tt.counters)
+			}
+			if tt.gauges > 0 {
+				updaterMock.On("SetGaugeAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.err).Times(tt.gauges)
+			}
+			logger := zaptest.NewLogger(t)
+			handler := Handler(logger, updaterMock)
+
+			request := httptest.NewRequest(tt.method, tt.link, bytes.NewBufferString(tt.body))
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.want {
+				t.Errorf("Handler() = %v, want %v", response.Code, tt.want)
+			}
+			if tt.wantBody != "" && response.Body.String() != tt.wantBody {
+				t.Errorf("Handler() body = %v, want %v", response.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func Example() {
+	//создаем тестовый объект
+	//
+	//create a test object
+	t := &testing.T{}
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	updaterMock := mocks.NewUpdater(t)
+	updaterMock.On("SetGaugeAt", mock.Anything, "cpu_usage", models.Labels{"host": "a"}, 0.5, time.Unix(1700000000, 0)).Return(nil)
+	updaterMock.On("SetCounterAt", mock.Anything, "cpu_requests", models.Labels{"host": "a"}, int64(10), time.Unix(1700000000, 0)).Return(nil)
+	//создаем логгер
+	//
+	//create a logger
+	logger := zaptest.NewLogger(t)
+	//создаем запрос с метриками в line protocol и стуктуру обработки ответа
+	//
+	//create a request with metrics in line protocol and response handling structure
+	body := bytes.NewBufferString("cpu,host=a usage=0.5,requests=10i 1700000000\ncpu,host=a usage=\n")
+	request := httptest.NewRequest(http.MethodPost, "/write?precision=s", body)
+	response := httptest.NewRecorder()
+	//вызываем обработчик
+	//
+	//call the handler
+	Handler(logger, updaterMock).ServeHTTP(response, request)
+	//выводим результаты
+	//
+	//output the results
+	fmt.Println(response.Code)
+	fmt.Println(response.Body.String())
+
+	// Output:
+	// 400
+	// {"errors":[{"line":2,"error":"invalid line protocol syntax: bad field \"usage=\""}]}
+}
+
+func BenchmarkHandler(b *testing.B) {
+	//создаем тестовую структуру
+	//
+	//create a test structure
+	t := &testing.T{}
+	//создаем моковый объект базы данных
+	//
+	//create a mock database object
+	updaterMock := mocks.NewUpdater(t)
+	updaterMock.On("SetGaugeAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	updaterMock.On("SetCounterAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	//создаем логгер
+	//
+	//create a logger
+	logger := zaptest.NewLogger(t)
+	body := []byte("cpu,host=a,region=eu usage=0.5,requests=10i 1700000000000000000\n")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	//вызываем обработчик
+	//
+	//call the handler
+	for i := 0; i < b.N; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/write", bytes.NewReader(body))
+		response := httptest.NewRecorder()
+		Handler(logger, updaterMock).ServeHTTP(response, request)
+	}
+}
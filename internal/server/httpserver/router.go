@@ -2,6 +2,9 @@
 package httpserver
 
 import (
+	"context"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/h2p2f/practicum-metrics/internal/server/config"
@@ -10,25 +13,37 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/dbping"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getalerts"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getallmetrics"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/gethistory"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getmetric"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getprometheus"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/getquery"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatejson"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatemetric"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/updatesmetrics"
+	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/handlers/writeinflux"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/middlewares/compressormiddleware"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/middlewares/hashmiddleware"
 	"github.com/h2p2f/practicum-metrics/internal/server/httpserver/middlewares/loggermiddleware"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
 )
 
 // DataBaser is an interface for working with a data store.
 type DataBaser interface {
-	SetCounter(key string, value int64)
-	SetGauge(key string, value float64)
-	GetCounter(name string) (value int64, err error)
-	GetGauge(name string) (value float64, err error)
-	GetCounters() map[string]int64
-	GetGauges() map[string]float64
-	Ping() error
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error
+	SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error
+	GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error)
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error
+	GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error)
+	SetMetrics(ctx context.Context, metrics []models.Metric) error
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
+	GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error)
+	Ping(ctx context.Context) error
 }
 
 // DataBase is a structure for working with a data store.
@@ -41,8 +56,8 @@ func NewDataBase(db DataBaser) *DataBase {
 	return &DataBase{db}
 }
 
-// MetricRouter is a constructor for the router.
-func MetricRouter(logger *zap.Logger, m DataBaser, config *config.ServerConfig) *chi.Mux {
+// MetricRouter is a constructor for the router, the alerts are the states of the alerting rules.
+func MetricRouter(logger *zap.Logger, m DataBaser, alerts getalerts.Alerter, config *config.ServerConfig) *chi.Mux {
 	db := NewDataBase(m)
 	r := chi.NewRouter()
 
@@ -63,9 +78,14 @@ func MetricRouter(logger *zap.Logger, m DataBaser, config *config.ServerConfig)
 	r.Post("/update/", updatejson.Handler(logger, db))
 	r.Post("/value/", updatejson.Handler(logger, db))
 	r.Post("/updates/", updatesmetrics.Handler(logger, db))
+	r.Post("/write", writeinflux.Handler(logger, db))
 
 	r.Get("/value/{metric}/{key}", getmetric.Handler(logger, db))
+	r.Get("/history/{metric}/{key}", gethistory.Handler(logger, db))
 	r.Get("/", getallmetrics.Handler(logger, db))
+	r.Get("/metrics", getprometheus.Handler(logger, db))
+	r.Get("/query", getquery.Handler(logger, db))
+	r.Get("/alerts", getalerts.Handler(logger, alerts))
 	r.Get("/ping", dbping.Handler(logger, db))
 
 	return r
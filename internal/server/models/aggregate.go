@@ -0,0 +1,38 @@
+package models
+
+// Aggregate - data model for the summary of the values rolled up into one point of the downsampled history.
+// For a counter the values are the accumulated counter values, a histogram point has no aggregate.
+type Aggregate struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Last  float64 `json:"last"`
+	Sum   float64 `json:"sum"`
+	Count uint64  `json:"count"`
+}
+
+// NewAggregate creates the aggregate of one value.
+func NewAggregate(v float64) Aggregate {
+	return Aggregate{Min: v, Max: v, Avg: v, Last: v, Sum: v, Count: 1}
+}
+
+// Merge adds the values of the other aggregate, the other one is considered to be the later.
+func (a *Aggregate) Merge(other Aggregate) {
+	if other.Count == 0 {
+		return
+	}
+	if a.Count == 0 {
+		*a = other
+		return
+	}
+	if other.Min < a.Min {
+		a.Min = other.Min
+	}
+	if other.Max > a.Max {
+		a.Max = other.Max
+	}
+	a.Sum += other.Sum
+	a.Count += other.Count
+	a.Avg = a.Sum / float64(a.Count)
+	a.Last = other.Last
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// The states of the alert.
+const (
+	AlertInactive = "inactive"
+	AlertPending  = "pending"
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert - data model for the state of the alert of a series matched by the alerting rule.
+// Labels are the labels of the series merged with the labels of the rule.
+// ActiveAt is the time the condition became true, FiredAt and ResolvedAt are set on the transitions.
+type Alert struct {
+	Rule       string     `json:"rule"`
+	Labels     Labels     `json:"labels,omitempty"`
+	Severity   string     `json:"severity,omitempty"`
+	State      string     `json:"state"`
+	Value      float64    `json:"value"`
+	ActiveAt   time.Time  `json:"active_at"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Key returns the key of the alert, it is unique for the rule and the labels.
+func (a *Alert) Key() string {
+	return SeriesKey(a.Rule, a.Labels)
+}
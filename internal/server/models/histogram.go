@@ -0,0 +1,93 @@
+package models
+
+import "errors"
+
+var (
+	// ErrInvalidHistogram - an error that occurs when the histogram buckets are inconsistent.
+	ErrInvalidHistogram = errors.New("invalid histogram")
+	// ErrBucketsMismatch - an error that occurs when histograms with different buckets are merged.
+	ErrBucketsMismatch = errors.New("histogram buckets mismatch")
+)
+
+// Histogram - data model for histogram metric.
+// Bounds are the upper bounds of the buckets in increasing order, Counts[i] is the number of observations
+// in the bucket with the upper bound Bounds[i], the last element of Counts counts the observations above the last bound.
+// Count is the total number of observations and Sum is their sum.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Count  uint64    `json:"count"`
+	Sum    float64   `json:"sum"`
+}
+
+// NewHistogram creates an empty histogram with the given bucket bounds.
+func NewHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: append([]float64(nil), bounds...),
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds n observations of the value to the histogram.
+func (h *Histogram) Observe(v float64, n uint64) {
+	i := 0
+	for i < len(h.Bounds) && v > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i] += n
+	h.Count += n
+	h.Sum += v * float64(n)
+}
+
+// Validate checks that the bounds are increasing and the counts match the bounds and the total count.
+func (h *Histogram) Validate() error {
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return ErrInvalidHistogram
+	}
+	for i := 1; i < len(h.Bounds); i++ {
+		if h.Bounds[i] <= h.Bounds[i-1] {
+			return ErrInvalidHistogram
+		}
+	}
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != h.Count {
+		return ErrInvalidHistogram
+	}
+	return nil
+}
+
+// Merge adds the observations of the other histogram, both histograms must have the same bounds.
+// An empty histogram takes the bounds of the other one.
+func (h *Histogram) Merge(other Histogram) error {
+	if h.Counts == nil {
+		*h = other.Copy()
+		return nil
+	}
+	if len(h.Bounds) != len(other.Bounds) {
+		return ErrBucketsMismatch
+	}
+	for i := range h.Bounds {
+		if h.Bounds[i] != other.Bounds[i] {
+			return ErrBucketsMismatch
+		}
+	}
+	for i := range h.Counts {
+		h.Counts[i] += other.Counts[i]
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+	return nil
+}
+
+// Copy returns a deep copy of the histogram.
+func (h *Histogram) Copy() Histogram {
+	return Histogram{
+		Bounds: append([]float64(nil), h.Bounds...),
+		Counts: append([]uint64(nil), h.Counts...),
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	h := Histogram{}
+	first := Histogram{Bounds: []float64{1, 5}, Counts: []uint64{1, 2, 0}, Count: 3, Sum: 7}
+	if err := first.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := h.Merge(first); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if err := h.Merge(first); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if h.Count != 6 || h.Sum != 14 || h.Counts[1] != 4 || first.Counts[1] != 2 {
+		t.Errorf("Merge() = %+v, source %+v", h, first)
+	}
+	other := Histogram{Bounds: []float64{1, 10}, Counts: []uint64{0, 0, 0}}
+	if err := h.Merge(other); !errors.Is(err, ErrBucketsMismatch) {
+		t.Errorf("Merge() error = %v, want %v", err, ErrBucketsMismatch)
+	}
+	invalid := []Histogram{
+		{Bounds: []float64{1, 5}, Counts: []uint64{1, 2}, Count: 3},
+		{Bounds: []float64{5, 1}, Counts: []uint64{1, 2, 0}, Count: 3},
+		{Bounds: []float64{1, 5}, Counts: []uint64{1, 2, 0}, Count: 4},
+	}
+	for _, h := range invalid {
+		if err := h.Validate(); !errors.Is(err, ErrInvalidHistogram) {
+			t.Errorf("Validate(%+v) error = %v, want %v", h, err, ErrInvalidHistogram)
+		}
+	}
+}
+
+func TestHistogramObserve(t *testing.T) {
+	h := NewHistogram([]float64{1, 5})
+	h.Observe(0.5, 1)
+	h.Observe(5, 2)
+	h.Observe(10, 1)
+	want := Histogram{Bounds: []float64{1, 5}, Counts: []uint64{1, 2, 1}, Count: 4, Sum: 20.5}
+	if err := h.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("Observe() = %+v, want %+v", h, want)
+	}
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidLabels - an error that occurs when the labels or the series key cannot be used.
+var ErrInvalidLabels = errors.New("invalid labels")
+
+// Labels - a set of label names and values, a series is identified by the metric name together with its labels.
+type Labels map[string]string
+
+// Validate checks that every label name consists of letters, digits and underscores and does not start with a digit.
+func (l Labels) Validate() error {
+	for name := range l {
+		if !validLabelName(name) {
+			return ErrInvalidLabels
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the labels contain every label of the filter with the same value.
+// An empty filter matches any labels.
+func (l Labels) Matches(filter Labels) bool {
+	for name, value := range filter {
+		if v, ok := l[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SeriesKey returns the key of the series - the metric name followed by its labels sorted by name,
+// for example HeapAlloc{host="a",service="b"}. The key of a metric without labels is its name.
+func SeriesKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(labels))
+	for n := range labels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[n]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// ParseSeriesKey splits the series key made by SeriesKey into the metric name and labels.
+func ParseSeriesKey(key string) (string, Labels, error) {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key, nil, nil
+	}
+	if !strings.HasSuffix(key, "}") {
+		return "", nil, ErrInvalidLabels
+	}
+	name := key[:start]
+	rest := key[start+1 : len(key)-1]
+	labels := make(Labels)
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return "", nil, ErrInvalidLabels
+		}
+		label := rest[:eq]
+		quoted, err := strconv.QuotedPrefix(rest[eq+1:])
+		if err != nil {
+			return "", nil, ErrInvalidLabels
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", nil, ErrInvalidLabels
+		}
+		labels[label] = value
+		rest = strings.TrimPrefix(rest[eq+1+len(quoted):], ",")
+	}
+	return name, labels, nil
+}
+
+// LabelsFromQuery collects labels from the URL query parameters, the reserved parameters are skipped.
+func LabelsFromQuery(query url.Values, reserved ...string) Labels {
+	labels := make(Labels)
+	for name, values := range query {
+		if len(values) == 0 || isReserved(name, reserved) {
+			continue
+		}
+		labels[name] = values[0]
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// isReserved - function to check if the name is in the reserved list
+func isReserved(name string, reserved []string) bool {
+	for _, r := range reserved {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validLabelName - function to check the label name
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
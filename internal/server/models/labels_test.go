@@ -0,0 +1,74 @@
+package models
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric string
+		labels Labels
+		want   string
+	}{
+		{
+			name:   "Test 1",
+			metric: "HeapAlloc",
+			want:   "HeapAlloc",
+		},
+		{
+			name:   "Test 2",
+			metric: "HeapAlloc",
+			labels: Labels{"service": "api", "host": "a"},
+			want:   `HeapAlloc{host="a",service="api"}`,
+		},
+		{
+			name:   "Test 3",
+			metric: "HeapAlloc",
+			labels: Labels{"env": `q"u,o=te}`},
+			want:   `HeapAlloc{env="q\"u,o=te}"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.metric, tt.labels)
+			if key != tt.want {
+				t.Errorf("SeriesKey() = %v, want %v", key, tt.want)
+			}
+			name, labels, err := ParseSeriesKey(key)
+			if err != nil {
+				t.Fatalf("ParseSeriesKey() error = %v", err)
+			}
+			if name != tt.metric || len(labels) != len(tt.labels) || !labels.Matches(tt.labels) {
+				t.Errorf("ParseSeriesKey() = %v %v, want %v %v", name, labels, tt.metric, tt.labels)
+			}
+		})
+	}
+}
+
+func TestParseSeriesKeyInvalid(t *testing.T) {
+	for _, key := range []string{`a{b="c"`, `a{b=c}`, `a{="c"}`} {
+		if _, _, err := ParseSeriesKey(key); err == nil {
+			t.Errorf("ParseSeriesKey(%q) expected error", key)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	labels := Labels{"host": "a", "env": "prod"}
+	if !labels.Matches(nil) || !labels.Matches(Labels{"host": "a"}) || labels.Matches(Labels{"host": "b"}) {
+		t.Errorf("Matches() returned unexpected result")
+	}
+	if err := labels.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (Labels{"1host": "a"}).Validate(); err == nil {
+		t.Errorf("Validate() expected error")
+	}
+	query := url.Values{"host": {"a"}, "from": {"0"}}
+	if got := LabelsFromQuery(query, "from", "to"); !reflect.DeepEqual(got, Labels{"host": "a"}) {
+		t.Errorf("LabelsFromQuery() = %v", got)
+	}
+}
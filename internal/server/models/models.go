@@ -1,10 +1,61 @@
 // Package models describes the data model used in the project.
 package models
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidMetric - an error that occurs when the metric has an unknown type, a missing or a negative value.
+var ErrInvalidMetric = errors.New("invalid metric")
+
 // Metric - data model for metric.
 type Metric struct {
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
+	Delta     *int64     `json:"delta,omitempty"`
+	Value     *float64   `json:"value,omitempty"`
+	Histogram *Histogram `json:"histogram,omitempty"`
+	ID        string     `json:"id"`
+	MType     string     `json:"type"`
+	Labels    Labels     `json:"labels,omitempty"`
+}
+
+// Validate checks the metric before it is stored: the name and the type are known,
+// the value of the type is present and not negative, the labels and the histogram are valid.
+func (m *Metric) Validate() error {
+	if m.ID == "" {
+		return ErrInvalidMetric
+	}
+	if err := m.Labels.Validate(); err != nil {
+		return err
+	}
+	switch m.MType {
+	case "gauge":
+		if m.Value == nil || *m.Value < 0 {
+			return ErrInvalidMetric
+		}
+	case "counter":
+		if m.Delta == nil || *m.Delta < 0 {
+			return ErrInvalidMetric
+		}
+	case "histogram":
+		if m.Histogram == nil {
+			return ErrInvalidMetric
+		}
+		return m.Histogram.Validate()
+	default:
+		return ErrInvalidMetric
+	}
+	return nil
+}
+
+// Point - data model for a timestamped metric value in the history.
+// For a counter and a histogram, the point holds the accumulated value at the moment of the update.
+// A point of the downsampled history starts at Timestamp, holds the last value of its interval
+// and the aggregate of all values of the interval.
+type Point struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Delta     *int64     `json:"delta,omitempty"`
+	Value     *float64   `json:"value,omitempty"`
+	Histogram *Histogram `json:"histogram,omitempty"`
+	Aggregate *Aggregate `json:"aggregate,omitempty"`
 }
@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// deadLetterRecord - the line of the dead-letter file, Body is the rendered body if it has been built
+type deadLetterRecord struct {
+	Time     time.Time `json:"time"`
+	Receiver string    `json:"receiver"`
+	URL      string    `json:"url"`
+	Event    Event     `json:"event"`
+	Body     string    `json:"body,omitempty"`
+	Error    string    `json:"error"`
+}
+
+// deadLetter - the file of the undeliverable events, one JSON record per line.
+// Without the path the events are only logged.
+type deadLetter struct {
+	path   string
+	logger *zap.Logger
+	mut    sync.Mutex
+}
+
+// write - method to append the undeliverable event to the file
+func (d *deadLetter) write(r *receiver, event Event, body []byte, reason error) {
+	record := deadLetterRecord{
+		Time:     time.Now(),
+		Receiver: r.name,
+		URL:      r.url,
+		Event:    event,
+		Body:     string(body),
+	}
+	if reason != nil {
+		record.Error = reason.Error()
+	}
+	d.logger.Warn("could not deliver event",
+		zap.String("receiver", r.name), zap.String("event", event.Type), zap.String("error", record.Error))
+	if d.path == "" {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		d.logger.Error("could not marshal dead-letter record", zap.Error(err))
+		return
+	}
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	file, err := os.OpenFile(d.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		d.logger.Error("could not open dead-letter file", zap.Error(err))
+		return
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		d.logger.Error("could not write dead-letter file", zap.Error(err))
+	}
+	if err := file.Close(); err != nil {
+		d.logger.Error("could not close dead-letter file", zap.Error(err))
+	}
+}
@@ -0,0 +1,285 @@
+// Package notifier sends the events of the server to the webhook receivers.
+// An event is an alert that has fired or resolved or a change of a watched metric,
+// it is posted to every receiver subscribed to its type as JSON or as the body rendered by the receiver template.
+// The body is signed with HMAC-SHA256 of the server key in the HashSHA256 header, as the server responses are.
+// A failed delivery is retried with the exponential backoff, the events that can not be delivered
+// are appended to the dead-letter file.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+const (
+	// EventAlertFiring - the type of the event of the alert that has fired
+	EventAlertFiring = "alert_firing"
+	// EventAlertResolved - the type of the event of the alert that has resolved
+	EventAlertResolved = "alert_resolved"
+	// EventMetricChanged - the type of the event of the watched metric whose value has changed
+	EventMetricChanged = "metric_changed"
+)
+
+const (
+	// defaultQueueSize - the number of the events queued for a receiver if it is not set
+	defaultQueueSize = 100
+	// defaultTimeout - the timeout of a delivery attempt if it is not set
+	defaultTimeout = 5 * time.Second
+	// defaultBackoff - the wait before the first retry if it is not set
+	defaultBackoff = time.Second
+	// defaultMaxBackoff - the longest wait between the retries if it is not set
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// errQueueFull - an error that occurs when the queue of the receiver has no room for the event.
+var errQueueFull = errors.New("the queue of the receiver is full")
+
+// errStopped - an error that occurs when the event comes after the notifier has stopped.
+var errStopped = errors.New("the notifier is stopped")
+
+// Event - the event sent to the receivers.
+type Event struct {
+	Type   string        `json:"type"`
+	Time   time.Time     `json:"time"`
+	Alert  *models.Alert `json:"alert,omitempty"`
+	Metric *MetricChange `json:"metric,omitempty"`
+}
+
+// MetricChange - the change of the watched metric, Old is missing for a new gauge.
+type MetricChange struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Labels models.Labels `json:"labels,omitempty"`
+	Old    *float64      `json:"old,omitempty"`
+	Value  float64       `json:"value"`
+}
+
+// Notifier - the sender of the events to the webhook receivers, every receiver has its own queue.
+type Notifier struct {
+	logger     *zap.Logger
+	client     *http.Client
+	key        string
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	receivers  []*receiver
+	deadLetter *deadLetter
+
+	mut     sync.RWMutex
+	stopped bool
+}
+
+// New creates a new instance of Notifier with the receivers of the configuration,
+// the bodies are signed with the key if it is not empty.
+// It returns an error if a receiver is inconsistent or its template can not be parsed.
+func New(conf config.NotifierConfig, key string, logger *zap.Logger) (*Notifier, error) {
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultQueueSize
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+	if conf.Retries < 0 {
+		conf.Retries = 0
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = defaultBackoff
+	}
+	if conf.MaxBackoff < conf.Backoff {
+		conf.MaxBackoff = defaultMaxBackoff
+		if conf.MaxBackoff < conf.Backoff {
+			conf.MaxBackoff = conf.Backoff
+		}
+	}
+	n := &Notifier{
+		logger:     logger,
+		client:     &http.Client{Timeout: conf.Timeout},
+		key:        key,
+		retries:    conf.Retries,
+		backoff:    conf.Backoff,
+		maxBackoff: conf.MaxBackoff,
+		deadLetter: &deadLetter{path: conf.DeadLetterFile, logger: logger},
+	}
+	names := make(map[string]bool, len(conf.Receivers))
+	for _, c := range conf.Receivers {
+		r, err := newReceiver(c, conf.QueueSize)
+		if err != nil {
+			return nil, err
+		}
+		if names[r.name] {
+			return nil, fmt.Errorf("%w: the name %q is repeated", ErrInvalidReceiver, r.name)
+		}
+		names[r.name] = true
+		n.receivers = append(n.receivers, r)
+	}
+	return n, nil
+}
+
+// Notify queues the event for every receiver subscribed to it, the event is written to the dead-letter file
+// if the queue of the receiver is full or the notifier has stopped.
+func (n *Notifier) Notify(event Event) {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	for _, r := range n.receivers {
+		if !r.accepts(event) {
+			continue
+		}
+		if n.stopped {
+			n.deadLetter.write(r, event, nil, errStopped)
+			continue
+		}
+		select {
+		case r.queue <- event:
+		default:
+			n.deadLetter.write(r, event, nil, errQueueFull)
+		}
+	}
+}
+
+// AlertChanged sends the event of the alert that has fired or resolved.
+func (n *Notifier) AlertChanged(alert models.Alert) {
+	event := Event{Type: EventAlertFiring, Time: alert.ActiveAt, Alert: &alert}
+	if alert.FiredAt != nil {
+		event.Time = *alert.FiredAt
+	}
+	if alert.State == models.AlertResolved {
+		event.Type = EventAlertResolved
+		if alert.ResolvedAt != nil {
+			event.Time = *alert.ResolvedAt
+		}
+	}
+	n.Notify(event)
+}
+
+// Watches reports whether a change of the metric is sent to any receiver.
+func (n *Notifier) Watches(name string) bool {
+	for _, r := range n.receivers {
+		if r.watches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watching reports whether any receiver is sent the changes of the metrics.
+func (n *Notifier) Watching() bool {
+	for _, r := range n.receivers {
+		if len(r.metrics) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Run delivers the queued events until the context is done,
+// then the events left in the queues and the events that come later are written to the dead-letter file.
+func (n *Notifier) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range n.receivers {
+		wg.Add(1)
+		go func(r *receiver) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-r.queue:
+					n.deliver(ctx, r, event)
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	n.mut.Lock()
+	n.stopped = true
+	n.mut.Unlock()
+	for _, r := range n.receivers {
+		for len(r.queue) > 0 {
+			n.deadLetter.write(r, <-r.queue, nil, ctx.Err())
+		}
+	}
+}
+
+// deliver - method to post the event to the receiver retrying with the backoff,
+// the event is written to the dead-letter file if all attempts fail or the receiver rejects it
+func (n *Notifier) deliver(ctx context.Context, r *receiver, event Event) {
+	body, err := r.render(event)
+	if err != nil {
+		n.deadLetter.write(r, event, nil, err)
+		return
+	}
+	wait := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, r, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= n.retries {
+			n.deadLetter.write(r, event, body, err)
+			return
+		}
+		n.logger.Info("could not deliver event, retrying",
+			zap.String("receiver", r.name), zap.Duration("backoff", wait), zap.Error(err))
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			n.deadLetter.write(r, event, body, ctx.Err())
+			return
+		case <-t.C:
+		}
+		wait *= 2
+		if wait > n.maxBackoff {
+			wait = n.maxBackoff
+		}
+	}
+}
+
+// post - method to send the body to the receiver, it reports whether the failed request may be retried:
+// the connection errors, 429 and 5xx responses are retried, the other responses are rejections
+func (n *Notifier) post(ctx context.Context, r *receiver, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+	if n.key != "" {
+		req.Header.Set("HashSHA256", Sign(n.key, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("the receiver responded %s", resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Sign returns the hex HMAC-SHA256 of the body with the key, it is sent in the HashSHA256 header.
+func Sign(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,304 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+)
+
+// request - the request received by the test receiver
+type request struct {
+	body []byte
+	hash string
+}
+
+// newTestReceiver - function to start the test receiver that responds with the statuses in turn,
+// the last status is repeated
+func newTestReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan request, *int32) {
+	requests := make(chan request, 10)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := int(atomic.AddInt32(&calls, 1))
+		status := statuses[len(statuses)-1]
+		if n <= len(statuses) {
+			status = statuses[n-1]
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			requests <- request{body: body, hash: r.Header.Get("HashSHA256")}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests, &calls
+}
+
+// start - function to run the notifier until the test ends
+func start(t *testing.T, n *Notifier) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// receive - function to wait for the request delivered to the test receiver
+func receive(t *testing.T, requests chan request) request {
+	select {
+	case r := <-requests:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event was not delivered")
+	}
+	return request{}
+}
+
+// readDeadLetter - function to wait for the records of the dead-letter file
+func readDeadLetter(t *testing.T, path string, want int) []deadLetterRecord {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		var records []deadLetterRecord
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var record deadLetterRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		if len(records) >= want || time.Now().After(deadline) {
+			return records
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func firingAlert() models.Alert {
+	fired := time.Date(2024, 1, 10, 12, 5, 0, 0, time.UTC)
+	return models.Alert{
+		Rule:     "LowMemory",
+		Labels:   models.Labels{"host": "a"},
+		Severity: "critical",
+		State:    models.AlertFiring,
+		Value:    1000,
+		ActiveAt: fired.Add(-5 * time.Minute),
+		FiredAt:  &fired,
+	}
+}
+
+func TestNotifierDeliver(t *testing.T) {
+	srv, requests, _ := newTestReceiver(t, http.StatusOK)
+	n, err := New(config.NotifierConfig{Receivers: []config.ReceiverConfig{
+		{Name: "json", URL: srv.URL},
+	}}, "secret", zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start(t, n)
+
+	n.AlertChanged(firingAlert())
+	r := receive(t, requests)
+	if r.hash != Sign("secret", r.body) {
+		t.Errorf("HashSHA256 = %q, want the HMAC of the body", r.hash)
+	}
+	var event Event
+	if err := json.Unmarshal(r.body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventAlertFiring || event.Alert == nil || event.Alert.Rule != "LowMemory" || !event.Time.Equal(*event.Alert.FiredAt) {
+		t.Errorf("event = %+v, want the firing alert", event)
+	}
+}
+
+func TestNotifierTemplate(t *testing.T) {
+	srv, requests, _ := newTestReceiver(t, http.StatusOK)
+	n, err := New(config.NotifierConfig{Receivers: []config.ReceiverConfig{{
+		Name:     "chat",
+		URL:      srv.URL,
+		Events:   []string{EventAlertResolved},
+		Template: `{"text":"{{.Alert.Rule}} is {{.Alert.State}}","labels":{{json .Alert.Labels}}}`,
+	}}}, "", zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start(t, n)
+
+	// the receiver is not subscribed to the firing alerts
+	n.AlertChanged(firingAlert())
+	resolved := firingAlert()
+	resolved.State = models.AlertResolved
+	n.AlertChanged(resolved)
+
+	r := receive(t, requests)
+	if want := `{"text":"LowMemory is resolved","labels":{"host":"a"}}`; string(r.body) != want {
+		t.Errorf("body = %s, want %s", r.body, want)
+	}
+	if r.hash != "" {
+		t.Errorf("HashSHA256 = %q, want no signature without the key", r.hash)
+	}
+}
+
+func TestNotifierRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		statuses       []int
+		retries        int
+		wantCalls      int32
+		wantDeadLetter bool
+	}{
+		{name: "Test 1", statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}, retries: 3, wantCalls: 3},
+		{name: "Test 2", statuses: []int{http.StatusBadGateway}, retries: 2, wantCalls: 3, wantDeadLetter: true},
+		{name: "Test 3", statuses: []int{http.StatusBadRequest}, retries: 3, wantCalls: 1, wantDeadLetter: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests, calls := newTestReceiver(t, tt.statuses...)
+			deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+			n, err := New(config.NotifierConfig{
+				Receivers:      []config.ReceiverConfig{{Name: "hook", URL: srv.URL}},
+				Retries:        tt.retries,
+				Backoff:        time.Millisecond,
+				MaxBackoff:     2 * time.Millisecond,
+				DeadLetterFile: deadLetterPath,
+			}, "", zaptest.NewLogger(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			start(t, n)
+			n.AlertChanged(firingAlert())
+
+			wantRecords := 1
+			if !tt.wantDeadLetter {
+				receive(t, requests)
+				wantRecords = 0
+			}
+			records := readDeadLetter(t, deadLetterPath, wantRecords)
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("the receiver was called %d times, want %d", got, tt.wantCalls)
+			}
+			if !tt.wantDeadLetter {
+				if len(records) != 0 {
+					t.Errorf("dead-letter records = %+v, want none", records)
+				}
+				return
+			}
+			if len(records) != 1 || records[0].Receiver != "hook" || records[0].Event.Type != EventAlertFiring || records[0].Error == "" {
+				t.Errorf("dead-letter records = %+v, want the firing alert", records)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		receiver config.ReceiverConfig
+	}{
+		{name: "Test 1", receiver: config.ReceiverConfig{URL: "http://localhost/hook"}},
+		{name: "Test 2", receiver: config.ReceiverConfig{Name: "a", URL: "localhost/hook"}},
+		{name: "Test 3", receiver: config.ReceiverConfig{Name: "a", URL: "http://localhost/hook", Events: []string{"alert_pending"}}},
+		{name: "Test 4", receiver: config.ReceiverConfig{Name: "a", URL: "http://localhost/hook", Events: []string{EventMetricChanged}}},
+		{name: "Test 5", receiver: config.ReceiverConfig{Name: "a", URL: "http://localhost/hook", Metrics: []string{"[a"}}},
+		{name: "Test 6", receiver: config.ReceiverConfig{Name: "a", URL: "http://localhost/hook", Template: "{{.Alert"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(config.NotifierConfig{Receivers: []config.ReceiverConfig{tt.receiver}}, "", zaptest.NewLogger(t))
+			if !errors.Is(err, ErrInvalidReceiver) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidReceiver)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	srv, requests, _ := newTestReceiver(t, http.StatusOK)
+	n, err := New(config.NotifierConfig{Receivers: []config.ReceiverConfig{
+		{Name: "metrics", URL: srv.URL, Events: []string{EventMetricChanged}, Metrics: []string{"Free*", "PollCount"}},
+	}}, "", zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start(t, n)
+	db := Watch(inmemorystorage.NewMemStorage(zaptest.NewLogger(t), 10), n)
+
+	labels := models.Labels{"host": "a"}
+	steps := []func() error{
+		func() error { return db.SetGauge(ctx, "FreeMemory", labels, 100) },
+		// the unchanged gauge and the metric that is not watched are not sent
+		func() error { return db.SetGauge(ctx, "FreeMemory", labels, 100) },
+		func() error { return db.SetGauge(ctx, "HeapInuse", labels, 5) },
+		func() error { return db.SetGauge(ctx, "FreeMemory", labels, 50) },
+		func() error {
+			delta := int64(3)
+			return db.SetMetrics(ctx, []models.Metric{{ID: "PollCount", MType: "counter", Delta: &delta}})
+		},
+		// the memory storage returns the counter value from the increment
+		func() error { return db.SetCounter(ctx, "PollCount", nil, 2) },
+		// the increments of one counter in the batch are sent as one change
+		func() error {
+			delta := int64(1)
+			return db.SetMetrics(ctx, []models.Metric{
+				{ID: "PollCount", MType: "counter", Delta: &delta},
+				{ID: "PollCount", MType: "counter", Delta: &delta},
+			})
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		`{"name":"FreeMemory","type":"gauge","labels":{"host":"a"},"value":100}`,
+		`{"name":"FreeMemory","type":"gauge","labels":{"host":"a"},"old":100,"value":50}`,
+		`{"name":"PollCount","type":"counter","old":0,"value":3}`,
+		`{"name":"PollCount","type":"counter","old":3,"value":5}`,
+		`{"name":"PollCount","type":"counter","old":5,"value":7}`,
+	}
+	for _, w := range want {
+		var event struct {
+			Type   string          `json:"type"`
+			Metric json.RawMessage `json:"metric"`
+		}
+		if err := json.Unmarshal(receive(t, requests).body, &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != EventMetricChanged || string(event.Metric) != w {
+			t.Errorf("event %s %s, want %s", event.Type, event.Metric, w)
+		}
+	}
+	select {
+	case r := <-requests:
+		t.Errorf("unexpected event %s", r.body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
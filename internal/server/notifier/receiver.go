@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"text/template"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+)
+
+// ErrInvalidReceiver - an error that occurs when the receiver configuration is inconsistent.
+var ErrInvalidReceiver = errors.New("invalid notification receiver")
+
+// eventTypes - the known types of the events
+var eventTypes = map[string]bool{EventAlertFiring: true, EventAlertResolved: true, EventMetricChanged: true}
+
+// templateFuncs - the functions of the receiver templates, json marshals the value
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// receiver - the webhook receiver and the queue of its events
+type receiver struct {
+	name     string
+	url      string
+	events   map[string]bool
+	metrics  []string
+	template *template.Template
+	headers  map[string]string
+	queue    chan Event
+}
+
+// newReceiver - function to check the receiver configuration and parse its template.
+// Without the events, the receiver gets the alert events and the metric changes if it watches the metrics.
+func newReceiver(c config.ReceiverConfig, queueSize int) (*receiver, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("%w: the name is missing", ErrInvalidReceiver)
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s: the url %q is not an http url", ErrInvalidReceiver, c.Name, c.URL)
+	}
+	for _, pattern := range c.Metrics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %s: the metric pattern %q: %v", ErrInvalidReceiver, c.Name, pattern, err)
+		}
+	}
+	r := &receiver{
+		name:    c.Name,
+		url:     c.URL,
+		events:  make(map[string]bool),
+		metrics: c.Metrics,
+		headers: c.Headers,
+		queue:   make(chan Event, queueSize),
+	}
+	events := c.Events
+	if len(events) == 0 {
+		events = []string{EventAlertFiring, EventAlertResolved}
+		if len(c.Metrics) > 0 {
+			events = append(events, EventMetricChanged)
+		}
+	}
+	for _, e := range events {
+		if !eventTypes[e] {
+			return nil, fmt.Errorf("%w: %s: unknown event %q", ErrInvalidReceiver, c.Name, e)
+		}
+		r.events[e] = true
+	}
+	if r.events[EventMetricChanged] != (len(c.Metrics) > 0) {
+		return nil, fmt.Errorf("%w: %s: the metric changes need the metric patterns", ErrInvalidReceiver, c.Name)
+	}
+	if c.Template != "" {
+		r.template, err = template.New(c.Name).Funcs(templateFuncs).Parse(c.Template)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidReceiver, c.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// accepts - method to check whether the receiver is subscribed to the event
+func (r *receiver) accepts(event Event) bool {
+	if !r.events[event.Type] {
+		return false
+	}
+	return event.Metric == nil || r.watches(event.Metric.Name)
+}
+
+// watches - method to check whether the changes of the metric are sent to the receiver
+func (r *receiver) watches(name string) bool {
+	if !r.events[EventMetricChanged] {
+		return false
+	}
+	for _, pattern := range r.metrics {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// render - method to build the request body of the event, the event is marshaled to JSON without the template
+func (r *receiver) render(event Event) ([]byte, error) {
+	if r.template == nil {
+		return json.Marshal(event)
+	}
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
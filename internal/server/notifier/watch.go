@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// WatchStorage - the storage decorator that sends the changes of the watched counters and gauges to the notifier.
+// A gauge changes when its value differs from the stored one, a counter changes with every non-zero increment.
+// The counter values are exact if the storage returns them from the increment (storage.CounterAdder),
+// otherwise they are read back after the update and the concurrent updates of the series may be included.
+// The old values of the gauges are read before the update, so they are approximate under concurrent updates as well.
+type WatchStorage struct {
+	storage.Storage
+	notifier *Notifier
+}
+
+// Watch returns the storage that sends the changes of the watched metrics to the notifier,
+// it returns the storage itself if no receiver watches the metrics.
+func Watch(db storage.Storage, n *Notifier) storage.Storage {
+	if !n.Watching() {
+		return db
+	}
+	return &WatchStorage{Storage: db, notifier: n}
+}
+
+// SetCounter increments the counter and sends its change.
+func (w *WatchStorage) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	if adder, ok := w.Storage.(storage.CounterAdder); ok && value != 0 && w.notifier.Watches(name) {
+		return w.addCounter(ctx, adder, name, labels, value, time.Now())
+	}
+	if err := w.Storage.SetCounter(ctx, name, labels, value); err != nil {
+		return err
+	}
+	w.counterChanged(ctx, name, labels, value, time.Now())
+	return nil
+}
+
+// SetCounterAt increments the counter at the time and sends its change.
+func (w *WatchStorage) SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error {
+	if adder, ok := w.Storage.(storage.CounterAdder); ok && value != 0 && w.notifier.Watches(name) {
+		return w.addCounter(ctx, adder, name, labels, value, ts)
+	}
+	if err := w.Storage.SetCounterAt(ctx, name, labels, value, ts); err != nil {
+		return err
+	}
+	w.counterChanged(ctx, name, labels, value, ts)
+	return nil
+}
+
+// addCounter - method to increment the counter by the storage that returns its value and send the exact change
+func (w *WatchStorage) addCounter(ctx context.Context, adder storage.CounterAdder, name string, labels models.Labels, value int64, ts time.Time) error {
+	total, err := adder.AddCounterAt(ctx, name, labels, value, ts)
+	if err != nil {
+		return err
+	}
+	w.notifyCounter(name, labels, total-value, total, ts)
+	return nil
+}
+
+// SetGauge sets the gauge and sends its change.
+func (w *WatchStorage) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	old := w.gauge(ctx, name, labels)
+	if err := w.Storage.SetGauge(ctx, name, labels, value); err != nil {
+		return err
+	}
+	w.gaugeChanged(name, labels, old, value, time.Now())
+	return nil
+}
+
+// SetGaugeAt sets the gauge at the time and sends its change.
+func (w *WatchStorage) SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error {
+	old := w.gauge(ctx, name, labels)
+	if err := w.Storage.SetGaugeAt(ctx, name, labels, value, ts); err != nil {
+		return err
+	}
+	w.gaugeChanged(name, labels, old, value, ts)
+	return nil
+}
+
+// SetMetrics stores the metrics and sends the changes of the watched ones.
+func (w *WatchStorage) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	olds := make(map[int]*float64)
+	for i, m := range metrics {
+		if m.MType == "gauge" {
+			olds[i] = w.gauge(ctx, m.ID, m.Labels)
+		}
+	}
+	if err := w.Storage.SetMetrics(ctx, metrics); err != nil {
+		return err
+	}
+	now := time.Now()
+	// the increments of one counter in the batch are sent as one change
+	deltas := make(map[string]int64)
+	for _, m := range metrics {
+		if m.MType == "counter" && m.Delta != nil {
+			deltas[models.SeriesKey(m.ID, m.Labels)] += *m.Delta
+		}
+	}
+	for i, m := range metrics {
+		switch {
+		case m.MType == "gauge" && m.Value != nil:
+			w.gaugeChanged(m.ID, m.Labels, olds[i], *m.Value, now)
+		case m.MType == "counter" && m.Delta != nil:
+			key := models.SeriesKey(m.ID, m.Labels)
+			if delta, ok := deltas[key]; ok {
+				delete(deltas, key)
+				w.counterChanged(ctx, m.ID, m.Labels, delta, now)
+			}
+		}
+	}
+	return nil
+}
+
+// gauge - method to read the stored value of the watched gauge, it is missing for a new series
+func (w *WatchStorage) gauge(ctx context.Context, name string, labels models.Labels) *float64 {
+	if !w.notifier.Watches(name) {
+		return nil
+	}
+	v, err := w.Storage.GetGauge(ctx, name, labels)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// gaugeChanged - method to send the change of the watched gauge if its value differs from the stored one
+func (w *WatchStorage) gaugeChanged(name string, labels models.Labels, old *float64, value float64, ts time.Time) {
+	if !w.notifier.Watches(name) || (old != nil && *old == value) {
+		return
+	}
+	w.notifier.Notify(Event{
+		Type:   EventMetricChanged,
+		Time:   ts,
+		Metric: &MetricChange{Name: name, Type: "gauge", Labels: labels, Old: old, Value: value},
+	})
+}
+
+// counterChanged - method to send the change of the watched counter by the non-zero increment,
+// the value is read back after the update, so the concurrent increments of the series may be included in it
+func (w *WatchStorage) counterChanged(ctx context.Context, name string, labels models.Labels, delta int64, ts time.Time) {
+	if delta == 0 || !w.notifier.Watches(name) {
+		return
+	}
+	value, err := w.Storage.GetCounter(ctx, name, labels)
+	if err != nil {
+		return
+	}
+	w.notifyCounter(name, labels, value-delta, value, ts)
+}
+
+// notifyCounter - method to send the change of the counter
+func (w *WatchStorage) notifyCounter(name string, labels models.Labels, old, value int64, ts time.Time) {
+	oldValue := float64(old)
+	w.notifier.Notify(Event{
+		Type:   EventMetricChanged,
+		Time:   ts,
+		Metric: &MetricChange{Name: name, Type: "counter", Labels: labels, Old: &oldValue, Value: float64(value)},
+	})
+}
@@ -0,0 +1,387 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+// value - the intermediate value of the evaluation, a scalar or a vector
+type value struct {
+	scalar   float64
+	vector   []Sample
+	isVector bool
+}
+
+// series - the series matched by the selector
+type series struct {
+	name   string
+	labels models.Labels
+	mType  string
+	value  float64
+}
+
+// eval - method to evaluate the number literal
+func (n *numberExpr) eval(_ context.Context, _ *Engine) (value, error) {
+	return value{scalar: n.value}, nil
+}
+
+// eval - method to evaluate the selector into the vector of the current values
+func (s *selectorExpr) eval(ctx context.Context, e *Engine) (value, error) {
+	matched, err := e.selectSeries(ctx, s)
+	if err != nil {
+		return value{}, err
+	}
+	samples := make([]Sample, 0, len(matched))
+	for _, m := range matched {
+		samples = append(samples, Sample{Name: m.name, Labels: m.labels, Value: m.value})
+	}
+	return value{vector: samples, isVector: true}, nil
+}
+
+// matches - method to check the metric name and the labels of the series against the selector
+func (s *selectorExpr) matches(name string, labels models.Labels) bool {
+	if ok, _ := path.Match(s.pattern, name); !ok {
+		return false
+	}
+	for _, m := range s.matchers {
+		if (labels[m.name] == m.value) == m.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// selectSeries - method to read the counters and the gauges matched by the selector,
+// the equality matchers are passed to the storage as the filter
+func (e *Engine) selectSeries(ctx context.Context, s *selectorExpr) ([]series, error) {
+	var filter models.Labels
+	for _, m := range s.matchers {
+		// a missing label matches the empty value, so it can not be filtered by the storage
+		if m.negate || m.value == "" {
+			continue
+		}
+		if filter == nil {
+			filter = models.Labels{}
+		}
+		filter[m.name] = m.value
+	}
+	counters, err := e.db.GetCounters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	gauges, err := e.db.GetGauges(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var result []series
+	add := func(key, mType string, v float64) error {
+		name, labels, err := models.ParseSeriesKey(key)
+		if err != nil {
+			return err
+		}
+		if s.matches(name, labels) {
+			result = append(result, series{name: name, labels: labels, mType: mType, value: v})
+		}
+		return nil
+	}
+	for key, v := range counters {
+		if err := add(key, "counter", float64(v)); err != nil {
+			return nil, err
+		}
+	}
+	for key, v := range gauges {
+		if err := add(key, "gauge", v); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ki, kj := models.SeriesKey(result[i].name, result[i].labels), models.SeriesKey(result[j].name, result[j].labels)
+		if ki != kj {
+			return ki < kj
+		}
+		return result[i].mType < result[j].mType
+	})
+	return result, nil
+}
+
+// eval - method to evaluate the function over the history of the matched series,
+// rate and increase take the counters, the other functions take the gauges
+func (c *callExpr) eval(ctx context.Context, e *Engine) (value, error) {
+	mType := "gauge"
+	if c.fn == "rate" || c.fn == "increase" {
+		mType = "counter"
+	}
+	matched, err := e.selectSeries(ctx, c.arg)
+	if err != nil {
+		return value{}, err
+	}
+	var samples []Sample
+	for _, m := range matched {
+		if m.mType != mType {
+			continue
+		}
+		points, err := e.db.GetHistory(ctx, mType, m.name, m.labels, e.now.Add(-c.arg.window), e.now)
+		if errors.Is(err, servererrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return value{}, err
+		}
+		v, ok := apply(c.fn, points, c.arg.window.Seconds())
+		if !ok {
+			continue
+		}
+		samples = append(samples, Sample{Labels: m.labels, Value: v})
+	}
+	return value{vector: samples, isVector: true}, nil
+}
+
+// apply - function to compute the function over the points, it reports false if the points are not enough
+func apply(fn string, points []models.Point, seconds float64) (float64, bool) {
+	switch fn {
+	case "rate":
+		v, ok := increase(points)
+		return v / seconds, ok
+	case "increase":
+		return increase(points)
+	}
+	if len(points) == 0 {
+		return 0, false
+	}
+	total := models.Aggregate{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, p := range points {
+		agg := models.NewAggregate(pointValue(p))
+		if p.Aggregate != nil {
+			agg = *p.Aggregate
+		}
+		total.Min = math.Min(total.Min, agg.Min)
+		total.Max = math.Max(total.Max, agg.Max)
+		total.Sum += agg.Sum
+		total.Count += agg.Count
+	}
+	switch fn {
+	case "min_over_time":
+		return total.Min, true
+	case "max_over_time":
+		return total.Max, true
+	case "sum_over_time":
+		return total.Sum, true
+	}
+	return total.Sum / float64(total.Count), total.Count > 0
+}
+
+// increase - function to compute the growth of the counter over the points, a decrease is taken as a reset.
+// The growth is not extrapolated to the edges of the range.
+func increase(points []models.Point) (float64, bool) {
+	if len(points) < 2 {
+		return 0, false
+	}
+	var total float64
+	prev := pointValue(points[0])
+	for _, p := range points[1:] {
+		v := pointValue(p)
+		if v < prev {
+			total += v
+		} else {
+			total += v - prev
+		}
+		prev = v
+	}
+	return total, true
+}
+
+// pointValue - function to get the value of the counter or gauge point
+func pointValue(p models.Point) float64 {
+	switch {
+	case p.Delta != nil:
+		return float64(*p.Delta)
+	case p.Value != nil:
+		return *p.Value
+	}
+	return 0
+}
+
+// group - the accumulated values of the aggregation group
+type group struct {
+	labels   models.Labels
+	sum      float64
+	min, max float64
+	count    int
+}
+
+// eval - method to evaluate the aggregation, the result keeps only the labels of the by clause
+func (a *aggregateExpr) eval(ctx context.Context, e *Engine) (value, error) {
+	v, err := a.arg.eval(ctx, e)
+	if err != nil {
+		return value{}, err
+	}
+	if !v.isVector {
+		return value{}, fmt.Errorf("%w: %s needs a vector", ErrInvalidQuery, a.op)
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for _, s := range v.vector {
+		labels := models.Labels{}
+		for _, name := range a.by {
+			if l, ok := s.Labels[name]; ok {
+				labels[name] = l
+			}
+		}
+		key := models.SeriesKey("", labels)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels, min: s.Value, max: s.Value}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.sum += s.Value
+		g.min = math.Min(g.min, s.Value)
+		g.max = math.Max(g.max, s.Value)
+		g.count++
+	}
+	sort.Strings(keys)
+	samples := make([]Sample, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		s := Sample{Labels: g.labels}
+		if len(g.labels) == 0 {
+			s.Labels = nil
+		}
+		switch a.op {
+		case "sum":
+			s.Value = g.sum
+		case "avg":
+			s.Value = g.sum / float64(g.count)
+		case "min":
+			s.Value = g.min
+		case "max":
+			s.Value = g.max
+		case "count":
+			s.Value = float64(g.count)
+		}
+		samples = append(samples, s)
+	}
+	return value{vector: samples, isVector: true}, nil
+}
+
+// eval - method to evaluate the arithmetic or the comparison, the vectors are matched by the labels.
+// The arithmetic drops the metric names, the series without a match and the non-finite results.
+// The comparison of the scalars returns 1 or 0, the comparison with a vector keeps the series of the vector
+// that satisfy it with their values.
+func (b *binaryExpr) eval(ctx context.Context, e *Engine) (value, error) {
+	lhs, err := b.lhs.eval(ctx, e)
+	if err != nil {
+		return value{}, err
+	}
+	rhs, err := b.rhs.eval(ctx, e)
+	if err != nil {
+		return value{}, err
+	}
+	switch {
+	case !lhs.isVector && !rhs.isVector:
+		if comparisons[b.op] {
+			if compare(b.op, lhs.scalar, rhs.scalar) {
+				return value{scalar: 1}, nil
+			}
+			return value{scalar: 0}, nil
+		}
+		return value{scalar: arithmetic(b.op, lhs.scalar, rhs.scalar)}, nil
+	case !rhs.isVector:
+		return value{vector: b.combine(lhs.vector, func(v float64) (float64, bool) {
+			return b.operate(v, rhs.scalar, v)
+		}), isVector: true}, nil
+	case !lhs.isVector:
+		return value{vector: b.combine(rhs.vector, func(v float64) (float64, bool) {
+			return b.operate(lhs.scalar, v, v)
+		}), isVector: true}, nil
+	}
+	right := make(map[string]float64, len(rhs.vector))
+	for _, s := range rhs.vector {
+		key := models.SeriesKey("", s.Labels)
+		if _, ok := right[key]; ok {
+			return value{}, fmt.Errorf("%w: many series with labels %s on the right side of %s", ErrInvalidQuery, key, b.op)
+		}
+		right[key] = s.Value
+	}
+	seen := make(map[string]bool, len(lhs.vector))
+	var samples []Sample
+	for _, s := range lhs.vector {
+		key := models.SeriesKey("", s.Labels)
+		if seen[key] {
+			return value{}, fmt.Errorf("%w: many series with labels %s on the left side of %s", ErrInvalidQuery, key, b.op)
+		}
+		seen[key] = true
+		r, ok := right[key]
+		if !ok {
+			continue
+		}
+		samples = append(samples, b.combine([]Sample{s}, func(v float64) (float64, bool) {
+			return b.operate(v, r, v)
+		})...)
+	}
+	return value{vector: samples, isVector: true}, nil
+}
+
+// operate - method to apply the operator to the operands, it returns the value of the series and whether it is kept.
+// The comparison keeps the value of the vector side, kept is its result.
+func (b *binaryExpr) operate(l, r, kept float64) (float64, bool) {
+	if comparisons[b.op] {
+		return kept, compare(b.op, l, r)
+	}
+	v := arithmetic(b.op, l, r)
+	return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// combine - method to apply the operation to the samples, the arithmetic drops the metric names
+func (b *binaryExpr) combine(samples []Sample, op func(float64) (float64, bool)) []Sample {
+	result := make([]Sample, 0, len(samples))
+	for _, s := range samples {
+		v, ok := op(s.Value)
+		if !ok {
+			continue
+		}
+		if comparisons[b.op] {
+			result = append(result, s)
+			continue
+		}
+		result = append(result, Sample{Labels: s.Labels, Value: v})
+	}
+	return result
+}
+
+// arithmetic - function to apply the arithmetic operator to the operands
+func arithmetic(op string, l, r float64) float64 {
+	switch op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	}
+	return l / r
+}
+
+// compare - function to apply the comparison operator to the operands
+func compare(op string, l, r float64) bool {
+	switch op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	}
+	return l >= r
+}
@@ -0,0 +1,406 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// tokenKind - the kind of the lexical token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenString
+	tokenDuration
+	tokenPunct
+)
+
+// token - the lexical token and its position in the expression
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// isNameStart - function to check whether the character can start the metric name pattern
+func isNameStart(c rune) bool {
+	return c == '_' || c == ':' || unicode.IsLetter(c)
+}
+
+// isNameChar - function to check whether the character can continue the metric name pattern,
+// so the glob characters follow the name and a multiplication after the name needs a space
+func isNameChar(c rune) bool {
+	return isNameStart(c) || c == '.' || c == '*' || c == '?' || unicode.IsDigit(c)
+}
+
+// lex - function to split the expression into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case isNameStart(c):
+			start := i
+			for i < len(runes) && isNameChar(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+			}
+			i++
+			raw := string(runes[start:i])
+			if c == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad string at %d", ErrSyntax, start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: start})
+		case c == '[':
+			start := i
+			for i < len(runes) && runes[i] != ']' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated range at %d", ErrSyntax, start)
+			}
+			i++
+			text := strings.TrimSpace(string(runes[start+1 : i-1]))
+			tokens = append(tokens, token{kind: tokenDuration, text: text, pos: start})
+		case strings.ContainsRune("!<>=", c) && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, token{kind: tokenPunct, text: string(runes[i : i+2]), pos: i})
+			i += 2
+		case strings.ContainsRune("(){},=+-*/<>", c):
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q at %d", ErrSyntax, c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// The functions over the range of the history.
+var functions = map[string]bool{
+	"rate":          true,
+	"increase":      true,
+	"avg_over_time": true,
+	"min_over_time": true,
+	"max_over_time": true,
+	"sum_over_time": true,
+}
+
+// The aggregation operators.
+var aggregations = map[string]bool{
+	"sum":   true,
+	"avg":   true,
+	"min":   true,
+	"max":   true,
+	"count": true,
+}
+
+// The comparison operators.
+var comparisons = map[string]bool{
+	"==": true,
+	"!=": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+}
+
+// parser - the recursive descent parser of the expression
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses the expression.
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return expr, nil
+}
+
+// peek - method to get the current token
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next - method to get the current token and move to the next one
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isPunct - method to check whether the current token is the punctuation
+func (p *parser) isPunct(text string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.text == text
+}
+
+// expect - method to consume the punctuation or return an error
+func (p *parser) expect(text string) error {
+	if !p.isPunct(text) {
+		return p.unexpected(p.peek())
+	}
+	p.next()
+	return nil
+}
+
+// unexpected - method to make the error of the unexpected token
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+	return fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+}
+
+// comparison - method to parse the comparison of the sums
+func (p *parser) comparison() (Expr, error) {
+	lhs, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokenPunct && comparisons[t.text]; t = p.peek() {
+		p.next()
+		rhs, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &binaryExpr{op: t.text, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+// expr - method to parse the sum or difference of the terms
+func (p *parser) expr() (Expr, error) {
+	lhs, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("+") || p.isPunct("-") {
+		op := p.next().text
+		rhs, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &binaryExpr{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+// term - method to parse the product or quotient of the unary expressions
+func (p *parser) term() (Expr, error) {
+	lhs, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("*") || p.isPunct("/") {
+		op := p.next().text
+		rhs, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &binaryExpr{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+// unary - method to parse the negation, it is evaluated as the subtraction from zero
+func (p *parser) unary() (Expr, error) {
+	if p.isPunct("-") {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: "-", lhs: &numberExpr{value: 0}, rhs: operand}, nil
+	}
+	return p.primary()
+}
+
+// primary - method to parse the number, the parenthesized expression, the call, the aggregation or the selector
+func (p *parser) primary() (Expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenNumber:
+		p.next()
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad number %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		return &numberExpr{value: value}, nil
+	case t.kind == tokenPunct && t.text == "(":
+		p.next()
+		expr, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	case t.kind != tokenIdent:
+		return nil, p.unexpected(t)
+	}
+	following := p.tokens[p.pos+1]
+	opens := following.kind == tokenPunct && following.text == "("
+	switch {
+	case functions[t.text] && opens:
+		return p.call()
+	case aggregations[t.text] && (opens || (following.kind == tokenIdent && following.text == "by")):
+		return p.aggregation()
+	}
+	sel, err := p.selector()
+	if err != nil {
+		return nil, err
+	}
+	if sel.window > 0 {
+		return nil, fmt.Errorf("%w: range selector %s outside of a function", ErrSyntax, sel.pattern)
+	}
+	return sel, nil
+}
+
+// call - method to parse the function over the range selector
+func (p *parser) call() (Expr, error) {
+	fn := p.next().text
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenIdent {
+		return nil, p.unexpected(p.peek())
+	}
+	arg, err := p.selector()
+	if err != nil {
+		return nil, err
+	}
+	if arg.window == 0 {
+		return nil, fmt.Errorf("%w: %s needs a range selector", ErrSyntax, fn)
+	}
+	return &callExpr{fn: fn, arg: arg}, p.expect(")")
+}
+
+// aggregation - method to parse the aggregation, the by clause may precede or follow the argument
+func (p *parser) aggregation() (Expr, error) {
+	agg := &aggregateExpr{op: p.next().text}
+	if p.peek().kind == tokenIdent && p.peek().text == "by" {
+		if err := p.by(agg); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	arg, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	agg.arg = arg
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if agg.by == nil && p.peek().kind == tokenIdent && p.peek().text == "by" {
+		if err := p.by(agg); err != nil {
+			return nil, err
+		}
+	}
+	return agg, nil
+}
+
+// by - method to parse the labels of the by clause
+func (p *parser) by(agg *aggregateExpr) error {
+	p.next()
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	agg.by = []string{}
+	for !p.isPunct(")") {
+		if len(agg.by) > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+		t := p.next()
+		if t.kind != tokenIdent {
+			return p.unexpected(t)
+		}
+		agg.by = append(agg.by, t.text)
+	}
+	p.next()
+	return nil
+}
+
+// selector - method to parse the metric name pattern, the label matchers and the range
+func (p *parser) selector() (*selectorExpr, error) {
+	sel := &selectorExpr{pattern: p.next().text}
+	if p.isPunct("{") {
+		p.next()
+		for !p.isPunct("}") {
+			if len(sel.matchers) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, p.unexpected(name)
+			}
+			op := p.next()
+			if op.kind != tokenPunct || (op.text != "=" && op.text != "!=") {
+				return nil, p.unexpected(op)
+			}
+			value := p.next()
+			if value.kind != tokenString {
+				return nil, p.unexpected(value)
+			}
+			sel.matchers = append(sel.matchers, matcher{name: name.text, negate: op.text == "!=", value: value.text})
+		}
+		p.next()
+	}
+	if t := p.peek(); t.kind == tokenDuration {
+		p.next()
+		window, err := time.ParseDuration(t.text)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("%w: bad range %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		sel.window = window
+	}
+	return sel, nil
+}
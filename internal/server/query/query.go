@@ -0,0 +1,138 @@
+// Package query implements a small PromQL-like language over the stored metrics.
+// An expression combines the selectors of the current values, the functions over the history ranges,
+// the arithmetic, the comparisons and the aggregations, for example:
+//
+//	HeapInuse / HeapSys
+//	rate(PollCount{host="a"}[5m])
+//	sum by (host) (avg_over_time(cpu_*[10m]))
+//	FreeMemory < 1e8
+//
+// The selector matches the metric names by the glob pattern and the labels by the = and != matchers.
+// The engine reads the values through the storage layer.
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+var (
+	// ErrSyntax - an error that occurs when the expression can not be parsed.
+	ErrSyntax = errors.New("syntax error")
+	// ErrInvalidQuery - an error that occurs when the expression can not be evaluated.
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// The types of the results.
+const (
+	TypeScalar = "scalar"
+	TypeVector = "vector"
+)
+
+// Storage - the storage the engine reads the metrics from.
+type Storage interface {
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
+	GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error)
+}
+
+// Sample - the value of a series, the result of an arithmetic or an aggregation has no metric name.
+type Sample struct {
+	Name   string        `json:"name,omitempty"`
+	Labels models.Labels `json:"labels,omitempty"`
+	Value  float64       `json:"value"`
+}
+
+// Result - the value of the expression, a scalar or a vector of samples.
+type Result struct {
+	Type   string   `json:"type"`
+	Scalar *float64 `json:"scalar,omitempty"`
+	Vector []Sample `json:"vector,omitempty"`
+}
+
+// Expr - the parsed expression.
+type Expr interface {
+	eval(ctx context.Context, e *Engine) (value, error)
+}
+
+// numberExpr - the number literal
+type numberExpr struct {
+	value float64
+}
+
+// matcher - the label matcher of the selector
+type matcher struct {
+	name   string
+	negate bool
+	value  string
+}
+
+// selectorExpr - the metric name pattern, the label matchers and the range of the history
+type selectorExpr struct {
+	pattern  string
+	matchers []matcher
+	window   time.Duration
+}
+
+// callExpr - the function over the range selector
+type callExpr struct {
+	fn  string
+	arg *selectorExpr
+}
+
+// aggregateExpr - the aggregation of the vector, grouped by the labels
+type aggregateExpr struct {
+	op  string
+	by  []string
+	arg Expr
+}
+
+// binaryExpr - the arithmetic operation
+type binaryExpr struct {
+	op       string
+	lhs, rhs Expr
+}
+
+// Engine - the evaluator of the expressions.
+type Engine struct {
+	db  Storage
+	now time.Time
+}
+
+// NewEngine returns the engine reading the metrics from the storage.
+func NewEngine(db Storage) *Engine {
+	return &Engine{db: db}
+}
+
+// Query parses and evaluates the expression, the ranges of the history end at now.
+func (e *Engine) Query(ctx context.Context, input string, now time.Time) (Result, error) {
+	expr, err := Parse(input)
+	if err != nil {
+		return Result{}, err
+	}
+	return e.Eval(ctx, expr, now)
+}
+
+// Eval evaluates the parsed expression, the ranges of the history end at now.
+func (e *Engine) Eval(ctx context.Context, expr Expr, now time.Time) (Result, error) {
+	evaluator := &Engine{db: e.db, now: now}
+	v, err := expr.eval(ctx, evaluator)
+	if err != nil {
+		return Result{}, err
+	}
+	if v.isVector {
+		if len(v.vector) == 0 {
+			return Result{Type: TypeVector}, nil
+		}
+		return Result{Type: TypeVector, Vector: v.vector}, nil
+	}
+	if math.IsNaN(v.scalar) || math.IsInf(v.scalar, 0) {
+		return Result{}, fmt.Errorf("%w: the result %v is not finite", ErrInvalidQuery, v.scalar)
+	}
+	return Result{Type: TypeScalar, Scalar: &v.scalar}, nil
+}
@@ -0,0 +1,228 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{name: "Test 1", expr: `HeapInuse / HeapSys`},
+		{name: "Test 2", expr: `rate(PollCount{host="a", env!='dev'}[5m]) * 60`},
+		{name: "Test 3", expr: `sum by (host) (avg_over_time(cpu_*[1h30m]))`},
+		{name: "Test 4", expr: `max(Heap?nuse) by (host) - -1.5e3`},
+		{name: "Test 5", expr: `HeapInuse[5m]`, wantErr: true},
+		{name: "Test 6", expr: `rate(PollCount)`, wantErr: true},
+		{name: "Test 7", expr: `HeapInuse{host="a"`, wantErr: true},
+		{name: "Test 8", expr: `(HeapInuse`, wantErr: true},
+		{name: "Test 9", expr: `HeapInuse $ 2`, wantErr: true},
+		{name: "Test 10", expr: `rate(PollCount[5x])`, wantErr: true},
+		{name: "Test 11", expr: `HeapInuse HeapSys`, wantErr: true},
+		{name: "Test 12", expr: `sum by host (HeapInuse)`, wantErr: true},
+		{name: "Test 13", expr: `HeapInuse / HeapSys >= 0.9`},
+		{name: "Test 14", expr: `HeapInuse > `, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrSyntax) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrSyntax)
+			}
+		})
+	}
+}
+
+// testStorage - function to fill the in-memory storage with the gauges and the counters of two hosts,
+// the counters grow for the last 10 minutes with a reset on the host b
+func testStorage(t *testing.T, now time.Time) Storage {
+	t.Helper()
+	ctx := context.Background()
+	m := inmemorystorage.NewMemStorage(zap.NewNop(), 100)
+	for _, host := range []string{"a", "b"} {
+		labels := models.Labels{"host": host}
+		for i, v := range []float64{1, 2, 3, 6} {
+			if err := m.SetGaugeAt(ctx, "cpu_load", labels, v, now.Add(time.Duration(i-4)*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for name, v := range map[string]float64{"HeapInuse": 25, "HeapSys": 100} {
+			if err := m.SetGauge(ctx, name, labels, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			if err := m.SetCounterAt(ctx, "PollCount", labels, 60, now.Add(time.Duration(i-10)*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// the restart of the host b resets its counter
+	if err := m.SetCounterAt(ctx, "PollCount", models.Labels{"host": "b"}, -600, now.Add(-30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetGauge(ctx, "HeapSys", models.Labels{"host": "c"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEngineQuery(t *testing.T) {
+	now := time.Now()
+	engine := NewEngine(testStorage(t, now))
+	scalar := func(v float64) *float64 { return &v }
+	a, b, c := models.Labels{"host": "a"}, models.Labels{"host": "b"}, models.Labels{"host": "c"}
+	tests := []struct {
+		name    string
+		expr    string
+		want    Result
+		wantErr error
+	}{
+		{
+			name: "Test 1",
+			expr: `HeapInuse / HeapSys`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 0.25}, {Labels: b, Value: 0.25}}},
+		},
+		{
+			name: "Test 2",
+			expr: `HeapSys{host!="b"}`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Name: "HeapSys", Labels: a, Value: 100}, {Name: "HeapSys", Labels: c, Value: 0}}},
+		},
+		{
+			name: "Test 3",
+			expr: `Heap*{host="a"} * 2`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 50}, {Labels: a, Value: 200}}},
+		},
+		{
+			name: "Test 4",
+			expr: `increase(PollCount[1h])`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 540}, {Labels: b, Value: 540}}},
+		},
+		{
+			name: "Test 5",
+			expr: `rate(PollCount{host="a"}[1h])`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 540.0 / 3600}}},
+		},
+		{
+			name: "Test 6",
+			expr: `avg_over_time(cpu_load{host="a"}[10m]) + max_over_time(cpu_load{host="a"}[10m]) - min_over_time(cpu_load{host="a"}[10m])`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 3 + 6 - 1}}},
+		},
+		{
+			name: "Test 7",
+			expr: `sum(sum_over_time(cpu_load[150s]))`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Value: 18}}},
+		},
+		{
+			name: "Test 8",
+			expr: `sum by (host) (HeapInuse) / count by (host) (HeapSys)`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 25}, {Labels: b, Value: 25}}},
+		},
+		{
+			name: "Test 9",
+			expr: `-(1 + 2) * 3`,
+			want: Result{Type: TypeScalar, Scalar: scalar(-9)},
+		},
+		{
+			name: "Test 10",
+			expr: `rate(HeapInuse[5m])`,
+			want: Result{Type: TypeVector},
+		},
+		{
+			name: "Test 15",
+			expr: `HeapSys < 50`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Name: "HeapSys", Labels: c, Value: 0}}},
+		},
+		{
+			name: "Test 16",
+			expr: `HeapInuse / HeapSys <= 0.25 == 0.25`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Labels: a, Value: 0.25}, {Labels: b, Value: 0.25}}},
+		},
+		{
+			name: "Test 17",
+			expr: `200 > HeapSys{host="a"}`,
+			want: Result{Type: TypeVector, Vector: []Sample{{Name: "HeapSys", Labels: a, Value: 100}}},
+		},
+		{
+			name: "Test 18",
+			expr: `HeapInuse > HeapSys`,
+			want: Result{Type: TypeVector},
+		},
+		{
+			name: "Test 19",
+			expr: `1 < 2`,
+			want: Result{Type: TypeScalar, Scalar: scalar(1)},
+		},
+		{
+			name:    "Test 11",
+			expr:    `Heap* / HeapSys`,
+			wantErr: ErrInvalidQuery,
+		},
+		{
+			name:    "Test 12",
+			expr:    `sum(1)`,
+			wantErr: ErrInvalidQuery,
+		},
+		{
+			name:    "Test 13",
+			expr:    `1 / 0`,
+			wantErr: ErrInvalidQuery,
+		},
+		{
+			name:    "Test 14",
+			expr:    `rate(`,
+			wantErr: ErrSyntax,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Query(context.Background(), tt.expr, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	ctx := context.Background()
+	m := inmemorystorage.NewMemStorage(zap.NewNop(), 100)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		labels := models.Labels{"host": fmt.Sprintf("host_%d", i)}
+		for j := 0; j < 60; j++ {
+			ts := now.Add(time.Duration(j-60) * time.Second)
+			m.SetGaugeAt(ctx, "HeapInuse", labels, float64(j), ts) //nolint:errcheck
+			m.SetGaugeAt(ctx, "HeapSys", labels, float64(j+1), ts) //nolint:errcheck
+			m.SetCounterAt(ctx, "PollCount", labels, int64(j), ts) //nolint:errcheck
+		}
+	}
+	engine := NewEngine(m)
+	for _, expr := range []string{`HeapInuse / HeapSys`, `sum(rate(PollCount[1m]))`} {
+		b.Run(expr, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := engine.Query(ctx, expr, now); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
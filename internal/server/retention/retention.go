@@ -0,0 +1,196 @@
+// Package retention implements the retention policies and the downsampling of the metric history.
+// The raw points of a metric are kept for the raw age of its policy, then they are rolled up into 1-minute points
+// and later into 1-hour points, the points older than the last kept age are dropped.
+// The storages keep the rolled up points and run the compaction in the background,
+// the history queries are resampled to the resolution that covers the requested range.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// defaultInterval - the compaction period used if it is not set
+const defaultInterval = time.Minute
+
+// ErrInvalidPolicy - an error that occurs when the retention policy is inconsistent.
+var ErrInvalidPolicy = errors.New("invalid retention policy")
+
+// Level - the resolution of the rolled up points and the age up to which they are kept.
+type Level struct {
+	Step time.Duration
+	Keep time.Duration
+}
+
+// Policy - the retention policy of the metric names matching the pattern.
+type Policy struct {
+	Pattern string
+	Raw     time.Duration
+	Levels  []Level
+}
+
+// Policies - the retention policies, the first policy whose pattern matches the metric name is used.
+type Policies []Policy
+
+// FromConfig makes the policies of the history configuration.
+// It returns an error if a pattern is malformed, the raw age is not positive or the ages do not increase.
+func FromConfig(conf config.HistoryConfig) (Policies, error) {
+	policies := make(Policies, 0, len(conf.Retention))
+	for _, c := range conf.Retention {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", ErrInvalidPolicy, c.Pattern, err)
+		}
+		if c.Raw <= 0 {
+			return nil, fmt.Errorf("%w: pattern %q: the raw age must be positive", ErrInvalidPolicy, c.Pattern)
+		}
+		p := Policy{Pattern: c.Pattern, Raw: c.Raw}
+		keep := c.Raw
+		for _, l := range []Level{{Step: time.Minute, Keep: c.Minute}, {Step: time.Hour, Keep: c.Hour}} {
+			if l.Keep == 0 {
+				continue
+			}
+			if l.Keep <= keep {
+				return nil, fmt.Errorf("%w: pattern %q: the %v rollups must be kept longer than %v", ErrInvalidPolicy, c.Pattern, l.Step, keep)
+			}
+			keep = l.Keep
+			p.Levels = append(p.Levels, l)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// For returns the policy of the metric name.
+func (p Policies) For(name string) (Policy, bool) {
+	i := p.Index(name)
+	if i < 0 {
+		return Policy{}, false
+	}
+	return p[i], true
+}
+
+// Index returns the index of the policy of the metric name or -1 if no pattern matches it.
+func (p Policies) Index(name string) int {
+	for i, policy := range p {
+		if ok, _ := path.Match(policy.Pattern, name); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// Step returns the resolution of the history for the range starting at from:
+// zero for the raw points if they are kept for the range, else the step of the first level that covers it.
+// The range older than all levels gets the coarsest resolution.
+func (p Policy) Step(from, now time.Time) time.Duration {
+	age := now.Sub(from)
+	if age <= p.Raw {
+		return 0
+	}
+	var step time.Duration
+	for _, l := range p.Levels {
+		step = l.Step
+		if age <= l.Keep {
+			break
+		}
+	}
+	return step
+}
+
+// Step returns the resolution of the history of the metric name for the range starting at from,
+// the history of a metric without a policy is raw.
+func (p Policies) Step(name string, from, now time.Time) time.Duration {
+	policy, ok := p.For(name)
+	if !ok {
+		return 0
+	}
+	return policy.Step(from, now)
+}
+
+// Resample rolls the points up into the points of the step, the points may be raw or already rolled up.
+// The result is sorted by time, with a zero step the points are only sorted.
+func Resample(points []models.Point, step time.Duration) []models.Point {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	if step <= 0 {
+		return points
+	}
+	result := make([]models.Point, 0, len(points))
+	for _, p := range points {
+		start := p.Timestamp.Truncate(step)
+		if n := len(result); n > 0 && result[n-1].Timestamp.Equal(start) {
+			merge(&result[n-1], p)
+			continue
+		}
+		rollup := p
+		rollup.Timestamp = start
+		if agg, ok := aggregate(p); ok {
+			rollup.Aggregate = &agg
+		}
+		result = append(result, rollup)
+	}
+	return result
+}
+
+// aggregate - function to get the aggregate of the point, a histogram point has none
+func aggregate(p models.Point) (models.Aggregate, bool) {
+	switch {
+	case p.Aggregate != nil:
+		return *p.Aggregate, true
+	case p.Value != nil:
+		return models.NewAggregate(*p.Value), true
+	case p.Delta != nil:
+		return models.NewAggregate(float64(*p.Delta)), true
+	}
+	return models.Aggregate{}, false
+}
+
+// merge - function to add the later point to the rolled up point, the last value is taken from the later point
+func merge(rollup *models.Point, p models.Point) {
+	rollup.Delta, rollup.Value, rollup.Histogram = p.Delta, p.Value, p.Histogram
+	agg, ok := aggregate(p)
+	if !ok {
+		return
+	}
+	if rollup.Aggregate == nil {
+		rollup.Aggregate = &agg
+		return
+	}
+	merged := *rollup.Aggregate
+	merged.Merge(agg)
+	rollup.Aggregate = &merged
+}
+
+// Compactor - the storage that downsamples its history by the policies.
+type Compactor interface {
+	CompactHistory(ctx context.Context, now time.Time) error
+}
+
+// Run compacts the history every interval until the context is done.
+func Run(ctx context.Context, interval time.Duration, c Compactor, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if err := c.CompactHistory(ctx, now); err != nil {
+				logger.Error("could not compact history", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package retention
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    []config.RetentionPolicy
+		want    Policies
+		wantErr bool
+	}{
+		{
+			name: "Test 1",
+			conf: []config.RetentionPolicy{{Pattern: "cpu_*", Raw: time.Hour, Minute: 24 * time.Hour, Hour: 30 * 24 * time.Hour}},
+			want: Policies{{Pattern: "cpu_*", Raw: time.Hour, Levels: []Level{
+				{Step: time.Minute, Keep: 24 * time.Hour},
+				{Step: time.Hour, Keep: 30 * 24 * time.Hour},
+			}}},
+		},
+		{
+			name: "Test 2",
+			conf: []config.RetentionPolicy{{Pattern: "*", Raw: time.Hour, Hour: 24 * time.Hour}},
+			want: Policies{{Pattern: "*", Raw: time.Hour, Levels: []Level{{Step: time.Hour, Keep: 24 * time.Hour}}}},
+		},
+		{
+			name:    "Test 3",
+			conf:    []config.RetentionPolicy{{Pattern: "[", Raw: time.Hour}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 4",
+			conf:    []config.RetentionPolicy{{Pattern: "*"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test 5",
+			conf:    []config.RetentionPolicy{{Pattern: "*", Raw: time.Hour, Minute: 2 * time.Hour, Hour: time.Hour}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromConfig(config.HistoryConfig{Retention: tt.conf})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPolicy) {
+					t.Errorf("FromConfig() error = %v, want %v", err, ErrInvalidPolicy)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoliciesStep(t *testing.T) {
+	policies := Policies{
+		{Pattern: "cpu_*", Raw: time.Hour, Levels: []Level{{Step: time.Minute, Keep: 24 * time.Hour}, {Step: time.Hour, Keep: 720 * time.Hour}}},
+		{Pattern: "*", Raw: time.Hour},
+	}
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		metric string
+		from   time.Time
+		want   time.Duration
+	}{
+		{name: "Test 1", metric: "cpu_load", from: now.Add(-30 * time.Minute), want: 0},
+		{name: "Test 2", metric: "cpu_load", from: now.Add(-2 * time.Hour), want: time.Minute},
+		{name: "Test 3", metric: "cpu_load", from: now.Add(-48 * time.Hour), want: time.Hour},
+		{name: "Test 4", metric: "cpu_load", from: time.Time{}, want: time.Hour},
+		{name: "Test 5", metric: "memory", from: time.Time{}, want: 0},
+		{name: "Test 6", metric: "memory", from: now.Add(-2 * time.Hour), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policies.Step(tt.metric, tt.from, now); got != tt.want {
+				t.Errorf("Step() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResample(t *testing.T) {
+	start := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	value := func(v float64) *float64 { return &v }
+	delta := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name   string
+		points []models.Point
+		step   time.Duration
+		want   []models.Point
+	}{
+		{
+			name: "Test 1",
+			points: []models.Point{
+				{Timestamp: start.Add(70 * time.Second), Value: value(4)},
+				{Timestamp: start.Add(10 * time.Second), Value: value(1)},
+				{Timestamp: start.Add(20 * time.Second), Value: value(3)},
+			},
+			step: time.Minute,
+			want: []models.Point{
+				{Timestamp: start, Value: value(3), Aggregate: &models.Aggregate{Min: 1, Max: 3, Avg: 2, Last: 3, Sum: 4, Count: 2}},
+				{Timestamp: start.Add(time.Minute), Value: value(4), Aggregate: &models.Aggregate{Min: 4, Max: 4, Avg: 4, Last: 4, Sum: 4, Count: 1}},
+			},
+		},
+		{
+			name: "Test 2",
+			points: []models.Point{
+				{Timestamp: start, Delta: delta(10), Aggregate: &models.Aggregate{Min: 5, Max: 10, Avg: 7.5, Last: 10, Sum: 15, Count: 2}},
+				{Timestamp: start.Add(time.Minute), Delta: delta(20)},
+			},
+			step: time.Hour,
+			want: []models.Point{
+				{Timestamp: start, Delta: delta(20), Aggregate: &models.Aggregate{Min: 5, Max: 20, Avg: 35.0 / 3, Last: 20, Sum: 35, Count: 3}},
+			},
+		},
+		{
+			name: "Test 3",
+			points: []models.Point{
+				{Timestamp: start.Add(time.Second), Value: value(2)},
+				{Timestamp: start, Value: value(1)},
+			},
+			want: []models.Point{
+				{Timestamp: start, Value: value(1)},
+				{Timestamp: start.Add(time.Second), Value: value(2)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resample(tt.points, tt.step); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resample() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkResample(b *testing.B) {
+	start := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	points := make([]models.Point, 3600)
+	for i := range points {
+		v := float64(i)
+		points[i] = models.Point{Timestamp: start.Add(time.Duration(i) * time.Second), Value: &v}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Resample(points, time.Minute)
+	}
+}
@@ -0,0 +1,85 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// errSyntax - an error that occurs when the line does not follow the StatsD format
+var errSyntax = errors.New("invalid statsd syntax")
+
+// sample - one parsed StatsD line
+type sample struct {
+	name     string
+	labels   models.Labels
+	mType    string
+	value    float64
+	relative bool
+	rate     float64
+}
+
+// parseLine parses one StatsD line:
+//
+//	name:value|type[|@rate][|#tag:value,...]
+//
+// The supported types are c (counter), g (gauge) and ms or h (timer).
+// A gauge value with an explicit sign changes the current value instead of replacing it.
+// The counters must not be negative and the values must be finite.
+// The optional DogStatsD tags become labels.
+func parseLine(line string) (sample, error) {
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 {
+		return sample{}, errSyntax
+	}
+	s := sample{name: line[:colon], rate: 1}
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 {
+		return sample{}, errSyntax
+	}
+	value := parts[0]
+	var err error
+	s.value, err = strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+		return sample{}, fmt.Errorf("%w: bad value %q", errSyntax, value)
+	}
+	switch parts[1] {
+	case "c":
+		s.mType = "counter"
+		if s.value < 0 {
+			return sample{}, fmt.Errorf("%w: counter must be positive", errSyntax)
+		}
+	case "g":
+		s.mType = "gauge"
+		s.relative = value[0] == '+' || value[0] == '-'
+	case "ms", "h":
+		s.mType = "histogram"
+	default:
+		return sample{}, fmt.Errorf("%w: unknown type %q", errSyntax, parts[1])
+	}
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			s.rate, err = strconv.ParseFloat(part[1:], 64)
+			if err != nil || s.rate <= 0 || s.rate > 1 {
+				return sample{}, fmt.Errorf("%w: bad sample rate %q", errSyntax, part)
+			}
+		case strings.HasPrefix(part, "#"):
+			s.labels = make(models.Labels)
+			for _, tag := range strings.Split(part[1:], ",") {
+				name, value, _ := strings.Cut(tag, ":")
+				s.labels[name] = value
+			}
+			if err := s.labels.Validate(); err != nil {
+				return sample{}, err
+			}
+		default:
+			return sample{}, fmt.Errorf("%w: unknown field %q", errSyntax, part)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,220 @@
+// Package statsd implements a StatsD listener that aggregates the received metrics
+// for the flush interval and writes them to the metric storage.
+package statsd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+const (
+	// maxPacketSize - the maximum size of the UDP packet
+	maxPacketSize = 65535
+	// defaultFlushInterval - the flush interval used if it is not set
+	defaultFlushInterval = 10 * time.Second
+)
+
+// Updater is an interface that updates metrics.
+//
+//go:generate mockery --name Updater --output ./mocks --filename mocks_statsd.go
+type Updater interface {
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error
+}
+
+// series - the aggregated value of one series
+type series struct {
+	name      string
+	labels    models.Labels
+	counter   float64
+	gauge     float64
+	histogram models.Histogram
+	// relative is true if the gauge is the change of the stored value
+	relative bool
+}
+
+// Server - a StatsD server that listens on UDP.
+// Counters are summed, the last gauge value wins and timers are collected into histograms.
+type Server struct {
+	address       string
+	flushInterval time.Duration
+	timerBuckets  []float64
+	db            Updater
+	logger        *zap.Logger
+	counters      map[string]*series
+	gauges        map[string]*series
+	timers        map[string]*series
+	mut           sync.Mutex
+}
+
+// NewServer creates a StatsD server, timers are collected into histograms with the given bucket bounds in milliseconds.
+func NewServer(address string, flushInterval time.Duration, timerBuckets []float64, db Updater, logger *zap.Logger) *Server {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	return &Server{
+		address:       address,
+		flushInterval: flushInterval,
+		timerBuckets:  timerBuckets,
+		db:            db,
+		logger:        logger,
+		counters:      make(map[string]*series),
+		gauges:        make(map[string]*series),
+		timers:        make(map[string]*series),
+	}
+}
+
+// Run listens for StatsD packets until the context is done and flushes the aggregated metrics every flush interval.
+// The metrics received before the stop are flushed as well, Run returns after the last flush.
+func (s *Server) Run(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", s.address)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		t := time.NewTicker(s.flushInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				s.Flush(ctx)
+			}
+		}
+	}()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// the context may be done already, the last interval is flushed without it
+			// after the periodic flush is stopped
+			<-flushed
+			s.Flush(context.Background())
+			if ctx.Err() != nil && errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.HandlePacket(buf[:n])
+	}
+}
+
+// HandlePacket aggregates the lines of the packet, the invalid lines are logged and skipped.
+func (s *Server) HandlePacket(packet []byte) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for _, line := range strings.Split(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sm, err := parseLine(line)
+		if err != nil {
+			s.logger.Info("could not parse statsd line", zap.String("line", line), zap.Error(err))
+			continue
+		}
+		s.add(sm)
+	}
+}
+
+// add - method to aggregate the sample, the caller must hold the lock.
+// The changes of the gauge are summed, they are applied to the stored value on flush.
+func (s *Server) add(sm sample) {
+	key := models.SeriesKey(sm.name, sm.labels)
+	switch sm.mType {
+	case "counter":
+		ser := s.get(s.counters, key, sm)
+		ser.counter += sm.value / sm.rate
+	case "gauge":
+		ser, ok := s.gauges[key]
+		if !ok {
+			ser = s.get(s.gauges, key, sm)
+			ser.relative = sm.relative
+		}
+		if sm.relative {
+			ser.gauge += sm.value
+		} else {
+			ser.gauge, ser.relative = sm.value, false
+		}
+	case "histogram":
+		ser, ok := s.timers[key]
+		if !ok {
+			ser = s.get(s.timers, key, sm)
+			ser.histogram = models.NewHistogram(s.timerBuckets)
+		}
+		ser.histogram.Observe(sm.value, uint64(math.Round(1/sm.rate)))
+	}
+}
+
+// get - method to get the series from the map, a missing series is created
+func (s *Server) get(m map[string]*series, key string, sm sample) *series {
+	ser, ok := m[key]
+	if !ok {
+		ser = &series{name: sm.name, labels: sm.labels}
+		m[key] = ser
+	}
+	return ser
+}
+
+// Flush writes the aggregated metrics to the storage and starts a new interval.
+// The changes of the gauges are applied to the stored values, a missing gauge starts from zero,
+// the gauge that becomes negative is logged and dropped.
+// The metrics that the storage fails to read or write are logged and dropped.
+func (s *Server) Flush(ctx context.Context) {
+	s.mut.Lock()
+	counters, gauges, timers := s.counters, s.gauges, s.timers
+	s.counters = make(map[string]*series)
+	s.gauges = make(map[string]*series)
+	s.timers = make(map[string]*series)
+	s.mut.Unlock()
+
+	for _, ser := range counters {
+		if err := s.db.SetCounter(ctx, ser.name, ser.labels, int64(math.Round(ser.counter))); err != nil {
+			s.logger.Error("could not store statsd counter", zap.String("name", ser.name), zap.Error(err))
+		}
+	}
+	for _, ser := range gauges {
+		value := ser.gauge
+		if ser.relative {
+			stored, err := s.db.GetGauge(ctx, ser.name, ser.labels)
+			if err != nil && !errors.Is(err, servererrors.ErrNotFound) {
+				s.logger.Error("could not get stored statsd gauge", zap.String("name", ser.name), zap.Error(err))
+				continue
+			}
+			value += stored
+		}
+		if err := (&models.Metric{ID: ser.name, MType: "gauge", Value: &value, Labels: ser.labels}).Validate(); err != nil {
+			s.logger.Warn("dropped invalid statsd gauge", zap.String("name", ser.name), zap.Float64("value", value), zap.Error(err))
+			continue
+		}
+		if err := s.db.SetGauge(ctx, ser.name, ser.labels, value); err != nil {
+			s.logger.Error("could not store statsd gauge", zap.String("name", ser.name), zap.Error(err))
+		}
+	}
+	for _, ser := range timers {
+		if err := s.db.SetHistogram(ctx, ser.name, ser.labels, ser.histogram); err != nil {
+			s.logger.Error("could not store statsd timer", zap.String("name", ser.name), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+	"github.com/h2p2f/practicum-metrics/internal/server/statsd/mocks"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    sample
+		wantErr bool
+	}{
+		{
+			name: "Test 1",
+			line: "requests:2|c|@0.5",
+			want: sample{name: "requests", mType: "counter", value: 2, rate: 0.5},
+		},
+		{
+			name: "Test 2",
+			line: "temperature:-1.5|g|#host:a,env:prod",
+			want: sample{name: "temperature", labels: models.Labels{"host": "a", "env": "prod"}, mType: "gauge", value: -1.5, relative: true, rate: 1},
+		},
+		{
+			name: "Test 3",
+			line: "latency:320|ms",
+			want: sample{name: "latency", mType: "histogram", value: 320, rate: 1},
+		},
+		{
+			name:    "Test 4",
+			line:    "requests:-1|c",
+			wantErr: true,
+		},
+		{
+			name:    "Test 5",
+			line:    "requests:abc|c",
+			wantErr: true,
+		},
+		{
+			name:    "Test 6",
+			line:    "requests:1|s",
+			wantErr: true,
+		},
+		{
+			name:    "Test 7",
+			line:    "requests:1|c|@2",
+			wantErr: true,
+		},
+		{
+			name:    "Test 8",
+			line:    "requests",
+			wantErr: true,
+		},
+		// the non-finite values are rejected
+		{
+			name:    "Test 9",
+			line:    "load:NaN|g",
+			wantErr: true,
+		},
+		{
+			name:    "Test 10",
+			line:    "load:+Inf|g",
+			wantErr: true,
+		},
+		{
+			name:    "Test 11",
+			line:    "latency:inf|ms",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerFlush(t *testing.T) {
+	updaterMock := mocks.NewUpdater(t)
+	labels := models.Labels{"host": "a"}
+	histogram := models.Histogram{Bounds: []float64{100, 500}, Counts: []uint64{1, 2, 0}, Count: 3, Sum: 750}
+	updaterMock.On("SetCounter", mock.Anything, "requests", models.Labels(nil), int64(5)).Return(nil).Once()
+	updaterMock.On("GetGauge", mock.Anything, "queue", labels).Return(float64(0), servererrors.ErrNotFound).Once()
+	updaterMock.On("SetGauge", mock.Anything, "queue", labels, float64(7)).Return(nil).Once()
+	updaterMock.On("SetGauge", mock.Anything, "temperature", models.Labels(nil), float64(25)).Return(nil).Once()
+	// the stored gauge that can not be read is not overwritten by the change
+	updaterMock.On("GetGauge", mock.Anything, "pool", models.Labels(nil)).Return(float64(0), errors.New("connection refused")).Once()
+	// the gauge that becomes negative is dropped
+	updaterMock.On("GetGauge", mock.Anything, "debt", models.Labels(nil)).Return(float64(2), nil).Once()
+	updaterMock.On("SetHistogram", mock.Anything, "latency", models.Labels(nil), histogram).Return(nil).Once()
+
+	s := NewServer("localhost:0", time.Second, []float64{100, 500}, updaterMock, zaptest.NewLogger(t))
+	s.HandlePacket([]byte("requests:1|c\nrequests:2|c|@0.5\nrequests:-1|c\nrequests:NaN|c\nqueue:+10|g|#host:a\nqueue:-3|g|#host:a\nbad line\n"))
+	s.HandlePacket([]byte("temperature:30|g\ntemperature:20|g\ntemperature:+5|g\npool:+1|g\ndebt:-5|g\nlatency:50|ms\nlatency:350|ms|@0.5\n"))
+	s.Flush(context.Background())
+	// the next flush has nothing to write
+	s.Flush(context.Background())
+}
@@ -0,0 +1,400 @@
+// Package cachestorage implements a write-back memory cache in front of another metric storage.
+// The counters and gauges are served from memory, the updates are coalesced
+// and flushed to the storage in batches on an interval or when too many series are pending.
+package cachestorage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+const (
+	// defaultFlushInterval - the flush interval used if it is not set
+	defaultFlushInterval = time.Second
+	// defaultMaxPending - the number of the pending series that triggers the flush if it is not set
+	defaultMaxPending = 10000
+)
+
+// series - the name and the labels of the series, they are kept to build the metrics of the flush
+type series struct {
+	name   string
+	labels models.Labels
+}
+
+// CacheStorage - the storage decorator that caches the counters and gauges of the storage in memory.
+// A counter increment and a gauge value are accepted into memory and written to the storage by the next flush,
+// the increments of one counter and the values of one gauge between the flushes are coalesced,
+// so the history of the storage gets one point per flush.
+// The updates with their own time are written through, so the history keeps it.
+// The histograms are written through, the histograms and the history are read from the storage.
+type CacheStorage struct {
+	storage.Storage
+	logger        *zap.Logger
+	flushInterval time.Duration
+	maxPending    int
+
+	mut             sync.RWMutex
+	counters        map[string]int64
+	gauges          map[string]float64
+	series          map[string]series
+	pendingCounters map[string]int64
+	pendingGauges   map[string]float64
+
+	// flushMut serializes the flushes, so a failed batch is returned to the pending updates in order
+	flushMut sync.Mutex
+	trigger  chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewCacheStorage creates a new instance of CacheStorage, loads the counters and gauges of the storage
+// and starts flushing every flushInterval until the context is done or the cache is closed.
+func NewCacheStorage(ctx context.Context, base storage.Storage, flushInterval time.Duration, maxPending int, logger *zap.Logger) (*CacheStorage, error) {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+	c := &CacheStorage{
+		Storage:         base,
+		logger:          logger,
+		flushInterval:   flushInterval,
+		maxPending:      maxPending,
+		counters:        make(map[string]int64),
+		gauges:          make(map[string]float64),
+		series:          make(map[string]series),
+		pendingCounters: make(map[string]int64),
+		pendingGauges:   make(map[string]float64),
+		trigger:         make(chan struct{}, 1),
+		done:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+	}
+	counters, err := base.GetCounters(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range counters {
+		c.counters[key] = value
+	}
+	gauges, err := base.GetGauges(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range gauges {
+		c.gauges[key] = value
+	}
+	go c.run(ctx)
+	return c, nil
+}
+
+// run - method to flush the pending updates on the interval and on the trigger
+func (c *CacheStorage) run(ctx context.Context) {
+	defer close(c.stopped)
+	t := time.NewTicker(c.flushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		case <-t.C:
+		case <-c.trigger:
+		}
+		if err := c.Flush(ctx); err != nil {
+			c.logger.Error("could not flush cache", zap.Error(err))
+		}
+	}
+}
+
+// SetCounter adds the value to the cached counter, the increment is written by the next flush.
+// It returns models.ErrInvalidMetric if the increment is not accepted by the storage.
+func (c *CacheStorage) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	if err := (&models.Metric{ID: name, MType: "counter", Delta: &value, Labels: labels}).Validate(); err != nil {
+		return err
+	}
+	key := models.SeriesKey(name, labels)
+	c.mut.Lock()
+	c.addCounter(key, name, labels, value)
+	pending := len(c.pendingCounters) + len(c.pendingGauges)
+	c.mut.Unlock()
+	c.checkPending(pending)
+	return nil
+}
+
+// SetCounterAt adds the value to the counter at the given time. The timestamped increment is written through,
+// so the history of the storage keeps its time, and then it is added to the cached counter.
+func (c *CacheStorage) SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error {
+	if err := (&models.Metric{ID: name, MType: "counter", Delta: &value, Labels: labels}).Validate(); err != nil {
+		return err
+	}
+	if err := c.Storage.SetCounterAt(ctx, name, labels, value, ts); err != nil {
+		return err
+	}
+	key := models.SeriesKey(name, labels)
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.counters[key] += value
+	c.series[key] = series{name: name, labels: labels}
+	return nil
+}
+
+// SetGauge sets the cached gauge, the last value is written by the next flush.
+// It returns models.ErrInvalidMetric if the value is not accepted by the storage.
+func (c *CacheStorage) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	if err := (&models.Metric{ID: name, MType: "gauge", Value: &value, Labels: labels}).Validate(); err != nil {
+		return err
+	}
+	key := models.SeriesKey(name, labels)
+	c.mut.Lock()
+	c.setGauge(key, name, labels, value)
+	pending := len(c.pendingCounters) + len(c.pendingGauges)
+	c.mut.Unlock()
+	c.checkPending(pending)
+	return nil
+}
+
+// SetGaugeAt sets the gauge at the given time. The timestamped value is written through,
+// so the history of the storage keeps its time, and it replaces the cached and the pending value.
+// It waits for the running flush, so the older pending value is not written over it.
+func (c *CacheStorage) SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error {
+	if err := (&models.Metric{ID: name, MType: "gauge", Value: &value, Labels: labels}).Validate(); err != nil {
+		return err
+	}
+	c.flushMut.Lock()
+	defer c.flushMut.Unlock()
+	if err := c.Storage.SetGaugeAt(ctx, name, labels, value, ts); err != nil {
+		return err
+	}
+	key := models.SeriesKey(name, labels)
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.gauges[key] = value
+	delete(c.pendingGauges, key)
+	c.series[key] = series{name: name, labels: labels}
+	return nil
+}
+
+// SetMetrics stores the batch of metrics all or nothing: the histograms are written to the storage first
+// and the counters and gauges are accepted into the cache only if they succeed.
+func (c *CacheStorage) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	var histograms []models.Metric
+	for i := range metrics {
+		if err := metrics[i].Validate(); err != nil {
+			return err
+		}
+		if metrics[i].MType == "histogram" {
+			histograms = append(histograms, metrics[i])
+		}
+	}
+	if len(histograms) > 0 {
+		if err := c.Storage.SetMetrics(ctx, histograms); err != nil {
+			return err
+		}
+	}
+	c.mut.Lock()
+	for _, metric := range metrics {
+		key := models.SeriesKey(metric.ID, metric.Labels)
+		switch metric.MType {
+		case "counter":
+			c.addCounter(key, metric.ID, metric.Labels, *metric.Delta)
+		case "gauge":
+			c.setGauge(key, metric.ID, metric.Labels, *metric.Value)
+		}
+	}
+	pending := len(c.pendingCounters) + len(c.pendingGauges)
+	c.mut.Unlock()
+	c.checkPending(pending)
+	return nil
+}
+
+// addCounter - method to add the increment to the cached and the pending counter, the caller must hold the lock
+func (c *CacheStorage) addCounter(key, name string, labels models.Labels, value int64) {
+	c.counters[key] += value
+	c.pendingCounters[key] += value
+	c.series[key] = series{name: name, labels: labels}
+}
+
+// setGauge - method to set the cached and the pending gauge, the caller must hold the lock
+func (c *CacheStorage) setGauge(key, name string, labels models.Labels, value float64) {
+	c.gauges[key] = value
+	c.pendingGauges[key] = value
+	c.series[key] = series{name: name, labels: labels}
+}
+
+// checkPending - method to trigger the flush if the number of the pending series reaches the threshold
+func (c *CacheStorage) checkPending(pending int) {
+	if pending < c.maxPending {
+		return
+	}
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Flush writes the pending counter increments and gauge values to the storage in one batch.
+// The series that fail the validation are logged and dropped, so they do not block the others.
+// If the batch fails, the updates stay pending until the next flush.
+func (c *CacheStorage) Flush(ctx context.Context) error {
+	c.flushMut.Lock()
+	defer c.flushMut.Unlock()
+
+	c.mut.Lock()
+	counters, gauges := c.pendingCounters, c.pendingGauges
+	if len(counters) == 0 && len(gauges) == 0 {
+		c.mut.Unlock()
+		return nil
+	}
+	c.pendingCounters = make(map[string]int64)
+	c.pendingGauges = make(map[string]float64)
+	metrics := make([]models.Metric, 0, len(counters)+len(gauges))
+	for key, delta := range counters {
+		delta := delta
+		s := c.series[key]
+		metric := models.Metric{ID: s.name, MType: "counter", Delta: &delta, Labels: s.labels}
+		if !c.valid(metric) {
+			delete(counters, key)
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	for key, value := range gauges {
+		value := value
+		s := c.series[key]
+		metric := models.Metric{ID: s.name, MType: "gauge", Value: &value, Labels: s.labels}
+		if !c.valid(metric) {
+			delete(gauges, key)
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	c.mut.Unlock()
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	if err := c.Storage.SetMetrics(ctx, metrics); err != nil {
+		// return the batch to the pending updates, a newer gauge value wins
+		c.mut.Lock()
+		for key, delta := range counters {
+			c.pendingCounters[key] += delta
+		}
+		for key, value := range gauges {
+			if _, ok := c.pendingGauges[key]; !ok {
+				c.pendingGauges[key] = value
+			}
+		}
+		c.mut.Unlock()
+		return err
+	}
+	return nil
+}
+
+// valid - method to check the pending metric, the invalid one is logged and dropped from the flush
+func (c *CacheStorage) valid(metric models.Metric) bool {
+	if err := metric.Validate(); err != nil {
+		c.logger.Error("dropped invalid cached metric", zap.String("name", metric.ID), zap.String("type", metric.MType), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// GetCounter returns the cached counter value.
+func (c *CacheStorage) GetCounter(ctx context.Context, name string, labels models.Labels) (int64, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	value, ok := c.counters[models.SeriesKey(name, labels)]
+	if !ok {
+		return 0, servererrors.ErrNotFound
+	}
+	return value, nil
+}
+
+// GetGauge returns the cached gauge value.
+func (c *CacheStorage) GetGauge(ctx context.Context, name string, labels models.Labels) (float64, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	value, ok := c.gauges[models.SeriesKey(name, labels)]
+	if !ok {
+		return 0, servererrors.ErrNotFound
+	}
+	return value, nil
+}
+
+// GetCounters returns the cached counters whose labels match the filter.
+func (c *CacheStorage) GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	counters := make(map[string]int64, len(c.counters))
+	for key, value := range c.counters {
+		if matches(key, filter) {
+			counters[key] = value
+		}
+	}
+	return counters, nil
+}
+
+// GetGauges returns the cached gauges whose labels match the filter.
+func (c *CacheStorage) GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	gauges := make(map[string]float64, len(c.gauges))
+	for key, value := range c.gauges {
+		if matches(key, filter) {
+			gauges[key] = value
+		}
+	}
+	return gauges, nil
+}
+
+// matches reports whether the labels of the series key match the filter.
+func matches(key string, filter models.Labels) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	_, labels, err := models.ParseSeriesKey(key)
+	return err == nil && labels.Matches(filter)
+}
+
+// alertStore - the storage that keeps the alert states
+type alertStore interface {
+	LoadAlerts(ctx context.Context) ([]models.Alert, error)
+	SaveAlerts(ctx context.Context, alerts []models.Alert) error
+}
+
+// LoadAlerts loads the alert states from the storage, it returns servererrors.ErrNotImplemented if the storage does not keep them.
+func (c *CacheStorage) LoadAlerts(ctx context.Context) ([]models.Alert, error) {
+	store, ok := c.Storage.(alertStore)
+	if !ok {
+		return nil, servererrors.ErrNotImplemented
+	}
+	return store.LoadAlerts(ctx)
+}
+
+// SaveAlerts saves the alert states to the storage, it returns servererrors.ErrNotImplemented if the storage does not keep them.
+func (c *CacheStorage) SaveAlerts(ctx context.Context, alerts []models.Alert) error {
+	store, ok := c.Storage.(alertStore)
+	if !ok {
+		return servererrors.ErrNotImplemented
+	}
+	return store.SaveAlerts(ctx, alerts)
+}
+
+// Close stops the flushing, writes the pending updates and closes the storage.
+func (c *CacheStorage) Close(ctx context.Context) error {
+	close(c.done)
+	<-c.stopped
+	if err := c.Flush(ctx); err != nil {
+		c.logger.Error("could not flush cache on close", zap.Error(err))
+	}
+	return c.Storage.Close(ctx)
+}
@@ -0,0 +1,247 @@
+package cachestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/postgrestorage"
+)
+
+// failingStorage - the storage whose batches fail while failing is set
+type failingStorage struct {
+	storage.Storage
+	failing bool
+	batches int
+}
+
+func (f *failingStorage) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	if f.failing {
+		return errors.New("storage is unavailable")
+	}
+	f.batches++
+	return f.Storage.SetMetrics(ctx, metrics)
+}
+
+func TestCacheStorageFlush(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	base := &failingStorage{Storage: inmemorystorage.NewMemStorage(logger, 0)}
+	if err := base.Storage.SetCounter(ctx, "requests", nil, 10); err != nil {
+		t.Fatal(err)
+	}
+	// the flushes are started by the test only
+	cache, err := NewCacheStorage(ctx, base, time.Hour, 1000, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := models.Labels{"host": "a"}
+	for i := 0; i < 3; i++ {
+		if err := cache.SetCounter(ctx, "requests", nil, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.SetGauge(ctx, "load", labels, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter, _ := cache.GetCounter(ctx, "requests", nil); counter != 13 {
+		t.Errorf("cached counter = %v, want 13", counter)
+	}
+	if _, err := base.GetGauge(ctx, "load", labels); err == nil {
+		t.Error("the gauge is written before the flush")
+	}
+
+	// a failed flush keeps the updates pending
+	base.failing = true
+	if err := cache.Flush(ctx); err == nil {
+		t.Fatal("Flush() error = nil, want the storage error")
+	}
+	if err := cache.SetCounter(ctx, "requests", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	base.failing = false
+	if err := cache.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if base.batches != 1 {
+		t.Errorf("batches = %v, want 1", base.batches)
+	}
+	if counter, _ := base.GetCounter(ctx, "requests", nil); counter != 14 {
+		t.Errorf("stored counter = %v, want 14", counter)
+	}
+	if gauge, _ := base.GetGauge(ctx, "load", labels); gauge != 2 {
+		t.Errorf("stored gauge = %v, want 2", gauge)
+	}
+}
+
+func TestCacheStorageInvalid(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	base := &failingStorage{Storage: inmemorystorage.NewMemStorage(logger, 0)}
+	cache, err := NewCacheStorage(ctx, base, time.Hour, 1000, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetGauge(ctx, "load", nil, -1); !errors.Is(err, models.ErrInvalidMetric) {
+		t.Errorf("SetGauge() error = %v, want %v", err, models.ErrInvalidMetric)
+	}
+	if err := cache.SetCounterAt(ctx, "requests", nil, -1, time.Now()); !errors.Is(err, models.ErrInvalidMetric) {
+		t.Errorf("SetCounterAt() error = %v, want %v", err, models.ErrInvalidMetric)
+	}
+	if err := cache.SetCounter(ctx, "requests", nil, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetGauge(ctx, "load", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	// the series cached before the validation sits next to the good ones
+	cache.mut.Lock()
+	cache.setGauge(models.SeriesKey("bad", nil), "bad", nil, -1)
+	cache.mut.Unlock()
+
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v, want the bad series dropped", err)
+	}
+	if counter, _ := base.GetCounter(ctx, "requests", nil); counter != 2 {
+		t.Errorf("stored counter = %v, want 2", counter)
+	}
+	if gauge, _ := base.GetGauge(ctx, "load", nil); gauge != 1 {
+		t.Errorf("stored gauge = %v, want 1", gauge)
+	}
+	if _, err := base.GetGauge(ctx, "bad", nil); err == nil {
+		t.Error("the invalid gauge is stored")
+	}
+	cache.mut.RLock()
+	pending := len(cache.pendingCounters) + len(cache.pendingGauges)
+	cache.mut.RUnlock()
+	if pending != 0 {
+		t.Errorf("pending series = %v, want 0", pending)
+	}
+	if err := cache.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheStorageTimestamps(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	base := &failingStorage{Storage: inmemorystorage.NewMemStorage(logger, 10)}
+	cache, err := NewCacheStorage(ctx, base, time.Hour, 1000, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := cache.SetGauge(ctx, "load", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	// the timestamped updates are written through and the newer gauge replaces the pending one
+	if err := cache.SetGaugeAt(ctx, "load", nil, 2, ts); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetCounterAt(ctx, "requests", nil, 3, ts); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if base.batches != 0 {
+		t.Errorf("batches = %v, want 0", base.batches)
+	}
+	for _, mType := range []string{"gauge", "counter"} {
+		name := map[string]string{"gauge": "load", "counter": "requests"}[mType]
+		points, err := base.GetHistory(ctx, mType, name, nil, time.Time{}, time.Now())
+		if err != nil || len(points) != 1 || !points[0].Timestamp.Equal(ts) {
+			t.Errorf("%s history = %+v, %v, want one point at %v", mType, points, err, ts)
+		}
+	}
+	if gauge, _ := cache.GetGauge(ctx, "load", nil); gauge != 2 {
+		t.Errorf("cached gauge = %v, want 2", gauge)
+	}
+	if counter, _ := cache.GetCounter(ctx, "requests", nil); counter != 3 {
+		t.Errorf("cached counter = %v, want 3", counter)
+	}
+	if err := cache.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheStorageMaxPending(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	base := inmemorystorage.NewMemStorage(logger, 0)
+	cache, err := NewCacheStorage(ctx, base, time.Hour, 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close(ctx)
+	if err := cache.SetGauge(ctx, "a", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetGauge(ctx, "b", nil, 2); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := base.GetGauge(ctx, "b", nil); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the threshold did not trigger the flush")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// BenchmarkIngest compares the updates written directly to postgres with the updates accepted by the cache.
+// It needs a database, the DSN is taken from the TEST_DATABASE_DSN environment variable.
+func BenchmarkIngest(b *testing.B) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+	logger := zap.NewNop()
+	pgDB, err := postgrestorage.NewPostgresDB(ctx, config.DatabaseConfig{Dsn: dsn}, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pgDB.Close(ctx)
+	if err := pgDB.Create(); err != nil {
+		b.Fatal(err)
+	}
+	labels := models.Labels{"host": "bench"}
+
+	b.Run("pg", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := pgDB.SetCounter(ctx, fmt.Sprintf("bench_counter_%d", i%100), labels, 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cache", func(b *testing.B) {
+		cache, err := NewCacheStorage(ctx, pgDB, defaultFlushInterval, defaultMaxPending, logger)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := cache.SetCounter(ctx, fmt.Sprintf("bench_counter_%d", i%100), labels, 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+		// the final flush is a part of the ingest
+		if err := cache.Flush(ctx); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
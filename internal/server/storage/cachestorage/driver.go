@@ -0,0 +1,37 @@
+package cachestorage
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// Driver - the name of the cache backend in the storage registry.
+// It caches the base backend, the postgres one by default.
+const Driver = "cache"
+
+func init() {
+	storage.Register(Driver, func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (storage.Storage, error) {
+		base := conf.Cache.Base
+		if base == "" {
+			base = "postgres"
+		}
+		if base == Driver {
+			return nil, fmt.Errorf("cache storage cannot wrap itself")
+		}
+		inner, err := storage.Open(ctx, base, conf, logger)
+		if err != nil {
+			return nil, err
+		}
+		cache, err := NewCacheStorage(ctx, inner, conf.Cache.FlushInterval, conf.Cache.MaxPending, logger)
+		if err != nil {
+			inner.Close(ctx)
+			return nil, err
+		}
+		return cache, nil
+	})
+}
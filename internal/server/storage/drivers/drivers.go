@@ -0,0 +1,10 @@
+// Package drivers registers the built-in storage backends, a new backend is added here with a blank import.
+package drivers
+
+import (
+	// register the built-in backends
+	_ "github.com/h2p2f/practicum-metrics/internal/server/storage/cachestorage"
+	_ "github.com/h2p2f/practicum-metrics/internal/server/storage/filestorage"
+	_ "github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+	_ "github.com/h2p2f/practicum-metrics/internal/server/storage/postgrestorage"
+)
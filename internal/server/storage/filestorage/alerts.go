@@ -0,0 +1,79 @@
+package filestorage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// alertsPath - method to get the path of the alert states file
+func (f *FileDB) alertsPath() string {
+	return f.FilePath + ".alerts"
+}
+
+// WriteAlerts writes the alert states to the file next to the snapshot.
+// The states are written to a temporary file, synced to the disk and renamed over the file.
+func (f *FileDB) WriteAlerts(ctx context.Context, alerts []models.Alert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	tmpPath := f.alertsPath() + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if err2 := file.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, f.alertsPath()); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(f.alertsPath()))
+}
+
+// ReadAlerts reads the alert states from the file, there are no states if the file does not exist.
+func (f *FileDB) ReadAlerts(ctx context.Context) ([]models.Alert, error) {
+	data, err := os.ReadFile(f.alertsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var alerts []models.Alert
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// LoadAlerts loads the alert states from the file.
+func (s *SnapshotStorage) LoadAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.file.ReadAlerts(ctx)
+}
+
+// SaveAlerts saves the alert states to the file.
+func (s *SnapshotStorage) SaveAlerts(ctx context.Context, alerts []models.Alert) error {
+	return s.file.WriteAlerts(ctx, alerts)
+}
+
+// LoadAlerts loads the alert states from the file.
+func (s *WALStorage) LoadAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.file.ReadAlerts(ctx)
+}
+
+// SaveAlerts saves the alert states to the file.
+func (s *WALStorage) SaveAlerts(ctx context.Context, alerts []models.Alert) error {
+	return s.file.WriteAlerts(ctx, alerts)
+}
@@ -0,0 +1,95 @@
+package filestorage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// Driver - the name of the file backend in the storage registry.
+// It persists the metrics of the base backend, the in-memory one by default, to the file.
+const Driver = "file"
+
+// defaultCompactInterval - the compaction period of the write-ahead log if the flush interval is not set
+const defaultCompactInterval = time.Minute
+
+func init() {
+	storage.Register(Driver, open)
+}
+
+// open - function to open the base backend and wrap it into the snapshot or the write-ahead log storage
+func open(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (storage.Storage, error) {
+	base := conf.File.Base
+	if base == "" {
+		base = "memory"
+	}
+	if base == Driver {
+		return nil, fmt.Errorf("file storage cannot wrap itself")
+	}
+	inner, err := storage.Open(ctx, base, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := inner.(Storage)
+	if !ok {
+		inner.Close(ctx)
+		return nil, fmt.Errorf("storage driver %q cannot be persisted to the file", base)
+	}
+	file := NewFileDB(conf.File.Path, conf.File.StoreInterval, logger)
+	file.Generations = conf.File.Generations
+	file.Compress = conf.File.Compress
+
+	if !conf.File.WAL {
+		snapshotDB := NewSnapshotStorage(ctx, db, file, logger)
+		if conf.File.Restore {
+			if err := snapshotDB.Restore(ctx); err != nil {
+				logger.Error("could not restore metrics from file", zap.Error(err))
+			}
+		}
+		return snapshotDB, nil
+	}
+
+	wal, err := OpenWAL(conf.File.Path+".wal", conf.File.WALSync, logger)
+	if err != nil {
+		inner.Close(ctx)
+		return nil, err
+	}
+	walDB := NewWALStorage(db, file, wal, logger)
+	if conf.File.Restore {
+		err = walDB.Restore(ctx)
+	} else {
+		// the compaction of the empty storage discards the saved snapshot and log
+		err = walDB.Compact(ctx)
+	}
+	if err != nil {
+		walDB.Close(ctx)
+		return nil, err
+	}
+	go wal.Run(ctx)
+	go compact(ctx, conf.File.StoreInterval, walDB, logger)
+	return walDB, nil
+}
+
+// compact - function for the periodic compaction of the write-ahead log
+func compact(ctx context.Context, interval time.Duration, walDB *WALStorage, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = defaultCompactInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := walDB.Compact(ctx); err != nil {
+				logger.Error("could not compact wal", zap.Error(err))
+			}
+		}
+	}
+}
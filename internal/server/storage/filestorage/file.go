@@ -3,19 +3,55 @@ package filestorage
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// formatVersion - the version of the snapshot format written by FileDB
+const formatVersion = 1
+
+// ErrCorruptedSnapshot - an error that occurs when the snapshot is truncated or its checksum does not match.
+var ErrCorruptedSnapshot = errors.New("corrupted snapshot")
+
 // FileDB - a structure that describes a metric store in a file.
+// Every snapshot is written to a temporary file and renamed over the file,
+// the previous Generations snapshots are kept in the files with the .1, .2, ... suffixes.
 type FileDB struct {
-	logger   *zap.Logger
-	File     *os.File
-	FilePath string
-	Interval time.Duration
+	logger      *zap.Logger
+	File        *os.File
+	FilePath    string
+	Interval    time.Duration
+	Generations int
+	Compress    bool
+}
+
+// Snapshot - the metrics saved to the file.
+// Seq is the sequence number of the last write-ahead log record included in the metrics, it is zero without the log.
+type Snapshot struct {
+	Seq     uint64
+	Metrics [][]byte
+}
+
+// header - the first line of the snapshot file, the checksum is the SHA-256 of the uncompressed metric lines
+type header struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Checksum  string    `json:"checksum"`
+	Count     int       `json:"count"`
+	Gzip      bool      `json:"gzip,omitempty"`
+	Seq       *uint64   `json:"wal_seq,omitempty"`
 }
 
 // NewFileDB is a function that returns a new fileDB
@@ -29,50 +65,252 @@ func NewFileDB(filePath string, interval time.Duration, logger *zap.Logger) *Fil
 
 // Write is a function that writes metrics to file
 func (f *FileDB) Write(ctx context.Context, metrics [][]byte) error {
-	var err error
-	f.File, err = os.OpenFile(f.FilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
-	defer func() {
-		if err2 := f.File.Close(); err2 != nil {
-			f.logger.Sugar().Fatalf("error while closing file on write: %s", err2)
-		}
-	}()
+	return f.WriteSnapshot(ctx, Snapshot{Metrics: metrics})
+}
+
+// WriteSnapshot is a function that writes the snapshot to file.
+// The snapshot is written to a temporary file and synced to the disk, the current file is kept as the first generation
+// and the temporary file is renamed over it, so a crash leaves either the previous or the new snapshot in place.
+func (f *FileDB) WriteSnapshot(ctx context.Context, snapshot Snapshot) error {
+	var payload bytes.Buffer
+	for _, metric := range snapshot.Metrics {
+		payload.Write(metric)
+		payload.WriteByte('\n')
+	}
+	sum := sha256.Sum256(payload.Bytes())
+	h := header{
+		Version:   formatVersion,
+		Timestamp: time.Now().UTC(),
+		Checksum:  hex.EncodeToString(sum[:]),
+		Count:     len(snapshot.Metrics),
+		Gzip:      f.Compress,
+	}
+	if snapshot.Seq > 0 {
+		h.Seq = &snapshot.Seq
+	}
+	line, err := json.Marshal(h)
 	if err != nil {
 		return err
 	}
-	for _, metric := range metrics {
-		_, err = f.File.Write(append(metric, '\n'))
-		if err != nil {
-			return err
-		}
+
+	tmpPath := f.FilePath + ".tmp"
+	f.File, err = os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err = writeSnapshot(f.File, line, payload.Bytes(), f.Compress); err != nil {
+		f.File.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = f.File.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = f.rotate(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, f.FilePath); err != nil {
+		return err
+	}
+	if err = syncDir(filepath.Dir(f.FilePath)); err != nil {
+		return err
 	}
 	f.logger.Sugar().Infof("saved to file - success")
 	return nil
 }
 
+// writeSnapshot - function to write the header line and the payload to the file and sync it
+func writeSnapshot(file *os.File, line, payload []byte, compress bool) error {
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if compress {
+		zw := gzip.NewWriter(file)
+		if _, err := zw.Write(payload); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	} else if _, err := file.Write(payload); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// rotate - method to shift the previous generations of the snapshot, the oldest one is dropped.
+// The current snapshot stays in place, it is linked or copied to the first generation,
+// so a crash before the new snapshot is renamed over it leaves the current one.
+func (f *FileDB) rotate() error {
+	if f.Generations <= 0 {
+		return nil
+	}
+	for i := f.Generations; i > 1; i-- {
+		if err := os.Rename(f.generation(i-1), f.generation(i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	tmpPath := f.generation(1) + ".tmp"
+	os.Remove(tmpPath)
+	if err := os.Link(f.FilePath, tmpPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		// the file system may not support the hard links
+		if err = copyFile(f.FilePath, tmpPath); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	return os.Rename(tmpPath, f.generation(1))
+}
+
+// copyFile - function to copy the file and sync the copy
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Sync()
+	}
+	if err2 := out.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// generation - method to get the path of the snapshot generation, zero is the current one
+func (f *FileDB) generation(i int) string {
+	if i == 0 {
+		return f.FilePath
+	}
+	return fmt.Sprintf("%s.%d", f.FilePath, i)
+}
+
+// syncDir - function to sync the directory, so the rename survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 // Read is a function that reads metrics from file
 func (f *FileDB) Read(ctx context.Context) ([][]byte, error) {
+	snapshot, err := f.ReadSnapshot(ctx)
+	return snapshot.Metrics, err
+}
+
+// ReadSnapshot is a function that reads the newest good snapshot.
+// A corrupted or truncated generation is logged and the previous one is read instead.
+// If no generation can be read, it returns the error of the current one.
+func (f *FileDB) ReadSnapshot(ctx context.Context) (Snapshot, error) {
+	var firstErr error
+	for i := 0; i <= f.Generations; i++ {
+		snapshot, err := f.readGeneration(f.generation(i))
+		if err == nil {
+			if i > 0 {
+				f.logger.Warn("restored from the previous snapshot generation", zap.String("path", f.generation(i)))
+			}
+			f.logger.Sugar().Infof("read from file - success")
+			return snapshot, nil
+		}
+		if !os.IsNotExist(err) {
+			f.logger.Error("could not read snapshot", zap.String("path", f.generation(i)), zap.Error(err))
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return Snapshot{}, firstErr
+}
+
+// readGeneration - method to read and verify one snapshot file
+func (f *FileDB) readGeneration(path string) (Snapshot, error) {
+	var snapshot Snapshot
 	var err error
-	_, err = os.Stat(f.FilePath)
-	if os.IsNotExist(err) {
-		return nil, err
+	f.File, err = os.Open(path)
+	if err != nil {
+		return snapshot, err
 	}
-	f.File, err = os.OpenFile(f.FilePath, os.O_RDONLY, 0755)
 	defer func() {
 		if err2 := f.File.Close(); err2 != nil {
 			f.logger.Sugar().Errorf("error while closing file on read: %s", err2)
 		}
 	}()
+	reader := bufio.NewReader(f.File)
+	line, err := reader.ReadBytes('\n')
 	if err != nil {
-		return nil, err
+		return snapshot, fmt.Errorf("%w: no header", ErrCorruptedSnapshot)
+	}
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil || h.Version == 0 {
+		// the snapshots written before the header have one metric per line
+		return readLegacy(io.MultiReader(bytes.NewReader(line), reader))
+	}
+	if h.Version > formatVersion {
+		return snapshot, fmt.Errorf("%w: unknown version %d", ErrCorruptedSnapshot, h.Version)
 	}
-	var metrics [][]byte
-	scan := bufio.NewScanner(f.File)
-	for {
-		if !scan.Scan() {
-			break
+	var body io.Reader = reader
+	if h.Gzip {
+		zr, err := gzip.NewReader(reader)
+		if err != nil {
+			return snapshot, fmt.Errorf("%w: %v", ErrCorruptedSnapshot, err)
 		}
-		metrics = append(metrics, scan.Bytes())
+		body = zr
+	}
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return snapshot, fmt.Errorf("%w: %v", ErrCorruptedSnapshot, err)
+	}
+	sum := sha256.Sum256(payload)
+	if hex.EncodeToString(sum[:]) != h.Checksum {
+		return snapshot, fmt.Errorf("%w: checksum mismatch", ErrCorruptedSnapshot)
+	}
+	for _, metric := range bytes.Split(payload, []byte{'\n'}) {
+		if len(metric) > 0 {
+			snapshot.Metrics = append(snapshot.Metrics, metric)
+		}
+	}
+	if len(snapshot.Metrics) != h.Count {
+		return snapshot, fmt.Errorf("%w: %d metrics, want %d", ErrCorruptedSnapshot, len(snapshot.Metrics), h.Count)
+	}
+	if h.Seq != nil {
+		snapshot.Seq = *h.Seq
+	}
+	return snapshot, nil
+}
+
+// readLegacy - function to read the snapshot without the header, every line must be a complete JSON value
+func readLegacy(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	scan := bufio.NewScanner(r)
+	for first := true; scan.Scan(); first = false {
+		if !json.Valid(scan.Bytes()) {
+			return Snapshot{}, fmt.Errorf("%w: invalid line %q", ErrCorruptedSnapshot, scan.Text())
+		}
+		if first {
+			var h header
+			if json.Unmarshal(scan.Bytes(), &h) == nil && h.Seq != nil {
+				snapshot.Seq = *h.Seq
+				continue
+			}
+		}
+		// the scanner reuses its buffer, the line is copied
+		snapshot.Metrics = append(snapshot.Metrics, append([]byte(nil), scan.Bytes()...))
+	}
+	if err := scan.Err(); err != nil {
+		return Snapshot{}, err
 	}
-	f.logger.Sugar().Infof("read from file - success")
-	return metrics, nil
+	return snapshot, nil
 }
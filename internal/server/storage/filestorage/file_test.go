@@ -0,0 +1,171 @@
+package filestorage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+func TestFileDBSnapshot(t *testing.T) {
+	ctx := context.Background()
+	first := Snapshot{Seq: 1, Metrics: [][]byte{[]byte(`{"id":"a","type":"gauge","value":1}`)}}
+	second := Snapshot{Seq: 2, Metrics: [][]byte{[]byte(`{"id":"a","type":"gauge","value":2}`), []byte(`{"id":"b","type":"counter","delta":3}`)}}
+
+	tests := []struct {
+		name     string
+		compress bool
+		damage   func(t *testing.T, path string)
+		want     Snapshot
+		wantErr  error
+	}{
+		{
+			name: "Test 1",
+			want: second,
+		},
+		{
+			name:     "Test 2",
+			compress: true,
+			want:     second,
+		},
+		{
+			name: "Test 3",
+			damage: func(t *testing.T, path string) {
+				truncate(t, path, 20)
+			},
+			want: first,
+		},
+		{
+			name:     "Test 4",
+			compress: true,
+			damage: func(t *testing.T, path string) {
+				truncate(t, path, 20)
+			},
+			want: first,
+		},
+		{
+			name: "Test 5",
+			damage: func(t *testing.T, path string) {
+				truncate(t, path, 20)
+				truncate(t, path+".1", 20)
+			},
+			wantErr: ErrCorruptedSnapshot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metrics.json")
+			f := NewFileDB(path, 0, zaptest.NewLogger(t))
+			f.Generations = 1
+			f.Compress = tt.compress
+			if err := f.WriteSnapshot(ctx, first); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.WriteSnapshot(ctx, second); err != nil {
+				t.Fatal(err)
+			}
+			if tt.damage != nil {
+				tt.damage(t, path)
+			}
+
+			got, err := f.ReadSnapshot(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadSnapshot() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadSnapshot() = %q, want %q", got.Metrics, tt.want.Metrics)
+			}
+		})
+	}
+}
+
+func TestFileDBReadLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	f := NewFileDB(path, 0, zaptest.NewLogger(t))
+
+	if err := os.WriteFile(path, []byte("{\"id\":\"a\",\"type\":\"gauge\",\"value\":1}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := f.Read(context.Background())
+	if err != nil || len(metrics) != 1 {
+		t.Errorf("Read() = %q, %v, want one metric", metrics, err)
+	}
+
+	// a partial line is rejected instead of breaking the restore
+	if err := os.WriteFile(path, []byte("{\"id\":\"a\",\"type\":\"gauge\",\"value\":1}\n{\"id\":\"b\",\"ty"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Read(context.Background()); !errors.Is(err, ErrCorruptedSnapshot) {
+		t.Errorf("Read() error = %v, want %v", err, ErrCorruptedSnapshot)
+	}
+}
+
+// TestFileDBRotate checks that the current snapshot stays in place until the new one is renamed over it.
+func TestFileDBRotate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	f := NewFileDB(path, 0, zaptest.NewLogger(t))
+	f.Generations = 2
+	for _, metric := range []string{`{"id":"a"}`, `{"id":"b"}`} {
+		if err := f.WriteSnapshot(ctx, Snapshot{Metrics: [][]byte{[]byte(metric)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the crash after the rotation
+	if err := f.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{`{"id":"b"}`, `{"id":"b"}`, `{"id":"a"}`} {
+		snapshot, err := f.readGeneration(f.generation(i))
+		if err != nil || len(snapshot.Metrics) != 1 || string(snapshot.Metrics[0]) != want {
+			t.Errorf("generation %d = %q, %v, want %s", i, snapshot.Metrics, err, want)
+		}
+	}
+}
+
+// truncate - function to cut off the end of the file
+func truncate(t *testing.T, path string, n int64) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-n); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileDBAlerts(t *testing.T) {
+	ctx := context.Background()
+	f := NewFileDB(filepath.Join(t.TempDir(), "metrics.json"), 0, zaptest.NewLogger(t))
+	alerts, err := f.ReadAlerts(ctx)
+	if err != nil || alerts != nil {
+		t.Fatalf("ReadAlerts() = %v, %v, want no states before the first write", alerts, err)
+	}
+	fired := time.Date(2024, 1, 10, 12, 5, 0, 0, time.UTC)
+	want := []models.Alert{{
+		Rule:     "LowMemory",
+		Labels:   models.Labels{"host": "a"},
+		State:    models.AlertFiring,
+		Value:    10,
+		ActiveAt: time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC),
+		FiredAt:  &fired,
+	}}
+	if err := f.WriteAlerts(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.ReadAlerts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAlerts() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package filestorage
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SnapshotStorage - the storage decorator that writes the snapshot of the storage to the file every interval.
+// The updates accepted after the last snapshot are lost on a crash, the write-ahead log mode keeps them.
+type SnapshotStorage struct {
+	Storage
+	logger *zap.Logger
+	file   *FileDB
+	done   chan struct{}
+}
+
+// NewSnapshotStorage creates a new instance of SnapshotStorage and starts writing the snapshots
+// every file interval until the context is done. If the interval is not positive, the snapshot is written only on close.
+func NewSnapshotStorage(ctx context.Context, storage Storage, file *FileDB, logger *zap.Logger) *SnapshotStorage {
+	s := &SnapshotStorage{
+		Storage: storage,
+		logger:  logger,
+		file:    file,
+		done:    make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// Restore restores the storage from the last good snapshot.
+func (s *SnapshotStorage) Restore(ctx context.Context) error {
+	metrics, err := s.file.Read(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Storage.RestoreFromSerialized(metrics)
+}
+
+// Save writes the snapshot of the storage to the file.
+func (s *SnapshotStorage) Save(ctx context.Context) error {
+	metrics, err := s.Storage.GetAllSerialized()
+	if err != nil {
+		s.logger.Error("skipped metrics that could not be serialized", zap.Error(err))
+	}
+	return s.file.Write(ctx, metrics)
+}
+
+// run - method to write the snapshots until the context is done or the storage is closed
+func (s *SnapshotStorage) run(ctx context.Context) {
+	if s.file.Interval <= 0 {
+		return
+	}
+	t := time.NewTicker(s.file.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-t.C:
+			if err := s.Save(ctx); err != nil {
+				s.logger.Error("could not write metrics to file", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Close writes the last snapshot and closes the storage.
+func (s *SnapshotStorage) Close(ctx context.Context) error {
+	close(s.done)
+	if err := s.Save(ctx); err != nil {
+		s.logger.Error("could not write metrics to file", zap.Error(err))
+	}
+	return s.Storage.Close(ctx)
+}
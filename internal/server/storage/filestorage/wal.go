@@ -0,0 +1,256 @@
+package filestorage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// The fsync policies of the write-ahead log.
+const (
+	// SyncAlways - every record is synced to the disk before the update is accepted
+	SyncAlways = "always"
+	// SyncInterval - the records are synced to the disk every syncInterval
+	SyncInterval = "interval"
+	// SyncNever - the records are synced to the disk by the operating system
+	SyncNever = "never"
+)
+
+// syncInterval - the period of the fsync with the interval policy
+const syncInterval = time.Second
+
+// ErrUnknownSyncPolicy - an error that occurs when the fsync policy of the write-ahead log is unknown.
+var ErrUnknownSyncPolicy = errors.New("unknown wal sync policy")
+
+// Record - one line of the write-ahead log with the metrics of an accepted update.
+// A batch of metrics is one record, so it is replayed all or nothing.
+type Record struct {
+	Seq       uint64          `json:"seq"`
+	Timestamp time.Time       `json:"ts"`
+	Metrics   []models.Metric `json:"metrics"`
+}
+
+// WAL - an append-only write-ahead log of the accepted updates,
+// it is replayed over the last snapshot on restore and truncated by the compaction.
+type WAL struct {
+	logger *zap.Logger
+	file   *os.File
+	path   string
+	policy string
+	seq    uint64
+	dirty  bool
+	mut    sync.Mutex
+}
+
+// OpenWAL opens the write-ahead log at the path with the fsync policy, the file is created if it does not exist.
+// An empty policy is SyncAlways.
+func OpenWAL(path, policy string, logger *zap.Logger) (*WAL, error) {
+	switch policy {
+	case "":
+		policy = SyncAlways
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSyncPolicy, policy)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &WAL{logger: logger, file: file, path: path, policy: policy}, nil
+}
+
+// Replay applies the records with the sequence number greater than after in the log order
+// and continues the numbering from the last of them.
+// A torn or corrupted record ends the log: it is logged and cut off together with the records after it.
+func (w *WAL) Replay(after uint64, apply func(Record) error) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.seq = after
+	var offset int64
+	reader := bufio.NewReader(w.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			break
+		}
+		var rec Record
+		if err != nil || json.Unmarshal(line, &rec) != nil {
+			w.logger.Warn("cut off the damaged tail of the wal", zap.String("path", w.path), zap.Int64("offset", offset))
+			break
+		}
+		offset += int64(len(line))
+		if rec.Seq <= w.seq {
+			continue
+		}
+		if err := apply(rec); err != nil {
+			w.logger.Error("could not replay wal record", zap.Uint64("seq", rec.Seq), zap.Error(err))
+		}
+		w.seq = rec.Seq
+	}
+	if err := w.file.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := w.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// Append writes the metrics to the log as one record and syncs it according to the policy.
+// It returns the sequence number of the record. If the record can not be written or synced,
+// the log is cut back to its previous end, so the failed record is not replayed.
+func (w *WAL) Append(ts time.Time, metrics ...models.Metric) (uint64, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	line, err := json.Marshal(Record{Seq: w.seq + 1, Timestamp: ts, Metrics: metrics})
+	if err != nil {
+		return 0, err
+	}
+	end, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = w.file.Write(append(line, '\n')); err == nil {
+		w.dirty = true
+		if w.policy == SyncAlways {
+			err = w.sync()
+		}
+	}
+	if err != nil {
+		w.rollback(end)
+		return 0, err
+	}
+	w.seq++
+	return w.seq, nil
+}
+
+// rollback - method to cut the log back to the end, the caller must hold the lock
+func (w *WAL) rollback(end int64) {
+	if err := w.file.Truncate(end); err != nil {
+		w.logger.Error("could not cut off the failed wal record", zap.Error(err))
+		return
+	}
+	if _, err := w.file.Seek(end, io.SeekStart); err != nil {
+		w.logger.Error("could not cut off the failed wal record", zap.Error(err))
+	}
+}
+
+// Seq returns the sequence number of the last record.
+func (w *WAL) Seq() uint64 {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.seq
+}
+
+// Truncate removes the records with the sequence number up to upTo, the numbering continues.
+// The records after upTo are copied to a new file that is synced and renamed over the log,
+// so a crash leaves either the previous or the truncated log.
+func (w *WAL) Truncate(upTo uint64) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var offset int64
+	reader := bufio.NewReader(w.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var rec Record
+		if json.Unmarshal(line, &rec) != nil || rec.Seq > upTo {
+			break
+		}
+		offset += int64(len(line))
+	}
+	if _, err := w.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	tmpPath := w.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, w.file); err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, w.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		// the log stays as it was, the appends continue at its end
+		if _, seekErr := w.file.Seek(0, io.SeekEnd); seekErr != nil {
+			w.logger.Error("could not seek wal", zap.Error(seekErr))
+		}
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		w.logger.Error("could not close truncated wal", zap.Error(err))
+	}
+	w.file = file
+	w.dirty = false
+	return syncDir(filepath.Dir(w.path))
+}
+
+// Sync syncs the written records to the disk.
+func (w *WAL) Sync() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.sync()
+}
+
+// sync - method to sync the file if it has changed, the caller must hold the lock
+func (w *WAL) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+// Run syncs the log every syncInterval until the context is done if the policy is SyncInterval.
+func (w *WAL) Run(ctx context.Context) {
+	if w.policy != SyncInterval {
+		return
+	}
+	t := time.NewTicker(syncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := w.Sync(); err != nil {
+				w.logger.Error("could not sync wal", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Close syncs and closes the log.
+func (w *WAL) Close() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if err := w.sync(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,174 @@
+package filestorage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/inmemorystorage"
+)
+
+func TestWALStorageRestore(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	labels := models.Labels{"host": "a"}
+
+	open := func() (*WALStorage, *WAL) {
+		wal, err := OpenWAL(path+".wal", SyncAlways, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := NewWALStorage(inmemorystorage.NewMemStorage(logger, 0), NewFileDB(path, 0, logger), wal, logger)
+		return db, wal
+	}
+
+	db, wal := open()
+	if err := db.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetCounter(ctx, "requests", labels, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delta := int64(3)
+	value := 1.5
+	batch := []models.Metric{
+		{ID: "requests", MType: "counter", Delta: &delta, Labels: labels},
+		{ID: "load", MType: "gauge", Value: &value},
+	}
+	if err := db.SetMetrics(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetGauge(ctx, "load", nil, 2.5); err != nil {
+		t.Fatal(err)
+	}
+	// the process stops without the compaction
+	if err := wal.file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, wal = open()
+	defer wal.Close()
+	if err := db.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if counter, err := db.GetCounter(ctx, "requests", labels); err != nil || counter != 5 {
+		t.Errorf("GetCounter() = %v, %v, want 5", counter, err)
+	}
+	if gauge, err := db.GetGauge(ctx, "load", nil); err != nil || gauge != 2.5 {
+		t.Errorf("GetGauge() = %v, %v, want 2.5", gauge, err)
+	}
+	if seq := wal.Seq(); seq != 3 {
+		t.Errorf("Seq() = %v, want 3", seq)
+	}
+}
+
+func TestWALReplayDamagedTail(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	path := filepath.Join(t.TempDir(), "metrics.json.wal")
+
+	wal, err := OpenWAL(path, SyncNever, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := 1.0
+	for i := 0; i < 2; i++ {
+		if _, err := wal.Append(time.Unix(0, 0), models.Metric{ID: "load", MType: "gauge", Value: &value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the last record is torn by a crash
+	if _, err := wal.file.WriteString(`{"seq":3,"ts":`); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wal, err = OpenWAL(path, SyncNever, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seqs []uint64
+	err = wal.Replay(1, func(rec Record) error {
+		seqs = append(seqs, rec.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 1 || seqs[0] != 2 {
+		t.Errorf("Replay() applied %v, want [2]", seqs)
+	}
+	// the next record is appended after the last good one
+	if seq, err := wal.Append(time.Unix(0, 0)); err != nil || seq != 3 {
+		t.Errorf("Append() = %v, %v, want 3", seq, err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[len(data)-1] != '\n' {
+		t.Errorf("wal ends with %q, want a complete record", data[len(data)-10:])
+	}
+}
+
+func TestWALTruncate(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	path := filepath.Join(t.TempDir(), "metrics.json.wal")
+
+	wal, err := OpenWAL(path, SyncAlways, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := wal.Append(time.Unix(0, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the record 3 is written after the snapshot of the record 2 is taken
+	if err := wal.Truncate(2); err != nil {
+		t.Fatal(err)
+	}
+	if seq, err := wal.Append(time.Unix(0, 0)); err != nil || seq != 4 {
+		t.Errorf("Append() = %v, %v, want 4", seq, err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wal, err = OpenWAL(path, SyncAlways, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	var seqs []uint64
+	err = wal.Replay(0, func(rec Record) error {
+		seqs = append(seqs, rec.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 2 || seqs[0] != 3 || seqs[1] != 4 {
+		t.Errorf("Replay() applied %v, want [3 4]", seqs)
+	}
+}
+
+func TestOpenWALUnknownPolicy(t *testing.T) {
+	_, err := OpenWAL(filepath.Join(t.TempDir(), "wal"), "sometimes", zaptest.NewLogger(t))
+	if err == nil {
+		t.Error("OpenWAL() error = nil, want ErrUnknownSyncPolicy")
+	}
+}
@@ -0,0 +1,166 @@
+package filestorage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// Storage - the storage whose metrics are persisted to the file, it must be able to serialize all its metrics.
+type Storage interface {
+	storage.Storage
+	GetAllSerialized() ([][]byte, error)
+	RestoreFromSerialized(data [][]byte) error
+}
+
+// WALStorage - the storage decorator that appends every update to the write-ahead log before it is applied.
+// An update is applied only after its record is written and synced according to the policy,
+// an update that the storage rejects is rejected in the same way when the log is replayed.
+// The compaction writes the snapshot of the storage to the file and truncates the log.
+// The read methods are served by the storage.
+type WALStorage struct {
+	Storage
+	logger *zap.Logger
+	file   *FileDB
+	wal    *WAL
+	// mut orders the updates in the storage and in the log, so the snapshot matches the sequence number
+	mut sync.Mutex
+	// compactMut serializes the compactions
+	compactMut sync.Mutex
+}
+
+// NewWALStorage creates a new instance of WALStorage.
+func NewWALStorage(storage Storage, file *FileDB, wal *WAL, logger *zap.Logger) *WALStorage {
+	return &WALStorage{
+		Storage: storage,
+		logger:  logger,
+		file:    file,
+		wal:     wal,
+	}
+}
+
+// Restore restores the storage from the last snapshot and replays the log records written after it.
+func (s *WALStorage) Restore(ctx context.Context) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	snapshot, err := s.file.ReadSnapshot(ctx)
+	if err != nil {
+		// without a snapshot the whole log is replayed
+		s.logger.Info("could not read snapshot", zap.Error(err))
+	}
+	if err := s.Storage.RestoreFromSerialized(snapshot.Metrics); err != nil {
+		return err
+	}
+	return s.wal.Replay(snapshot.Seq, func(rec Record) error {
+		return s.apply(ctx, rec)
+	})
+}
+
+// apply - method to apply the log record to the storage
+func (s *WALStorage) apply(ctx context.Context, rec Record) error {
+	if len(rec.Metrics) == 1 {
+		m := rec.Metrics[0]
+		switch {
+		case m.MType == "gauge" && m.Value != nil:
+			return s.Storage.SetGaugeAt(ctx, m.ID, m.Labels, *m.Value, rec.Timestamp)
+		case m.MType == "counter" && m.Delta != nil:
+			return s.Storage.SetCounterAt(ctx, m.ID, m.Labels, *m.Delta, rec.Timestamp)
+		}
+	}
+	return s.Storage.SetMetrics(ctx, rec.Metrics)
+}
+
+// SetGauge sets the gauge value and appends it to the log.
+func (s *WALStorage) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	return s.SetGaugeAt(ctx, name, labels, value, time.Now())
+}
+
+// SetGaugeAt sets the gauge value at the given time and appends it to the log.
+func (s *WALStorage) SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.append(ts, models.Metric{ID: name, MType: "gauge", Value: &value, Labels: labels}); err != nil {
+		return err
+	}
+	return s.Storage.SetGaugeAt(ctx, name, labels, value, ts)
+}
+
+// SetCounter adds the value to the counter and appends it to the log.
+func (s *WALStorage) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	return s.SetCounterAt(ctx, name, labels, value, time.Now())
+}
+
+// SetCounterAt adds the value to the counter at the given time and appends it to the log.
+func (s *WALStorage) SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.append(ts, models.Metric{ID: name, MType: "counter", Delta: &value, Labels: labels}); err != nil {
+		return err
+	}
+	return s.Storage.SetCounterAt(ctx, name, labels, value, ts)
+}
+
+// SetHistogram adds the observations of the histogram and appends it to the log.
+func (s *WALStorage) SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.append(time.Now(), models.Metric{ID: name, MType: "histogram", Histogram: &value, Labels: labels}); err != nil {
+		return err
+	}
+	return s.Storage.SetHistogram(ctx, name, labels, value)
+}
+
+// SetMetrics stores the batch of metrics and appends it to the log as one record.
+func (s *WALStorage) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.append(time.Now(), metrics...); err != nil {
+		return err
+	}
+	return s.Storage.SetMetrics(ctx, metrics)
+}
+
+// append - method to append the update to the log before it is applied, the caller must hold the lock.
+// If the append fails, the update is not applied.
+func (s *WALStorage) append(ts time.Time, metrics ...models.Metric) error {
+	if _, err := s.wal.Append(ts, metrics...); err != nil {
+		s.logger.Error("could not append to wal", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Compact writes the snapshot of the storage to the file and removes the records it includes from the log.
+// The updates wait only while the snapshot is taken, so it includes exactly the records up to its sequence number,
+// the file is written without the lock.
+func (s *WALStorage) Compact(ctx context.Context) error {
+	s.compactMut.Lock()
+	defer s.compactMut.Unlock()
+	s.mut.Lock()
+	metrics, err := s.Storage.GetAllSerialized()
+	snapshot := Snapshot{Seq: s.wal.Seq(), Metrics: metrics}
+	s.mut.Unlock()
+	if err != nil {
+		s.logger.Error("skipped metrics that could not be serialized", zap.Error(err))
+	}
+	if err := s.file.WriteSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+	return s.wal.Truncate(snapshot.Seq)
+}
+
+// Close compacts the log and closes it together with the storage.
+func (s *WALStorage) Close(ctx context.Context) error {
+	if err := s.Compact(ctx); err != nil {
+		s.logger.Error("could not compact wal", zap.Error(err))
+	}
+	if err := s.wal.Close(); err != nil {
+		return err
+	}
+	return s.Storage.Close(ctx)
+}
@@ -0,0 +1,35 @@
+package inmemorystorage
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// Driver - the name of the in-memory backend in the storage registry.
+const Driver = "memory"
+
+func init() {
+	storage.Register(Driver, func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (storage.Storage, error) {
+		policies, err := retention.FromConfig(conf.History)
+		if err != nil {
+			return nil, err
+		}
+		m := NewShardedMemStorage(logger, conf.History.Depth, conf.Storage.Shards)
+		m.SetRetention(policies)
+		// downsample the history in the background if the policies are set
+		if len(policies) > 0 {
+			go retention.Run(ctx, conf.History.CompactInterval, m, logger)
+		}
+		return m, nil
+	})
+}
+
+// Close releases the storage, the metrics kept in memory are lost.
+func (m *MemStorage) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package inmemorystorage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+)
+
+// ring is a bounded buffer of history points, the oldest point is overwritten when it is full.
+type ring struct {
+	points []models.Point
+	next   int
+	full   bool
+}
+
+// newRing creates a ring buffer with the given capacity.
+func newRing(depth int) *ring {
+	return &ring{points: make([]models.Point, depth)}
+}
+
+// add puts the point into the buffer.
+func (r *ring) add(p models.Point) {
+	r.points[r.next] = p
+	r.next++
+	if r.next == len(r.points) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// between returns a copy of the points in the time range [from, to] sorted by time.
+func (r *ring) between(from, to time.Time) []models.Point {
+	size := r.next
+	if r.full {
+		size = len(r.points)
+	}
+	result := make([]models.Point, 0, size)
+	for i := 0; i < size; i++ {
+		p := r.points[i]
+		if p.Timestamp.Before(from) || p.Timestamp.After(to) {
+			continue
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// drop removes the points older than before and returns them.
+func (r *ring) drop(before time.Time) []models.Point {
+	size, start := r.next, 0
+	if r.full {
+		size, start = len(r.points), r.next
+	}
+	var dropped, kept []models.Point
+	for i := 0; i < size; i++ {
+		p := r.points[(start+i)%len(r.points)]
+		if p.Timestamp.Before(before) {
+			dropped = append(dropped, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	r.points = make([]models.Point, len(r.points))
+	r.next = copy(r.points, kept) % len(r.points)
+	r.full = len(kept) == len(r.points)
+	return dropped
+}
+
+// history is the history of one series: the raw points and the rolled up points by their step.
+type history struct {
+	raw     *ring
+	rollups map[time.Duration][]models.Point
+}
+
+// newHistory creates a history with the raw buffer of the given capacity.
+func newHistory(depth int) *history {
+	return &history{raw: newRing(depth), rollups: make(map[time.Duration][]models.Point)}
+}
+
+// between returns a copy of the raw and the rolled up points in the time range [from, to].
+func (h *history) between(from, to time.Time) []models.Point {
+	points := h.raw.between(from, to)
+	for _, rollups := range h.rollups {
+		for _, p := range rollups {
+			if !p.Timestamp.Before(from) && !p.Timestamp.After(to) {
+				points = append(points, p)
+			}
+		}
+	}
+	return points
+}
+
+// compact moves the raw points older than the raw age of the policy to the first level,
+// the points of every level older than its age to the next one, and drops the points older than the last level.
+func (h *history) compact(p retention.Policy, now time.Time) {
+	expired := h.raw.drop(now.Add(-p.Raw))
+	for _, l := range p.Levels {
+		points := h.rollups[l.Step]
+		if len(expired) > 0 {
+			points = retention.Resample(append(points, expired...), l.Step)
+		}
+		i := sort.Search(len(points), func(i int) bool {
+			return !points[i].Timestamp.Before(now.Add(-l.Keep))
+		})
+		expired = points[:i]
+		if i == len(points) {
+			delete(h.rollups, l.Step)
+			continue
+		}
+		h.rollups[l.Step] = append([]models.Point(nil), points[i:]...)
+	}
+}
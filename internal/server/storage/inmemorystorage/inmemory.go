@@ -2,125 +2,462 @@
 package inmemorystorage
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
 	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
 )
 
+// defaultShards - the number of the shards used if it is not set
+const defaultShards = 32
+
+// MemStorage - the metric storage in memory. The series are split into shards by the hash of the metric name,
+// every shard has its own lock, so the updates of different metrics do not wait for each other.
+// A batch locks all its shards in the order of their indexes, the listings lock all shards in the same order,
+// so they see a batch either entirely or not at all.
 type MemStorage struct {
-	logger   *zap.Logger
-	gauges   map[string]float64
-	counters map[string]int64
-	mut      sync.RWMutex
+	logger       *zap.Logger
+	shards       []*shard
+	historyDepth int
+	retention    retention.Policies
+	// now is the clock of the timestamps and the history resolution, it is replaced in tests
+	now func() time.Time
+}
+
+// shard - the part of the storage with the series of the metric names hashed to it
+type shard struct {
+	mut              sync.RWMutex
+	gauges           map[string]float64
+	counters         map[string]int64
+	histograms       map[string]models.Histogram
+	gaugeHistory     map[string]*history
+	counterHistory   map[string]*history
+	histogramHistory map[string]*history
+}
+
+// NewMemStorage creates a new instance of MemStorage with the default number of shards.
+// historyDepth is the number of points kept in the history of each metric,
+// if it is not positive, the history is not recorded.
+func NewMemStorage(log *zap.Logger, historyDepth int) *MemStorage {
+	return NewShardedMemStorage(log, historyDepth, defaultShards)
+}
+
+// NewShardedMemStorage creates a new instance of MemStorage with the given number of shards,
+// if it is not positive, the default number is used.
+func NewShardedMemStorage(log *zap.Logger, historyDepth, shards int) *MemStorage {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	m := &MemStorage{
+		shards:       make([]*shard, shards),
+		historyDepth: historyDepth,
+		logger:       log,
+		now:          time.Now,
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard{
+			gauges:           make(map[string]float64),
+			counters:         make(map[string]int64),
+			histograms:       make(map[string]models.Histogram),
+			gaugeHistory:     make(map[string]*history),
+			counterHistory:   make(map[string]*history),
+			histogramHistory: make(map[string]*history),
+		}
+	}
+	return m
+}
+
+// shardIndex - method to get the index of the shard of the metric name, the name is hashed with FNV-1a
+func (m *MemStorage) shardIndex(name string) int {
+	hash := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		hash ^= uint32(name[i])
+		hash *= 16777619
+	}
+	return int(hash % uint32(len(m.shards)))
+}
+
+// shard - method to get the shard of the metric name
+func (m *MemStorage) shard(name string) *shard {
+	return m.shards[m.shardIndex(name)]
+}
+
+// rlockAll - method to lock all shards for reading in the order of their indexes
+func (m *MemStorage) rlockAll() {
+	for _, sh := range m.shards {
+		sh.mut.RLock()
+	}
+}
+
+// runlockAll - method to unlock all shards locked by rlockAll
+func (m *MemStorage) runlockAll() {
+	for _, sh := range m.shards {
+		sh.mut.RUnlock()
+	}
+}
+
+// record adds the point to the history of the metric, the caller must hold the lock of the shard.
+func (m *MemStorage) record(histories map[string]*history, name string, p models.Point) {
+	if m.historyDepth <= 0 {
+		return
+	}
+	h, ok := histories[name]
+	if !ok {
+		h = newHistory(m.historyDepth)
+		histories[name] = h
+	}
+	h.raw.add(p)
+}
+
+// SetRetention sets the retention policies of the history, it must be called before the storage is used.
+func (m *MemStorage) SetRetention(policies retention.Policies) {
+	m.retention = policies
 }
 
-// NewMemStorage creates a new instance of MemStorage.
-func NewMemStorage(log *zap.Logger) *MemStorage {
-	return &MemStorage{
-		gauges:   make(map[string]float64),
-		counters: make(map[string]int64),
-		logger:   log,
+// CompactHistory downsamples the history of the series by the retention policies.
+// The shards are compacted one by one, so the updates of the other shards are not blocked.
+func (m *MemStorage) CompactHistory(ctx context.Context, now time.Time) error {
+	if len(m.retention) == 0 {
+		return nil
 	}
+	for _, sh := range m.shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sh.mut.Lock()
+		for _, histories := range []map[string]*history{sh.gaugeHistory, sh.counterHistory, sh.histogramHistory} {
+			for key, h := range histories {
+				name, _, err := models.ParseSeriesKey(key)
+				if err != nil {
+					continue
+				}
+				if policy, ok := m.retention.For(name); ok {
+					h.compact(policy, now)
+				}
+			}
+		}
+		sh.mut.Unlock()
+	}
+	return nil
 }
 
-// SetGauges sets the gauge value for the given name.
-func (m *MemStorage) SetGauge(name string, value float64) {
-	m.mut.Lock()
-	defer m.mut.Unlock()
-	m.gauges[name] = value
+// SetGauges sets the gauge value for the series with the given name and labels.
+func (m *MemStorage) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	return m.SetGaugeAt(ctx, name, labels, value, m.now())
+}
+
+// SetGaugeAt sets the gauge value for the series and records it in the history at the given time.
+func (m *MemStorage) SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error {
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.Lock()
+	defer sh.mut.Unlock()
+	sh.gauges[key] = value
+	m.record(sh.gaugeHistory, key, models.Point{Timestamp: ts, Value: &value})
+	return nil
 }
 
 // SetCounter устанавливает значение counter для заданного имени.
 //
-// SetCounter sets the counter value for the given name.
-func (m *MemStorage) SetCounter(name string, value int64) {
-	m.mut.Lock()
-	defer m.mut.Unlock()
-	m.counters[name] = m.counters[name] + value
+// SetCounter sets the counter value for the series with the given name and labels.
+func (m *MemStorage) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	return m.SetCounterAt(ctx, name, labels, value, m.now())
+}
+
+// SetCounterAt adds the value to the counter of the series and records the accumulated value in the history at the given time.
+func (m *MemStorage) SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error {
+	_, err := m.AddCounterAt(ctx, name, labels, value, ts)
+	return err
+}
+
+// AddCounterAt adds the value to the counter of the series at the given time and returns the accumulated value.
+func (m *MemStorage) AddCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) (int64, error) {
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.Lock()
+	defer sh.mut.Unlock()
+	total := sh.counters[key] + value
+	sh.counters[key] = total
+	m.record(sh.counterHistory, key, models.Point{Timestamp: ts, Delta: &total})
+	return total, nil
+}
+
+// SetHistogram adds the observations of the histogram to the series with the given name and labels.
+// It returns an error if the histogram is invalid or its buckets differ from the stored ones.
+func (m *MemStorage) SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error {
+	if err := value.Validate(); err != nil {
+		return err
+	}
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.Lock()
+	defer sh.mut.Unlock()
+	total := sh.histograms[key]
+	total = total.Copy()
+	if err := total.Merge(value); err != nil {
+		return err
+	}
+	sh.histograms[key] = total
+	point := total.Copy()
+	m.record(sh.histogramHistory, key, models.Point{Timestamp: m.now(), Histogram: &point})
+	return nil
 }
 
-// GetGauge returns the value of the gauge with the given name.
+// SetMetrics stores the batch of metrics under the locks of its shards, the batch is applied all or nothing.
+// It returns an error without changes if a metric is invalid or a histogram cannot be merged.
+func (m *MemStorage) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	for i := range metrics {
+		if err := metrics[i].Validate(); err != nil {
+			return err
+		}
+	}
+	// lock the shards of the batch in the order of their indexes, so concurrent batches do not deadlock
+	locked := make([]bool, len(m.shards))
+	for _, metric := range metrics {
+		locked[m.shardIndex(metric.ID)] = true
+	}
+	for i, sh := range m.shards {
+		if locked[i] {
+			sh.mut.Lock()
+			defer sh.mut.Unlock()
+		}
+	}
+	// merge the histograms first, so a mismatch leaves the storage unchanged
+	histograms := make(map[string]models.Histogram)
+	for _, metric := range metrics {
+		if metric.MType != "histogram" {
+			continue
+		}
+		key := models.SeriesKey(metric.ID, metric.Labels)
+		total, ok := histograms[key]
+		if !ok {
+			stored := m.shard(metric.ID).histograms[key]
+			total = stored.Copy()
+		}
+		if err := total.Merge(*metric.Histogram); err != nil {
+			return err
+		}
+		histograms[key] = total
+	}
+	now := m.now()
+	for _, metric := range metrics {
+		key := models.SeriesKey(metric.ID, metric.Labels)
+		sh := m.shard(metric.ID)
+		switch metric.MType {
+		case "gauge":
+			value := *metric.Value
+			sh.gauges[key] = value
+			m.record(sh.gaugeHistory, key, models.Point{Timestamp: now, Value: &value})
+		case "counter":
+			total := sh.counters[key] + *metric.Delta
+			sh.counters[key] = total
+			m.record(sh.counterHistory, key, models.Point{Timestamp: now, Delta: &total})
+		case "histogram":
+			total, ok := histograms[key]
+			if !ok {
+				// the merged histogram of the series is stored once
+				continue
+			}
+			delete(histograms, key)
+			sh.histograms[key] = total
+			point := total.Copy()
+			m.record(sh.histogramHistory, key, models.Point{Timestamp: now, Histogram: &point})
+		}
+	}
+	return nil
+}
+
+// GetGauge returns the value of the gauge with the given name and labels.
 // If the gauge is not found, it returns 0 and an error.
-func (m *MemStorage) GetGauge(name string) (float64, error) {
-	m.mut.RLock()
-	defer m.mut.RUnlock()
-	value, ok := m.gauges[name]
+func (m *MemStorage) GetGauge(ctx context.Context, name string, labels models.Labels) (float64, error) {
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+	value, ok := sh.gauges[key]
 	if !ok {
 		return 0, servererrors.ErrNotFound
 	}
 	return value, nil
 }
 
-// GetCounter returns the counter value for the given name.
+// GetCounter returns the counter value for the given name and labels.
 // If the counter does not exist, it returns 0 and an error.
-func (m *MemStorage) GetCounter(name string) (int64, error) {
-	m.mut.RLock()
-	defer m.mut.RUnlock()
-	value, ok := m.counters[name]
+func (m *MemStorage) GetCounter(ctx context.Context, name string, labels models.Labels) (int64, error) {
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+	value, ok := sh.counters[key]
 	if !ok {
 		return 0, servererrors.ErrNotFound
 	}
 	return value, nil
 }
 
-// GetCounters returns all counters.
-func (m *MemStorage) GetCounters() map[string]int64 {
-	return m.counters
+// GetHistogram returns the histogram for the given name and labels.
+// If the histogram does not exist, it returns an empty histogram and an error.
+func (m *MemStorage) GetHistogram(ctx context.Context, name string, labels models.Labels) (models.Histogram, error) {
+	key := models.SeriesKey(name, labels)
+	sh := m.shard(name)
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+	value, ok := sh.histograms[key]
+	if !ok {
+		return models.Histogram{}, servererrors.ErrNotFound
+	}
+	return value.Copy(), nil
+}
+
+// GetCounters returns a copy of the counters whose labels match the filter, the map is keyed by the series key.
+// The copy is taken under the locks of all shards, so it is consistent with the batches.
+func (m *MemStorage) GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error) {
+	m.rlockAll()
+	defer m.runlockAll()
+	counters := make(map[string]int64)
+	for _, sh := range m.shards {
+		for key, value := range sh.counters {
+			if matches(key, filter) {
+				counters[key] = value
+			}
+		}
+	}
+	return counters, nil
+}
+
+// GetGauges returns a copy of the gauges whose labels match the filter, the map is keyed by the series key.
+// The copy is taken under the locks of all shards, so it is consistent with the batches.
+func (m *MemStorage) GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error) {
+	m.rlockAll()
+	defer m.runlockAll()
+	gauges := make(map[string]float64)
+	for _, sh := range m.shards {
+		for key, value := range sh.gauges {
+			if matches(key, filter) {
+				gauges[key] = value
+			}
+		}
+	}
+	return gauges, nil
+}
+
+// matches reports whether the labels of the series key match the filter.
+func matches(key string, filter models.Labels) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	_, labels, err := models.ParseSeriesKey(key)
+	return err == nil && labels.Matches(filter)
 }
 
-// GetGauges returns all gauges.
-func (m *MemStorage) GetGauges() map[string]float64 {
-	return m.gauges
+// GetHistory returns the points of the series recorded in the time range [from, to].
+// The points are resampled to the resolution that the retention policy of the metric keeps for the range.
+// If the series has no history, it returns an error, a series without points in the range has an empty history.
+func (m *MemStorage) GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error) {
+	sh := m.shard(name)
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+	var histories map[string]*history
+	switch mType {
+	case "gauge":
+		histories = sh.gaugeHistory
+	case "counter":
+		histories = sh.counterHistory
+	case "histogram":
+		histories = sh.histogramHistory
+	default:
+		return nil, servererrors.ErrNotFound
+	}
+	h, ok := histories[models.SeriesKey(name, labels)]
+	if !ok {
+		return nil, servererrors.ErrNotFound
+	}
+	points := retention.Resample(h.between(from, to), m.retention.Step(name, from, m.now()))
+	if points == nil {
+		points = []models.Point{}
+	}
+	return points, nil
 }
 
 // GetAllSerialized returns all metrics in serialized form.
-func (m *MemStorage) GetAllSerialized() [][]byte {
+// The metrics are read under the locks of all shards, so the snapshot is consistent with the batches.
+// The metrics that can not be serialized, such as a non-finite gauge, are skipped and returned in the error.
+func (m *MemStorage) GetAllSerialized() ([][]byte, error) {
 	var result [][]byte
-	var met models.Metric
-	m.mut.RLock()
-	defer m.mut.RUnlock()
-	for metric, value := range m.gauges {
-		met.ID = metric
-		met.MType = "gauge"
-		met.Value = &value
+	var errs []error
+	add := func(met models.Metric) {
 		out, err := json.Marshal(met)
 		if err != nil {
-			log.Fatal(err)
+			errs = append(errs, fmt.Errorf("%s %s: %w", met.MType, met.ID, err))
+			return
 		}
 		result = append(result, out)
 	}
-	for metric, value := range m.counters {
-		met.ID = metric
-		met.MType = "counter"
-		met.Delta = &value
-		out, err := json.Marshal(met)
-		if err != nil {
-			log.Fatal(err)
+	m.rlockAll()
+	defer m.runlockAll()
+	for _, sh := range m.shards {
+		for key, value := range sh.gauges {
+			value := value
+			met := serialized(key)
+			met.MType = "gauge"
+			met.Value = &value
+			add(met)
+		}
+		for key, value := range sh.counters {
+			value := value
+			met := serialized(key)
+			met.MType = "counter"
+			met.Delta = &value
+			add(met)
+		}
+		for key, value := range sh.histograms {
+			value := value
+			met := serialized(key)
+			met.MType = "histogram"
+			met.Histogram = &value
+			add(met)
 		}
-		result = append(result, out)
 	}
-	return result
+	return result, errors.Join(errs...)
+}
+
+// serialized - function to make a metric model from the series key
+func serialized(key string) models.Metric {
+	name, labels, err := models.ParseSeriesKey(key)
+	if err != nil {
+		return models.Metric{ID: key}
+	}
+	return models.Metric{ID: name, Labels: labels}
 }
 
 // RestoreFromSerialized restores all metrics from serialized form.
 func (m *MemStorage) RestoreFromSerialized(data [][]byte) error {
+	ctx := context.Background()
 
-	var met models.Metric
 	for _, value := range data {
+		var met models.Metric
 		err := json.Unmarshal(value, &met)
 		if err != nil {
 			return err
 		}
 		switch met.MType {
 		case "counter":
-			m.SetCounter(met.ID, *met.Delta)
+			err = m.SetCounter(ctx, met.ID, met.Labels, *met.Delta)
 		case "gauge":
-			m.SetGauge(met.ID, *met.Value)
+			err = m.SetGauge(ctx, met.ID, met.Labels, *met.Value)
+		case "histogram":
+			err = m.SetHistogram(ctx, met.ID, met.Labels, *met.Histogram)
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -129,6 +466,6 @@ func (m *MemStorage) RestoreFromSerialized(data [][]byte) error {
 // Ping checks the availability of the storage.
 // This is a stub that always returns an error.
 // Implemented for compatibility with the interface.
-func (m *MemStorage) Ping() error {
+func (m *MemStorage) Ping(ctx context.Context) error {
 	return servererrors.ErrNotImplemented
 }
@@ -0,0 +1,333 @@
+package inmemorystorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+)
+
+func TestMemStorageSetMetrics(t *testing.T) {
+	ctx := context.Background()
+	delta := int64(2)
+	value := 1.5
+	valid := models.Histogram{Bounds: []float64{1, 2}, Counts: []uint64{1, 0, 0}, Count: 1, Sum: 0.5}
+	mismatch := models.Histogram{Bounds: []float64{5}, Counts: []uint64{1, 0}, Count: 1, Sum: 1}
+	tests := []struct {
+		name        string
+		metrics     []models.Metric
+		wantErr     bool
+		wantCounter int64
+		wantGauge   float64
+	}{
+		{
+			name: "Test 1",
+			metrics: []models.Metric{
+				{ID: "requests", MType: "counter", Delta: &delta},
+				{ID: "requests", MType: "counter", Delta: &delta},
+				{ID: "load", MType: "gauge", Value: &value},
+				{ID: "latency", MType: "histogram", Histogram: &valid},
+			},
+			wantCounter: 4,
+			wantGauge:   1.5,
+		},
+		{
+			name: "Test 2",
+			metrics: []models.Metric{
+				{ID: "requests", MType: "counter", Delta: &delta},
+				{ID: "load", MType: "gauge", Value: &value},
+				{ID: "latency", MType: "histogram", Histogram: &valid},
+				{ID: "latency", MType: "histogram", Histogram: &mismatch},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewShardedMemStorage(zaptest.NewLogger(t), 10, 4)
+			err := m.SetMetrics(ctx, tt.metrics)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetMetrics() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			counter, err := m.GetCounter(ctx, "requests", nil)
+			if tt.wantErr {
+				// the failed batch leaves the storage unchanged
+				if !errors.Is(err, servererrors.ErrNotFound) {
+					t.Errorf("GetCounter() error = %v, want %v", err, servererrors.ErrNotFound)
+				}
+				return
+			}
+			if counter != tt.wantCounter {
+				t.Errorf("GetCounter() = %v, want %v", counter, tt.wantCounter)
+			}
+			if gauge, _ := m.GetGauge(ctx, "load", nil); gauge != tt.wantGauge {
+				t.Errorf("GetGauge() = %v, want %v", gauge, tt.wantGauge)
+			}
+			if points, _ := m.GetHistory(ctx, "histogram", "latency", nil, time.Time{}, time.Now().Add(time.Hour)); len(points) != 1 {
+				t.Errorf("histogram history has %d points, want 1", len(points))
+			}
+		})
+	}
+}
+
+func TestMemStorageListCopy(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemStorage(zaptest.NewLogger(t), 0)
+	if err := m.SetCounter(ctx, "requests", models.Labels{"host": "a"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetGauge(ctx, "load", models.Labels{"host": "b"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	counters, _ := m.GetCounters(ctx, nil)
+	for key := range counters {
+		counters[key] = 100
+	}
+	if counter, _ := m.GetCounter(ctx, "requests", models.Labels{"host": "a"}); counter != 1 {
+		t.Errorf("the listing changed the storage, counter = %v", counter)
+	}
+	if gauges, _ := m.GetGauges(ctx, models.Labels{"host": "a"}); len(gauges) != 0 {
+		t.Errorf("GetGauges() = %v, want no gauges", gauges)
+	}
+}
+
+// TestMemStorageGetAllSerialized checks that a metric that can not be serialized is skipped and reported.
+func TestMemStorageGetAllSerialized(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemStorage(zaptest.NewLogger(t), 0)
+	if err := m.SetGauge(ctx, "load", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetGauge(ctx, "broken", nil, math.NaN()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetCounter(ctx, "requests", nil, 2); err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := m.GetAllSerialized()
+	if err == nil {
+		t.Error("GetAllSerialized() error = nil, want the serialization error")
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("GetAllSerialized() returned %d metrics, want 2", len(metrics))
+	}
+	for _, value := range metrics {
+		var met models.Metric
+		if err := json.Unmarshal(value, &met); err != nil {
+			t.Fatal(err)
+		}
+		if met.ID == "broken" {
+			t.Errorf("GetAllSerialized() returned the non-serializable metric %s", value)
+		}
+	}
+}
+
+// TestMemStorageConcurrent checks the storage under the race detector:
+// the batches increment two counters of different metrics together, so every listing must see them equal.
+func TestMemStorageConcurrent(t *testing.T) {
+	ctx := context.Background()
+	m := NewShardedMemStorage(zaptest.NewLogger(t), 5, 8)
+	if m.shardIndex("left") == m.shardIndex("right") {
+		t.Fatal("the metrics of the test must be in different shards")
+	}
+	const (
+		writers = 8
+		updates = 200
+	)
+	one := int64(1)
+	batch := []models.Metric{
+		{ID: "left", MType: "counter", Delta: &one},
+		{ID: "right", MType: "counter", Delta: &one},
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				if err := m.SetMetrics(ctx, batch); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := m.SetGauge(ctx, fmt.Sprintf("gauge_%d", w), nil, float64(i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			counters, _ := m.GetCounters(ctx, nil)
+			if counters["left"] != counters["right"] {
+				t.Errorf("inconsistent listing: %v", counters)
+				return
+			}
+			m.GetGauges(ctx, nil)                                              //nolint:errcheck
+			m.GetAllSerialized()                                               //nolint:errcheck
+			m.GetHistory(ctx, "counter", "left", nil, time.Time{}, time.Now()) //nolint:errcheck
+		}
+	}()
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	for _, name := range []string{"left", "right"} {
+		if counter, _ := m.GetCounter(ctx, name, nil); counter != writers*updates {
+			t.Errorf("counter %s = %v, want %v", name, counter, writers*updates)
+		}
+	}
+	if gauges, _ := m.GetGauges(ctx, nil); len(gauges) != writers {
+		t.Errorf("GetGauges() returned %d gauges, want %d", len(gauges), writers)
+	}
+}
+
+func TestMemStorageCompactHistory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemStorage(zaptest.NewLogger(t), 2000)
+	m.SetRetention(retention.Policies{{
+		Pattern: "load",
+		Raw:     time.Hour,
+		Levels:  []retention.Level{{Step: time.Minute, Keep: 2 * time.Hour}, {Step: time.Hour, Keep: 4 * time.Hour}},
+	}})
+	now := time.Now().Truncate(time.Hour)
+	// the resolution of the history depends on the age of the range, so the clock is fixed
+	m.now = func() time.Time { return now }
+	// a point every 10 seconds for the last 5 hours
+	for ts := now.Add(-5 * time.Hour); ts.Before(now); ts = ts.Add(10 * time.Second) {
+		if err := m.SetGaugeAt(ctx, "load", nil, 1, ts); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetGaugeAt(ctx, "other", nil, 1, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.CompactHistory(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	sh := m.shard("load")
+	h := sh.gaugeHistory["load"]
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		// the last hour is raw
+		{name: "raw", got: len(h.raw.between(time.Time{}, now)), want: 360},
+		// the second hour is rolled up by minute
+		{name: "minute", got: len(h.rollups[time.Minute]), want: 60},
+		// the third and the fourth hours are rolled up by hour, the fifth one is dropped
+		{name: "hour", got: len(h.rollups[time.Hour]), want: 2},
+		// the metric without the policy keeps all raw points
+		{name: "other", got: len(m.shard("other").gaugeHistory["other"].raw.between(time.Time{}, now)), want: 1800},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s points = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+	hour := h.rollups[time.Hour][0]
+	if hour.Aggregate == nil || hour.Aggregate.Count != 360 || hour.Aggregate.Avg != 1 {
+		t.Errorf("hour rollup aggregate = %+v, want 360 points of 1", hour.Aggregate)
+	}
+
+	// the history of the range is resampled to the resolution of its age
+	points, err := m.GetHistory(ctx, "gauge", "load", nil, now.Add(-90*time.Minute), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 90 {
+		t.Errorf("GetHistory() returned %d points, want 90 points by minute", len(points))
+	}
+}
+
+func TestMemStorageGetHistory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemStorage(zaptest.NewLogger(t), 10)
+	now := time.Now()
+	if err := m.SetGaugeAt(ctx, "load", nil, 1, now); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		metric     string
+		from       time.Time
+		wantPoints int
+		wantErr    error
+	}{
+		{name: "Test 1", metric: "load", from: now.Add(-time.Minute), wantPoints: 1},
+		// the known series without points in the range has an empty history
+		{name: "Test 2", metric: "load", from: now.Add(time.Minute)},
+		{name: "Test 3", metric: "unknown", from: now.Add(-time.Minute), wantErr: servererrors.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := m.GetHistory(ctx, "gauge", tt.metric, nil, tt.from, now.Add(2*time.Minute))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetHistory() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (points == nil || len(points) != tt.wantPoints) {
+				t.Errorf("GetHistory() = %v, want %d points", points, tt.wantPoints)
+			}
+		})
+	}
+}
+
+// BenchmarkMemStorageParallel compares one lock with the sharded locks under parallel updates and reads.
+func BenchmarkMemStorageParallel(b *testing.B) {
+	ctx := context.Background()
+	labels := models.Labels{"host": "bench"}
+	names := make([]string, 256)
+	for i := range names {
+		names[i] = fmt.Sprintf("metric_%d", i)
+	}
+	for _, shards := range []int{1, defaultShards} {
+		b.Run(fmt.Sprintf("set_shards_%d", shards), func(b *testing.B) {
+			m := NewShardedMemStorage(zap.NewNop(), 0, shards)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if err := m.SetCounter(ctx, names[i%len(names)], labels, 1); err != nil {
+						b.Fatal(err)
+					}
+					i++
+				}
+			})
+		})
+		b.Run(fmt.Sprintf("mixed_shards_%d", shards), func(b *testing.B) {
+			m := NewShardedMemStorage(zap.NewNop(), 0, shards)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					name := names[i%len(names)]
+					if i%4 == 0 {
+						m.GetGauge(ctx, name, labels) //nolint:errcheck
+					} else if err := m.SetGauge(ctx, name, labels, float64(i)); err != nil {
+						b.Fatal(err)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
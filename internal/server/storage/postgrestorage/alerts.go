@@ -0,0 +1,70 @@
+package postgrestorage
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// alertQuery inserts the alert state, the table is cleared before the states are saved
+const alertQuery = `INSERT INTO alerts (id, rule, labels, severity, state, value, active_at, fired_at, resolved_at)
+		VALUES ($1, $2, $3::jsonb, $4, $5, $6, $7, $8, $9);`
+
+// SaveAlerts replaces the alert states in one transaction.
+func (pg *pg) SaveAlerts(ctx context.Context, alerts []models.Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.rollback(ctx, tx)
+	if _, err = tx.Exec(ctx, `DELETE FROM alerts;`); err != nil {
+		pg.logger.Sugar().Errorf("Error clearing alerts: %v", err)
+		return err
+	}
+	for _, a := range alerts {
+		_, err = tx.Exec(ctx, alertQuery, a.Key(), a.Rule, labelsJSON(a.Labels), a.Severity, a.State, a.Value,
+			a.ActiveAt, a.FiredAt, a.ResolvedAt)
+		if err != nil {
+			pg.logger.Sugar().Errorf("Error inserting alert: %v", err)
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// LoadAlerts returns the saved alert states.
+func (pg *pg) LoadAlerts(ctx context.Context) ([]models.Alert, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	query := `SELECT rule, labels, severity, state, value, active_at, fired_at, resolved_at FROM alerts ORDER BY id;`
+	rows, err := pg.pool.Query(ctx, query)
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error querying alerts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var alerts []models.Alert
+	for rows.Next() {
+		var a models.Alert
+		var labels []byte
+		if err = rows.Scan(&a.Rule, &labels, &a.Severity, &a.State, &a.Value, &a.ActiveAt, &a.FiredAt, &a.ResolvedAt); err != nil {
+			pg.logger.Sugar().Errorf("Error scanning alert: %v", err)
+			return nil, err
+		}
+		if err = json.Unmarshal(labels, &a.Labels); err != nil {
+			return nil, err
+		}
+		if len(a.Labels) == 0 {
+			a.Labels = nil
+		}
+		alerts = append(alerts, a)
+	}
+	if err = rows.Err(); err != nil {
+		pg.logger.Sugar().Errorf("Error reading alerts: %v", err)
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,39 @@
+package postgrestorage
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage"
+)
+
+// Driver - the name of the PostgreSQL backend in the storage registry.
+const Driver = "postgres"
+
+func init() {
+	storage.Register(Driver, func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (storage.Storage, error) {
+		policies, err := retention.FromConfig(conf.History)
+		if err != nil {
+			return nil, err
+		}
+		pgDB, err := NewPostgresDB(ctx, conf.DB, logger)
+		if err != nil {
+			return nil, err
+		}
+		pgDB.SetRetention(policies)
+		// apply the pending migrations if it is enabled
+		if conf.DB.AutoMigrate {
+			if err := pgDB.Create(); err != nil {
+				logger.Sugar().Errorf("Error creating DB: %s", err)
+			}
+		}
+		// downsample the history in the background if the policies are set
+		if len(policies) > 0 {
+			go retention.Run(ctx, conf.History.CompactInterval, pgDB, logger)
+		}
+		return pgDB, nil
+	})
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS metrics (
+    id text not null PRIMARY KEY,
+    mtype text not null,
+    delta bigint,
+    value double precision
+);
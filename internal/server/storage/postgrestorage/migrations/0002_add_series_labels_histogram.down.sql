@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS metrics_labels_idx;
+DELETE FROM metrics WHERE mtype = 'histogram';
+ALTER TABLE metrics DROP COLUMN IF EXISTS histogram;
+ALTER TABLE metrics DROP COLUMN IF EXISTS labels;
+ALTER TABLE metrics DROP COLUMN IF EXISTS name;
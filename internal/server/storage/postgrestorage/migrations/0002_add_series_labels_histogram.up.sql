@@ -0,0 +1,5 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS name text;
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS labels jsonb not null default '{}';
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS histogram jsonb;
+UPDATE metrics SET name = id WHERE name IS NULL;
+CREATE INDEX IF NOT EXISTS metrics_labels_idx ON metrics USING gin (labels);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS metric_points (
+    id text not null,
+    mtype text not null,
+    ts timestamptz not null,
+    delta bigint,
+    value double precision
+);
+ALTER TABLE metric_points ADD COLUMN IF NOT EXISTS histogram jsonb;
+CREATE INDEX IF NOT EXISTS metric_points_id_ts_idx ON metric_points (id, mtype, ts);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS metric_rollups (
+    id text not null,
+    mtype text not null,
+    step integer not null,
+    ts timestamptz not null,
+    delta bigint,
+    value double precision,
+    histogram jsonb,
+    min double precision,
+    max double precision,
+    sum double precision,
+    count bigint not null,
+    last double precision,
+    PRIMARY KEY (id, mtype, step, ts)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id text PRIMARY KEY,
+    rule text not null,
+    labels jsonb not null default '{}'::jsonb,
+    severity text not null default '',
+    state text not null,
+    value double precision not null,
+    active_at timestamptz not null,
+    fired_at timestamptz,
+    resolved_at timestamptz
+);
@@ -0,0 +1,207 @@
+// Package migrations implements versioned schema migrations of the PostgreSQL storage.
+// The migrations are SQL files compiled into the binary, named NNNN_description.up.sql and NNNN_description.down.sql.
+// The applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID - the key of the advisory lock that keeps concurrent servers from migrating at the same time
+const lockID = 7253110948
+
+// ErrInvalidMigrations - an error that occurs when the embedded migration files are inconsistent.
+var ErrInvalidMigrations = errors.New("invalid migrations")
+
+// Migration - one version of the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies and rolls back the migrations.
+type Migrator struct {
+	db         *sql.DB
+	logger     *zap.Logger
+	migrations []Migration
+}
+
+// New creates a migrator with the embedded migrations.
+func New(db *sql.DB, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, logger: logger, migrations: migrations}, nil
+}
+
+// load - function to read the migrations sorted by version, every version must have the up and down files
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("%w: bad file name %s", ErrInvalidMigrations, name)
+		}
+		prefix, description, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: bad version in %s", ErrInvalidMigrations, name)
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: description}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d has no up or down file", ErrInvalidMigrations, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("%w: version %d is missing", ErrInvalidMigrations, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// Up applies the pending migrations, every migration runs in its own transaction.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, current int) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the given number of the last applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *sql.Conn, current int) error {
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Version returns the last applied version, 0 if no migration was applied.
+func (m *Migrator) Version(ctx context.Context) (version int, err error) {
+	err = m.locked(ctx, func(_ *sql.Conn, current int) error {
+		version = current
+		return nil
+	})
+	return version, err
+}
+
+// locked - method to run the function on one connection under the advisory lock with the current version
+func (m *Migrator) locked(ctx context.Context, f func(conn *sql.Conn, current int) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, lockID); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err2 := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1);`, lockID); err2 != nil {
+			m.logger.Error("could not release migration lock", zap.Error(err2))
+		}
+	}()
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		    version integer not null PRIMARY KEY,
+		    name text not null,
+		    applied_at timestamptz not null default now()
+		    );`
+	if _, err = conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	var current int
+	query = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations;`
+	if err = conn.QueryRowContext(ctx, query).Scan(&current); err != nil {
+		return err
+	}
+	return f(conn, current)
+}
+
+// apply - method to run the migration script and record the version in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err2 := tx.Rollback(); err2 != nil && !errors.Is(err2, sql.ErrTxDone) {
+			m.logger.Error("could not roll back migration", zap.Error(err2))
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1;`, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	direction := "applied"
+	if !up {
+		direction = "rolled back"
+	}
+	m.logger.Info("migration "+direction, zap.Int("version", migration.Version), zap.String("name", migration.Name))
+	return nil
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "Test 1",
+			fsys: fstest.MapFS{
+				"0002_b.up.sql":   {Data: []byte("B")},
+				"0002_b.down.sql": {Data: []byte("-B")},
+				"0001_a.up.sql":   {Data: []byte("A")},
+				"0001_a.down.sql": {Data: []byte("-A")},
+			},
+			want: 2,
+		},
+		{
+			name: "Test 2",
+			fsys: fstest.MapFS{
+				"0001_a.up.sql": {Data: []byte("A")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test 3",
+			fsys: fstest.MapFS{
+				"0002_b.up.sql":   {Data: []byte("B")},
+				"0002_b.down.sql": {Data: []byte("-B")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test 4",
+			fsys: fstest.MapFS{
+				"first.up.sql":   {Data: []byte("A")},
+				"first.down.sql": {Data: []byte("-A")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.fsys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidMigrations) {
+					t.Errorf("load() error = %v, want %v", err, ErrInvalidMigrations)
+				}
+				return
+			}
+			if len(got) != tt.want {
+				t.Fatalf("load() got %d migrations, want %d", len(got), tt.want)
+			}
+			if got[0].Version != 1 || got[0].Name != "a" || got[0].Up != "A" || got[0].Down != "-A" {
+				t.Errorf("load() first migration = %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Error("no embedded migrations")
+	}
+}
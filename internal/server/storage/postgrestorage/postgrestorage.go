@@ -4,66 +4,317 @@ package postgrestorage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
-	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+	"github.com/h2p2f/practicum-metrics/internal/server/servererrors"
+	"github.com/h2p2f/practicum-metrics/internal/server/storage/postgrestorage/migrations"
 )
 
 type pg struct {
-	db     *sql.DB
-	logger *zap.Logger
+	pool          *pgxpool.Pool
+	logger        *zap.Logger
+	copyThreshold int
+	retention     retention.Policies
+}
+
+// labelsJSON - function to encode labels for the jsonb column
+func labelsJSON(labels models.Labels) string {
+	if len(labels) == 0 {
+		return "{}"
+	}
+	out, err := json.Marshal(labels)
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
+
+const (
+	// queryTimeout - the time limit of one query
+	queryTimeout = 1 * time.Second
+	// batchTimeout - the time limit of the batch transaction
+	batchTimeout = 5 * time.Second
+	// defaultCopyThreshold - the batch size from which the counters and gauges are copied through the staging table if it is not set
+	defaultCopyThreshold = 100
+)
+
+// counterQuery adds the delta to the counter, records the accumulated value in the history and returns it.
+// The upserts are prepared by the statement cache of pgx on their first use on the connection,
+// so a connection is opened before the schema is migrated.
+const counterQuery = `WITH upd AS (
+			INSERT INTO metrics (id, mtype, delta, name, labels) VALUES ($1, 'counter', $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE SET delta = metrics.delta + excluded.delta
+			RETURNING id, mtype, delta)
+		INSERT INTO metric_points (id, mtype, ts, delta) SELECT id, mtype, $5, delta FROM upd
+		RETURNING delta;`
+
+// gaugeQuery sets the gauge value and records it in the history.
+const gaugeQuery = `WITH upd AS (
+			INSERT INTO metrics (id, mtype, value, name, labels) VALUES ($1, 'gauge', $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE SET value = $2
+			RETURNING id, mtype, value)
+		INSERT INTO metric_points (id, mtype, ts, value) SELECT id, mtype, $5, value FROM upd;`
+
+// SetCounter sets the counter value of the series and records the accumulated value in the history.
+// The series is stored under its series key, the name and labels are kept for filtering.
+func (pg *pg) SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error {
+	return pg.SetCounterAt(ctx, name, labels, value, time.Now())
+}
+
+// SetCounterAt sets the counter value of the series and records the accumulated value in the history at the given time.
+func (pg *pg) SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error {
+	_, err := pg.AddCounterAt(ctx, name, labels, value, ts)
+	return err
 }
 
-// SetCounter sets the counter value by name.
-func (pg *pg) SetCounter(key string, value int64) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// AddCounterAt adds the value to the counter of the series at the given time and returns the accumulated value.
+func (pg *pg) AddCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) (total int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	mType := "counter"
-	query := `INSERT INTO metrics (id, mtype, delta) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET delta = metrics.delta + excluded.delta;`
-	_, err := pg.db.ExecContext(ctx, query, key, mType, value)
+	key := models.SeriesKey(name, labels)
+	err = pg.pool.QueryRow(ctx, counterQuery, key, value, name, labelsJSON(labels), ts).Scan(&total)
 	if err != nil {
 		pg.logger.Sugar().Errorf("Error inserting counter: %v", err)
 	}
+	return total, err
 }
 
-// SetGauge sets the gauge value by name.
-func (pg *pg) SetGauge(key string, value float64) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// SetGauge sets the gauge value of the series and records it in the history.
+func (pg *pg) SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error {
+	return pg.SetGaugeAt(ctx, name, labels, value, time.Now())
+}
+
+// SetGaugeAt sets the gauge value of the series and records it in the history at the given time.
+func (pg *pg) SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	mType := "gauge"
-	query := `INSERT INTO metrics (id, mtype, value) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET value = $3;`
-	_, err := pg.db.ExecContext(ctx, query, key, mType, value)
+	key := models.SeriesKey(name, labels)
+	_, err := pg.pool.Exec(ctx, gaugeQuery, key, value, name, labelsJSON(labels), ts)
 	if err != nil {
 		pg.logger.Sugar().Errorf("Error inserting gauge: %v", err)
 	}
+	return err
+}
+
+// SetHistogram adds the observations of the histogram to the series and records the accumulated histogram in the history.
+// The stored histogram is locked while it is merged, so concurrent updates are not lost.
+func (pg *pg) SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error {
+	if err := value.Validate(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error starting transaction: %v", err)
+		return err
+	}
+	defer pg.rollback(ctx, tx)
+	if err = pg.mergeHistogram(ctx, tx, name, labels, value, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetMetrics stores the batch of metrics in a single transaction, the batch is applied all or nothing.
+// The metrics are written in the order of the series keys, so concurrent batches lock the rows in the same order.
+// A batch of copyThreshold metrics or more is copied to a staging table and merged by one statement,
+// the updates of one series in such a batch are coalesced and get one point in the history.
+func (pg *pg) SetMetrics(ctx context.Context, metrics []models.Metric) error {
+	for i := range metrics {
+		if err := metrics[i].Validate(); err != nil {
+			return err
+		}
+	}
+	sorted := make([]models.Metric, len(metrics))
+	copy(sorted, metrics)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return models.SeriesKey(sorted[i].ID, sorted[i].Labels) < models.SeriesKey(sorted[j].ID, sorted[j].Labels)
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error starting transaction: %v", err)
+		return err
+	}
+	defer pg.rollback(ctx, tx)
+	now := time.Now()
+	if len(sorted) >= pg.copyThreshold {
+		err = pg.copyMetrics(ctx, tx, sorted, now)
+	} else {
+		err = pg.execMetrics(ctx, tx, sorted, now)
+	}
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error inserting batch: %v", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// execMetrics - method to write the metrics one by one with the prepared upserts within the transaction
+func (pg *pg) execMetrics(ctx context.Context, tx pgx.Tx, metrics []models.Metric, ts time.Time) error {
+	var err error
+	for _, metric := range metrics {
+		key := models.SeriesKey(metric.ID, metric.Labels)
+		switch metric.MType {
+		case "gauge":
+			_, err = tx.Exec(ctx, gaugeQuery, key, *metric.Value, metric.ID, labelsJSON(metric.Labels), ts)
+		case "counter":
+			_, err = tx.Exec(ctx, counterQuery, key, *metric.Delta, metric.ID, labelsJSON(metric.Labels), ts)
+		case "histogram":
+			err = pg.mergeHistogram(ctx, tx, metric.ID, metric.Labels, *metric.Histogram, ts)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stagingQuery creates the staging table of the batch, it is dropped when the transaction ends.
+const stagingQuery = `CREATE TEMP TABLE metrics_staging (LIKE metrics INCLUDING DEFAULTS) ON COMMIT DROP;`
+
+// mergeQuery merges the staged counters and gauges into the metrics and records them in the history.
+const mergeQuery = `WITH upd AS (
+			INSERT INTO metrics (id, mtype, delta, value, name, labels)
+			SELECT id, mtype, delta, value, name, labels FROM metrics_staging ORDER BY id
+			ON CONFLICT (id) DO UPDATE SET delta = metrics.delta + excluded.delta, value = excluded.value
+			RETURNING id, mtype, delta, value)
+		INSERT INTO metric_points (id, mtype, ts, delta, value) SELECT id, mtype, $1, delta, value FROM upd;`
+
+// copyMetrics - method to copy the coalesced counters and gauges to the staging table and merge them within the transaction,
+// the histograms are merged one by one
+func (pg *pg) copyMetrics(ctx context.Context, tx pgx.Tx, metrics []models.Metric, ts time.Time) error {
+	// the metrics are sorted, so the updates of one series are adjacent
+	var rows [][]any
+	for _, metric := range metrics {
+		key := models.SeriesKey(metric.ID, metric.Labels)
+		last := len(rows) - 1
+		switch metric.MType {
+		case "histogram":
+			if err := pg.mergeHistogram(ctx, tx, metric.ID, metric.Labels, *metric.Histogram, ts); err != nil {
+				return err
+			}
+		case "counter":
+			if last >= 0 && rows[last][0] == key && rows[last][1] == "counter" {
+				*rows[last][2].(*int64) += *metric.Delta
+				continue
+			}
+			rows = append(rows, []any{key, "counter", ptr(*metric.Delta), (*float64)(nil), metric.ID, labelsJSON(metric.Labels)})
+		case "gauge":
+			if last >= 0 && rows[last][0] == key && rows[last][1] == "gauge" {
+				rows[last][3] = ptr(*metric.Value)
+				continue
+			}
+			rows = append(rows, []any{key, "gauge", (*int64)(nil), ptr(*metric.Value), metric.ID, labelsJSON(metric.Labels)})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, stagingQuery); err != nil {
+		return err
+	}
+	columns := []string{"id", "mtype", "delta", "value", "name", "labels"}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"metrics_staging"}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, mergeQuery, ts)
+	return err
+}
+
+// ptr - function to get the pointer to the copy of the value
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// mergeHistogram - method to merge the histogram with the stored one and record it in the history within the transaction
+func (pg *pg) mergeHistogram(ctx context.Context, tx pgx.Tx, name string, labels models.Labels, value models.Histogram, ts time.Time) error {
+	key := models.SeriesKey(name, labels)
+	var (
+		stored []byte
+		total  models.Histogram
+	)
+	query := `SELECT histogram FROM metrics WHERE id = $1 FOR UPDATE;`
+	err := tx.QueryRow(ctx, query, key).Scan(&stored)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		pg.logger.Sugar().Errorf("Error scanning row: %v", err)
+		return err
+	}
+	if len(stored) > 0 {
+		if err = json.Unmarshal(stored, &total); err != nil {
+			return err
+		}
+	}
+	if err = total.Merge(value); err != nil {
+		return err
+	}
+	out, err := json.Marshal(total)
+	if err != nil {
+		return err
+	}
+	query = `INSERT INTO metrics (id, mtype, histogram, name, labels) VALUES ($1, 'histogram', $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET histogram = excluded.histogram;`
+	if _, err = tx.Exec(ctx, query, key, out, name, labelsJSON(labels)); err != nil {
+		pg.logger.Sugar().Errorf("Error inserting histogram: %v", err)
+		return err
+	}
+	query = `INSERT INTO metric_points (id, mtype, ts, histogram) VALUES ($1, 'histogram', $2, $3);`
+	if _, err = tx.Exec(ctx, query, key, ts, out); err != nil {
+		pg.logger.Sugar().Errorf("Error inserting histogram point: %v", err)
+		return err
+	}
+	return nil
 }
 
-// GetCounter returns the counter value by name.
-func (pg *pg) GetCounter(name string) (value int64, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// rollback - method to roll back the transaction if it was not committed
+func (pg *pg) rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		pg.logger.Sugar().Errorf("Error rolling back transaction: %v", err)
+	}
+}
+
+// GetCounter returns the counter value of the series.
+// If the counter is not found, it returns 0 and servererrors.ErrNotFound.
+func (pg *pg) GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	query := `SELECT delta FROM metrics WHERE id = $1;`
-	row := pg.db.QueryRowContext(ctx, query, name)
-	err = row.Scan(&value)
+	query := `SELECT delta FROM metrics WHERE id = $1 AND mtype = 'counter';`
+	err = pg.pool.QueryRow(ctx, query, models.SeriesKey(name, labels)).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, servererrors.ErrNotFound
+	}
 	if err != nil {
 		pg.logger.Sugar().Errorf("Error scanning row: %v", err)
-		return 0, nil
+		return 0, err
 	}
 	return value, nil
 }
 
-// GetGauge returns the gauge value by name.
-func (pg *pg) GetGauge(name string) (value float64, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// GetGauge returns the gauge value of the series.
+// If the gauge is not found, it returns 0 and servererrors.ErrNotFound.
+func (pg *pg) GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	query := `SELECT value FROM metrics WHERE id = $1;`
-	row := pg.db.QueryRowContext(ctx, query, name)
-	err = row.Scan(&value)
+	query := `SELECT value FROM metrics WHERE id = $1 AND mtype = 'gauge';`
+	err = pg.pool.QueryRow(ctx, query, models.SeriesKey(name, labels)).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, servererrors.ErrNotFound
+	}
 	if err != nil {
 		pg.logger.Sugar().Errorf("Error scanning row: %v", err)
 		return 0, err
@@ -71,144 +322,238 @@ func (pg *pg) GetGauge(name string) (value float64, err error) {
 	return value, nil
 }
 
-// GetCounters returns all counter values.
-func (pg *pg) GetCounters() map[string]int64 {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// GetHistogram returns the histogram of the series.
+func (pg *pg) GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	mType := "counter"
-	query := `SELECT id, delta FROM metrics WHERE mtype = $1;`
-	rows, err := pg.db.QueryContext(ctx, query, mType)
-	if rows.Err() != nil {
-		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
-		return nil
+	var stored []byte
+	query := `SELECT histogram FROM metrics WHERE id = $1 AND mtype = 'histogram';`
+	err = pg.pool.QueryRow(ctx, query, models.SeriesKey(name, labels)).Scan(&stored)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return value, servererrors.ErrNotFound
 	}
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			pg.logger.Sugar().Errorf("no row in result: %v", err)
-			return nil
-		} else {
-			pg.logger.Sugar().Errorf("Error querying rows: %v", err)
-			return nil
-		}
+		pg.logger.Sugar().Errorf("Error scanning row: %v", err)
+		return value, err
 	}
-	defer func() {
-		err2 := rows.Close()
-		if err2 != nil {
-			pg.logger.Sugar().Errorf("Error closing rows: %v", err2)
-		}
-	}()
+	err = json.Unmarshal(stored, &value)
+	return value, err
+}
+
+// GetCounters returns all counter values whose labels match the filter, keyed by the series key.
+func (pg *pg) GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	query := `SELECT id, delta FROM metrics WHERE mtype = 'counter' AND labels @> $1::jsonb;`
+	rows, err := pg.pool.Query(ctx, query, labelsJSON(filter))
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error querying rows: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
 	counters := make(map[string]int64)
 	for rows.Next() {
 		var key string
 		var value int64
-		err = rows.Scan(&key, &value)
-		if err != nil {
+		if err = rows.Scan(&key, &value); err != nil {
 			pg.logger.Sugar().Errorf("Error scanning row: %v", err)
-			return nil
+			return nil, err
 		}
 		counters[key] = value
 	}
-	return counters
+	if err = rows.Err(); err != nil {
+		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
+		return nil, err
+	}
+	return counters, nil
 }
 
-// GetGauges returns all gauge values.
-func (pg *pg) GetGauges() map[string]float64 {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+// GetGauges returns all gauge values whose labels match the filter, keyed by the series key.
+func (pg *pg) GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
 	defer cancel()
-	mType := "gauge"
-	query := `SELECT id, value FROM metrics WHERE mtype = $1;`
-	rows, err := pg.db.QueryContext(ctx, query, mType)
-	if rows.Err() != nil {
-		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
-		return nil
-	}
+	query := `SELECT id, value FROM metrics WHERE mtype = 'gauge' AND labels @> $1::jsonb;`
+	rows, err := pg.pool.Query(ctx, query, labelsJSON(filter))
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			pg.logger.Sugar().Errorf("no row in result: %v", err)
-			return nil
-		} else {
-			pg.logger.Sugar().Errorf("Error querying rows: %v", err)
-			return nil
-		}
+		pg.logger.Sugar().Errorf("Error querying rows: %v", err)
+		return nil, err
 	}
-	defer func() {
-		err2 := rows.Close()
-		if err2 != nil {
-			pg.logger.Sugar().Errorf("Error closing rows: %v", err2)
-		}
-	}()
+	defer rows.Close()
 	gauges := make(map[string]float64)
 	for rows.Next() {
 		var key string
 		var value float64
-		err = rows.Scan(&key, &value)
-		if err != nil {
+		if err = rows.Scan(&key, &value); err != nil {
 			pg.logger.Sugar().Errorf("Error scanning row: %v", err)
-			return nil
+			return nil, err
 		}
 		gauges[key] = value
 	}
-	return gauges
-}
-
-// NewPostgresDB creates a new instance of PostgresDB.
-func NewPostgresDB(param string, logger *zap.Logger) *pg {
-
-	db, err := sql.Open("pgx", param)
-	if err != nil {
-		// If the error is a connection exception, try to reconnect
-		//this code wrote for increment #13
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgerrcode.IsConnectionException(pgErr.Code) {
-			logger.Sugar().Errorf("Error opening database connection: %v, trying reconnect", err)
-			//time delta for reconnect is 1, 3, 5 seconds
-			waitTime := []time.Duration{1 * time.Second, 3 * time.Second, 5 * time.Second}
-			//try to reconnect
-			for _, v := range waitTime {
-				time.Sleep(v)
-				db, err = sql.Open("pgx", param)
-				if err == nil {
-					//if connection is successful, break the loop
-					break
-				}
+	if err = rows.Err(); err != nil {
+		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
+		return nil, err
+	}
+	return gauges, nil
+}
+
+// historyQuery selects the raw points and the rollups of the series in the time range.
+const historyQuery = `SELECT ts, delta, value, histogram, NULL::double precision, NULL::double precision, NULL::double precision, 0::bigint, NULL::double precision
+			FROM metric_points WHERE id = $1 AND mtype = $2 AND ts BETWEEN $3 AND $4
+		UNION ALL
+		SELECT ts, delta, value, histogram, min, max, sum, count, last
+			FROM metric_rollups WHERE id = $1 AND mtype = $2 AND ts BETWEEN $3 AND $4
+		ORDER BY 1;`
+
+// seriesExistsQuery checks whether the series is stored.
+const seriesExistsQuery = `SELECT EXISTS (SELECT 1 FROM metrics WHERE id = $1 AND mtype = $2);`
+
+// GetHistory returns the points of the series recorded in the time range [from, to].
+// If the series is unknown, it returns an error, a known series without points in the range has an empty history.
+// The points are resampled to the resolution that the retention policy of the metric keeps for the range.
+func (pg *pg) GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	query := historyQuery
+	rows, err := pg.pool.Query(ctx, query, models.SeriesKey(name, labels), mType, from, to)
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error querying history: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var points []models.Point
+	for rows.Next() {
+		var (
+			p                     models.Point
+			histogram             []byte
+			minV, maxV, sum, last *float64
+			count                 int64
+		)
+		err = rows.Scan(&p.Timestamp, &p.Delta, &p.Value, &histogram, &minV, &maxV, &sum, &count, &last)
+		if err != nil {
+			pg.logger.Sugar().Errorf("Error scanning row: %v", err)
+			return nil, err
+		}
+		// a rollup of the counter or gauge values has the aggregate
+		if count > 0 && minV != nil && maxV != nil && sum != nil && last != nil {
+			p.Aggregate = &models.Aggregate{Min: *minV, Max: *maxV, Avg: *sum / float64(count), Last: *last, Sum: *sum, Count: uint64(count)}
+		}
+		if len(histogram) > 0 {
+			p.Histogram = new(models.Histogram)
+			if err = json.Unmarshal(histogram, p.Histogram); err != nil {
+				return nil, err
 			}
 		}
+		points = append(points, p)
+	}
+	if err = rows.Err(); err != nil {
+		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
+		return nil, err
 	}
-	return &pg{db: db, logger: logger}
+	if len(points) == 0 {
+		// a known series without the points in the range has an empty history
+		var exists bool
+		err = pg.pool.QueryRow(ctx, seriesExistsQuery, models.SeriesKey(name, labels), mType).Scan(&exists)
+		if err != nil {
+			pg.logger.Sugar().Errorf("Error querying series: %v", err)
+			return nil, err
+		}
+		if !exists {
+			return nil, servererrors.ErrNotFound
+		}
+		return []models.Point{}, nil
+	}
+	return retention.Resample(points, pg.retention.Step(name, from, time.Now())), nil
 }
 
-// Close closes the database connection.
-func (pg *pg) Close() {
-	err := pg.db.Close()
+// NewPostgresDB creates a new instance of PostgresDB with a connection pool.
+// The pool connects lazily, its size, the connection lifetimes and the health check period are set by the config,
+// the options left zero keep the pgxpool defaults or the pool_* parameters of the DSN.
+func NewPostgresDB(ctx context.Context, conf config.DatabaseConfig, logger *zap.Logger) (*pg, error) {
+	poolConf, err := pgxpool.ParseConfig(conf.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	if conf.MaxConns > 0 {
+		poolConf.MaxConns = conf.MaxConns
+	}
+	if conf.MinConns > 0 {
+		poolConf.MinConns = conf.MinConns
+	}
+	if conf.MaxConnLifetime > 0 {
+		poolConf.MaxConnLifetime = conf.MaxConnLifetime
+	}
+	if conf.MaxConnIdleTime > 0 {
+		poolConf.MaxConnIdleTime = conf.MaxConnIdleTime
+	}
+	if conf.HealthCheckPeriod > 0 {
+		poolConf.HealthCheckPeriod = conf.HealthCheckPeriod
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
 	if err != nil {
-		pg.logger.Sugar().Errorf("Error closing database connection: %v", err)
+		return nil, err
 	}
+	copyThreshold := conf.CopyThreshold
+	if copyThreshold <= 0 {
+		copyThreshold = defaultCopyThreshold
+	}
+	return &pg{pool: pool, logger: logger, copyThreshold: copyThreshold}, nil
 }
 
-// Create creates the metrics table.
-func (pg *pg) Create() (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
+// Close closes the connections of the pool, it waits for the acquired connections to be released.
+func (pg *pg) Close(ctx context.Context) error {
+	pg.pool.Close()
+	return nil
+}
+
+// Create brings the schema up to date by applying the pending migrations.
+func (pg *pg) Create() error {
+	return pg.Migrate("up")
+}
 
-	query := `CREATE TABLE IF NOT EXISTS metrics (
-		    id text not null PRIMARY KEY,
-		    mtype text not null,
-		    delta bigint,
-		    value double precision
-		    );`
-	_, err = pg.db.ExecContext(ctx, query)
+// Migrate runs the migration command: up applies the pending migrations, down rolls back the last one
+// and version logs the current schema version.
+func (pg *pg) Migrate(command string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// the migrations run on a separate connection, the statements cached by the pool connections are re-prepared by pgx
+	// if the schema changes
+	db := stdlibDB(pg)
+	defer db.Close()
+	migrator, err := migrations.New(db, pg.logger)
 	if err != nil {
-		pg.logger.Sugar().Errorf("Error creating table: %v", err)
 		return err
 	}
-	pg.logger.Sugar().Info("Table metrics created successfully")
-	return nil
+	switch command {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx, 1)
+	case "version":
+		var version int
+		version, err = migrator.Version(ctx)
+		if err == nil {
+			pg.logger.Info("schema version", zap.Int("version", version))
+		}
+	default:
+		err = fmt.Errorf("unknown migrate command %q", command)
+	}
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error migrating database: %v", err)
+	}
+	return err
+}
+
+// stdlibDB - function to open a database/sql handle with the connection config of the pool
+func stdlibDB(pg *pg) *sql.DB {
+	return stdlib.OpenDB(*pg.pool.Config().ConnConfig)
 }
 
 // Ping checks the database connection.
-func (pg *pg) Ping() error {
-	ctx := context.Background()
-	err := pg.db.PingContext(ctx)
+func (pg *pg) Ping(ctx context.Context) error {
+	if err := pg.pool.Ping(ctx); err != nil {
+		pg.logger.Error("could not ping database", zap.Error(err))
+		return err
+	}
 	pg.logger.Sugar().Info("PingContext successfully")
-	return err
+	return nil
 }
@@ -0,0 +1,128 @@
+package postgrestorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/retention"
+)
+
+// compactTimeout - the time limit of the compaction of one policy
+const compactTimeout = time.Minute
+
+// rollupRawQuery moves the raw points older than $2 of the series $1 into the rollups of the step $3 seconds,
+// the rollups of an interval are merged with the ones written by the previous compactions.
+const rollupRawQuery = `WITH moved AS (
+			DELETE FROM metric_points WHERE id = ANY($1) AND ts < $2
+			RETURNING id, mtype, ts, delta, value, histogram, coalesce(value, delta::double precision) AS v)
+		INSERT INTO metric_rollups (id, mtype, step, ts, delta, value, histogram, min, max, sum, count, last)
+		SELECT id, mtype, $3::integer, to_timestamp(floor(extract(epoch FROM ts) / $3::integer) * $3::integer),
+			(array_agg(delta ORDER BY ts DESC))[1], (array_agg(value ORDER BY ts DESC))[1], (array_agg(histogram ORDER BY ts DESC))[1],
+			min(v), max(v), sum(v), count(*), (array_agg(v ORDER BY ts DESC))[1]
+		FROM moved GROUP BY 1, 2, 3, 4
+		ON CONFLICT (id, mtype, step, ts) DO UPDATE SET
+			delta = excluded.delta, value = excluded.value, histogram = excluded.histogram,
+			min = least(metric_rollups.min, excluded.min), max = greatest(metric_rollups.max, excluded.max),
+			sum = metric_rollups.sum + excluded.sum, count = metric_rollups.count + excluded.count, last = excluded.last;`
+
+// rollupQuery moves the rollups of the step $4 seconds older than $2 of the series $1 into the rollups of the step $3 seconds.
+const rollupQuery = `WITH moved AS (
+			DELETE FROM metric_rollups WHERE id = ANY($1) AND step = $4 AND ts < $2
+			RETURNING id, mtype, ts, delta, value, histogram, min, max, sum, count, last)
+		INSERT INTO metric_rollups (id, mtype, step, ts, delta, value, histogram, min, max, sum, count, last)
+		SELECT id, mtype, $3::integer, to_timestamp(floor(extract(epoch FROM ts) / $3::integer) * $3::integer),
+			(array_agg(delta ORDER BY ts DESC))[1], (array_agg(value ORDER BY ts DESC))[1], (array_agg(histogram ORDER BY ts DESC))[1],
+			min(min), max(max), sum(sum), sum(count), (array_agg(last ORDER BY ts DESC))[1]
+		FROM moved GROUP BY 1, 2, 3, 4
+		ON CONFLICT (id, mtype, step, ts) DO UPDATE SET
+			delta = excluded.delta, value = excluded.value, histogram = excluded.histogram,
+			min = least(metric_rollups.min, excluded.min), max = greatest(metric_rollups.max, excluded.max),
+			sum = metric_rollups.sum + excluded.sum, count = metric_rollups.count + excluded.count, last = excluded.last;`
+
+// SetRetention sets the retention policies of the history, it must be called before the storage is used.
+func (pg *pg) SetRetention(policies retention.Policies) {
+	pg.retention = policies
+}
+
+// CompactHistory downsamples the history of the series by the retention policies,
+// the series of every policy are compacted in a separate transaction.
+func (pg *pg) CompactHistory(ctx context.Context, now time.Time) error {
+	if len(pg.retention) == 0 {
+		return nil
+	}
+	series, err := pg.seriesByPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	for i, ids := range series {
+		if err := pg.compact(ctx, pg.retention[i], ids, now); err != nil {
+			pg.logger.Sugar().Errorf("Error compacting history: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// seriesByPolicy - method to group the series keys by the index of their retention policy
+func (pg *pg) seriesByPolicy(ctx context.Context) (map[int][]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	rows, err := pg.pool.Query(ctx, `SELECT id, name FROM metrics;`)
+	if err != nil {
+		pg.logger.Sugar().Errorf("Error querying rows: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	series := make(map[int][]string)
+	for rows.Next() {
+		var key, name string
+		if err = rows.Scan(&key, &name); err != nil {
+			pg.logger.Sugar().Errorf("Error scanning row: %v", err)
+			return nil, err
+		}
+		if i := pg.retention.Index(name); i >= 0 {
+			series[i] = append(series[i], key)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		pg.logger.Sugar().Errorf("Error reading from database: %v", err)
+		return nil, err
+	}
+	return series, nil
+}
+
+// compact - method to move the expired points of the series through the levels of the policy in one transaction,
+// the points older than the last level are dropped
+func (pg *pg) compact(ctx context.Context, policy retention.Policy, ids []string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, compactTimeout)
+	defer cancel()
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.rollback(ctx, tx)
+	// from is the step of the source level in seconds, zero for the raw points
+	from := 0
+	before := now.Add(-policy.Raw)
+	for _, l := range policy.Levels {
+		step := int(l.Step / time.Second)
+		if from == 0 {
+			_, err = tx.Exec(ctx, rollupRawQuery, ids, before, step)
+		} else {
+			_, err = tx.Exec(ctx, rollupQuery, ids, before, step, from)
+		}
+		if err != nil {
+			return err
+		}
+		from, before = step, now.Add(-l.Keep)
+	}
+	if from == 0 {
+		_, err = tx.Exec(ctx, `DELETE FROM metric_points WHERE id = ANY($1) AND ts < $2;`, ids, before)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM metric_rollups WHERE id = ANY($1) AND ts < $2 AND step = $3;`, ids, before, from)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,91 @@
+// Package storage contains the registry of the metric storage backends.
+// A backend registers itself by name in the init function of its package,
+// the server opens the one set by the storage.driver config key.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+	"github.com/h2p2f/practicum-metrics/internal/server/models"
+)
+
+// ErrUnknownDriver - an error that occurs when no backend is registered with the driver name.
+var ErrUnknownDriver = errors.New("unknown storage driver")
+
+// Storage - the metric storage used by the server.
+type Storage interface {
+	SetCounter(ctx context.Context, name string, labels models.Labels, value int64) error
+	SetGauge(ctx context.Context, name string, labels models.Labels, value float64) error
+	SetCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) error
+	SetGaugeAt(ctx context.Context, name string, labels models.Labels, value float64, ts time.Time) error
+	GetCounter(ctx context.Context, name string, labels models.Labels) (value int64, err error)
+	GetGauge(ctx context.Context, name string, labels models.Labels) (value float64, err error)
+	SetHistogram(ctx context.Context, name string, labels models.Labels, value models.Histogram) error
+	GetHistogram(ctx context.Context, name string, labels models.Labels) (value models.Histogram, err error)
+	SetMetrics(ctx context.Context, metrics []models.Metric) error
+	GetCounters(ctx context.Context, filter models.Labels) (map[string]int64, error)
+	GetGauges(ctx context.Context, filter models.Labels) (map[string]float64, error)
+	GetHistory(ctx context.Context, mType, name string, labels models.Labels, from, to time.Time) ([]models.Point, error)
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// CounterAdder - the storage that returns the accumulated value of the counter from the increment,
+// so the decorators get the exact change of the counter without reading it back.
+type CounterAdder interface {
+	AddCounterAt(ctx context.Context, name string, labels models.Labels, value int64, ts time.Time) (int64, error)
+}
+
+// Factory - the function that opens the storage with the server configuration.
+// The background work of the storage stops when the context is done.
+type Factory func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (Storage, error)
+
+var (
+	mut       sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes the backend available by the name.
+// It panics if the factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	mut.Lock()
+	defer mut.Unlock()
+	if factory == nil {
+		panic("storage: Register factory is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic("storage: Register called twice for driver " + name)
+	}
+	factories[name] = factory
+}
+
+// Open opens the storage of the registered backend.
+func Open(ctx context.Context, name string, conf *config.ServerConfig, logger *zap.Logger) (Storage, error) {
+	mut.RLock()
+	factory, ok := factories[name]
+	mut.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (registered: %v)", ErrUnknownDriver, name, Drivers())
+	}
+	return factory(ctx, conf, logger)
+}
+
+// Drivers returns the sorted names of the registered backends.
+func Drivers() []string {
+	mut.RLock()
+	defer mut.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/h2p2f/practicum-metrics/internal/server/config"
+)
+
+func TestOpen(t *testing.T) {
+	errOpen := errors.New("open failed")
+	Register("test-failing", func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (Storage, error) {
+		return nil, errOpen
+	})
+
+	tests := []struct {
+		name    string
+		driver  string
+		wantErr error
+	}{
+		{
+			name:    "Test 1",
+			driver:  "test-failing",
+			wantErr: errOpen,
+		},
+		{
+			name:    "Test 2",
+			driver:  "unknown",
+			wantErr: ErrUnknownDriver,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(context.Background(), tt.driver, &config.ServerConfig{}, zaptest.NewLogger(t))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	got := Drivers()
+	if i := sort.SearchStrings(got, "test-failing"); !sort.StringsAreSorted(got) || i == len(got) || got[i] != "test-failing" {
+		t.Errorf("Drivers() = %v, want sorted names with test-failing", got)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	factory := func(ctx context.Context, conf *config.ServerConfig, logger *zap.Logger) (Storage, error) {
+		return nil, nil
+	}
+	Register("test-twice", factory)
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() did not panic on the second call")
+		}
+	}()
+	Register("test-twice", factory)
+}